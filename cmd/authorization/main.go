@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"filmoteka/configs"
+	"filmoteka/database"
 	delivery_grpc "filmoteka/modules/authorization/delivery/grpc"
 	"filmoteka/modules/authorization/delivery/http"
 	"filmoteka/modules/authorization/usecase"
+	"filmoteka/pkg/logging"
+	"filmoteka/pkg/migrate"
+	"filmoteka/pkg/tracing"
 	"filmoteka/pkg/variables"
-	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "filmoteka/docs"
 )
@@ -25,56 +34,156 @@ import (
 // @name Authorization
 
 func main() {
-	logFile, err := os.Create("authorization.log")
+	err := run()
 	if err != nil {
-		fmt.Println("Error creating log file")
-		return
+		os.Exit(1)
+	}
+}
+
+// run starts the service and blocks until it is stopped, returning the error
+// a failed start or serve ended with once the deferred teardown is done
+func run() error {
+	// Used until the logging section of the config is read
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	args := os.Args[1:]
+	migrateCommand := len(args) > 0 && args[0] == variables.MigrateCommand
+	if migrateCommand {
+		args = args[1:]
+	}
+
+	configLoader, err := configs.GetAuthorizationConfigLoader(args)
+	if err != nil {
+		logger.Error(variables.ReadAuthConfigError, "error", err)
+		return err
 	}
 
-	logger := slog.New(slog.NewJSONHandler(logFile, nil))
-	authAppConfig, err := configs.ReadAuthAppConfig()
+	config, err := configLoader.Load()
 	if err != nil {
-		logger.Error(variables.ReadAuthConfigError, err.Error())
-		return
+		logger.Error(variables.ReadAuthConfigError, "error", err)
+		return err
+	}
+
+	if migrateCommand {
+		err = migrate.RunCommand(ctx, configLoader.Args(), config.Database, database.AuthMigrations(), variables.AuthMigrationsDir, os.Stdout, logger)
+		if err != nil {
+			logger.Error(variables.MigrateCommandError, "error", err)
+		}
+		return err
 	}
 
-	relationalDataBaseConfig, err := configs.ReadRelationalAuthDataBaseConfig()
+	logs, err := logging.GetLogging(config.App.Logging)
 	if err != nil {
-		logger.Error(variables.ReadAuthSqlConfigError, err.Error())
-		return
+		logger.Error(variables.LoggingInitError, "error", err)
+		return err
+	}
+	defer logs.Close()
+	logger = logs.Logger
+
+	reloader := configs.GetReloader(configLoader, config, logger)
+	reloader.OnReload(func(config *variables.AuthorizationConfig) {
+		err := logs.ApplyConfig(config.App.Logging)
+		if err != nil {
+			logger.Error(variables.ConfigReloadError, "error", err)
+		}
+	})
+
+	// The watch is waited for before the logs it reconfigures are closed
+	watched := make(chan struct{})
+	defer func() {
+		stop()
+		<-watched
+	}()
+	go func() {
+		defer close(watched)
+		reloader.Watch(ctx)
+	}()
+
+	if config.Database.AutoMigrate {
+		err = migrate.Up(ctx, config.Database, database.AuthMigrations(), logger)
+		if err != nil {
+			logger.Error(variables.MigrateCommandError, "error", err)
+			return err
+		}
 	}
 
-	cacheDatabaseConfig, err := configs.ReadCacheDatabaseConfig()
+	shutdownTracer, err := tracing.InitTracer(ctx, config.App.Tracing, variables.AuthServiceName)
 	if err != nil {
-		logger.Error(variables.ReadAuthCacheConfigError, err.Error())
-		return
+		logger.Error(variables.TracingExporterError, "error", err)
+		return err
 	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), variables.ShutdownTimeout)
+		defer cancel()
 
-	core, err := usecase.GetCore(relationalDataBaseConfig, cacheDatabaseConfig, logger)
+		err := shutdownTracer(flushCtx)
+		if err != nil {
+			logger.Error(variables.ShutdownError, "error", err)
+		}
+	}()
+
+	invalidations := usecase.GetInvalidationBroker(variables.InvalidationsBufferSize)
+
+	core, err := usecase.GetCore(&config.Database, &config.Cache, invalidations, func() time.Duration {
+		return reloader.Current().Session.TTL
+	}, logger)
 	if err != nil {
-		logger.Error(variables.CoreInitializeError, err)
-		return
+		logger.Error(variables.CoreInitializeError, "error", err)
+		return err
 	}
+	defer closeWithLog(core, logger)
 
-	grpcServer, err := delivery_grpc.NewServer(relationalDataBaseConfig, cacheDatabaseConfig, logger)
+	grpcServer, err := delivery_grpc.NewServer(&config.Database, &config.Cache, &config.Grpc, invalidations, logger)
 	if err != nil {
-		logger.Error(variables.ListenAndServeError)
-		return
+		logger.Error(variables.ListenAndServeError, "error", err)
+		return err
 	}
 
-	api := delivery.GetAuthorizationApi(core, logger)
+	api := delivery.GetAuthorizationApi(core, logs.Levels, reloader, logger)
 
 	errs := make(chan error, 2)
 	go func() {
-		errs <- api.ListenAndServe(authAppConfig)
+		errs <- api.ListenAndServe(&config.App)
 	}()
 
 	go func() {
 		errs <- grpcServer.ListenAndServeGrpc()
 	}()
 
-	err = <-errs
+	var serveErr error
+	select {
+	case serveErr = <-errs:
+		if serveErr != nil {
+			logger.Error(variables.ListenAndServeError, "error", serveErr)
+		}
+	case <-ctx.Done():
+		logger.Info(variables.ShutdownStartedMessage)
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), variables.ShutdownTimeout)
+	defer cancel()
+
+	// Stop taking user requests first, then let the films service drain its gRPC calls.
+	apiErr := api.Shutdown(shutdownCtx)
+	if apiErr != nil {
+		logger.Error(variables.ShutdownError, "error", apiErr)
+	}
+
+	grpcErr := grpcServer.Shutdown(shutdownCtx)
+	if grpcErr != nil {
+		logger.Error(variables.ShutdownError, "error", grpcErr)
+	}
+	logger.Info(variables.ShutdownFinishedMessage)
+	return errors.Join(serveErr, apiErr, grpcErr)
+}
+
+func closeWithLog(closer io.Closer, logger *slog.Logger) {
+	err := closer.Close()
 	if err != nil {
-		logger.Error(variables.ListenAndServeError, err.Error())
+		logger.Error(variables.ShutdownError, "error", err)
 	}
 }
@@ -1,14 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"filmoteka/configs"
+	"filmoteka/database"
 	"filmoteka/modules/films/delivery"
+	"filmoteka/modules/films/identity"
 	"filmoteka/modules/films/repository"
+	"filmoteka/modules/films/search"
 	"filmoteka/modules/films/usecase"
+	"filmoteka/pkg/health"
+	"filmoteka/pkg/logging"
+	"filmoteka/pkg/migrate"
+	"filmoteka/pkg/tracing"
 	"filmoteka/pkg/variables"
-	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
 // @title Films service
@@ -22,39 +35,176 @@ import (
 // @name Films
 
 func main() {
-	logFile, err := os.Create("films.log")
+	err := run()
 	if err != nil {
-		fmt.Println("Error creating log file")
-		return
+		os.Exit(1)
+	}
+}
+
+// run starts the service and blocks until it is stopped, returning the error
+// a failed start or serve ended with once the deferred teardown is done
+func run() error {
+	// Used until the logging section of the config is read
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	args := os.Args[1:]
+	migrateCommand := len(args) > 0 && args[0] == variables.MigrateCommand
+	if migrateCommand {
+		args = args[1:]
+	}
+
+	configLoader, err := configs.GetFilmsConfigLoader(args)
+	if err != nil {
+		logger.Error(variables.ReadFilmsConfigError, "error", err)
+		return err
+	}
+
+	config, err := configLoader.Load()
+	if err != nil {
+		logger.Error(variables.ReadFilmsConfigError, "error", err)
+		return err
 	}
 
-	logger := slog.New(slog.NewJSONHandler(logFile, nil))
+	if migrateCommand {
+		err = migrate.RunCommand(ctx, configLoader.Args(), config.Database, database.FilmsMigrations(), variables.FilmsMigrationsDir, os.Stdout, logger)
+		if err != nil {
+			logger.Error(variables.MigrateCommandError, "error", err)
+		}
+		return err
+	}
 
-	configFilms, err := configs.ReadFilmsAppConfig()
+	logs, err := logging.GetLogging(config.App.Logging)
 	if err != nil {
-		logger.Error(err.Error())
-		return
+		logger.Error(variables.LoggingInitError, "error", err)
+		return err
 	}
+	defer logs.Close()
+	logger = logs.Logger
 
-	relationalDataBaseConfig, err := configs.ReadRelationalFilmsDataBaseConfig()
+	reloader := configs.GetReloader(configLoader, config, logger)
+	reloader.OnReload(func(config *variables.FilmsConfig) {
+		err := logs.ApplyConfig(config.App.Logging)
+		if err != nil {
+			logger.Error(variables.ConfigReloadError, "error", err)
+		}
+	})
+
+	if config.Database.AutoMigrate {
+		err = migrate.Up(ctx, config.Database, database.FilmsMigrations(), logger)
+		if err != nil {
+			logger.Error(variables.MigrateCommandError, "error", err)
+			return err
+		}
+	}
+
+	shutdownTracer, err := tracing.InitTracer(ctx, config.App.Tracing, variables.FilmsServiceName)
 	if err != nil {
-		logger.Error(variables.ReadFilmsSqlConfigError, err.Error())
-		return
+		logger.Error(variables.TracingExporterError, "error", err)
+		return err
 	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), variables.ShutdownTimeout)
+		defer cancel()
+
+		err := shutdownTracer(flushCtx)
+		if err != nil {
+			logger.Error(variables.ShutdownError, "error", err)
+		}
+	}()
 
-	grpcConfig, err := configs.ReadGrpcConfig()
+	filmsRepository, err := repository.GetFilmRepository(config.Database, logger)
+	if err != nil {
+		logger.Error(variables.FilmsRepositoryError, "error", err)
+		return err
+	}
+	defer closeWithLog(filmsRepository, logger)
 
-	filmsRepository, err := repository.GetFilmRepository(*relationalDataBaseConfig, logger)
-	core := usecase.GetCore(*grpcConfig, filmsRepository, logger)
+	grpcIdentities, err := identity.GetGrpcIdentityProvider(config.Grpc, logger)
 	if err != nil {
-		logger.Error(variables.CoreInitializeError, err)
-		return
+		logger.Error(variables.CoreInitializeError, "error", err)
+		return err
 	}
+	defer closeWithLog(grpcIdentities, logger)
 
-	api := delivery.GetFilmsApi(core, logger)
+	identities := identity.GetCachingIdentityProvider(grpcIdentities, variables.IdentityCacheSize, variables.IdentityCacheTTL)
+
+	// Background loops run until ctx is done, they are waited for before the
+	// defers above close the logs, the repository and the identity provider
+	var background sync.WaitGroup
+	defer func() {
+		stop()
+		background.Wait()
+	}()
+	goBackground := func(loop func()) {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			loop()
+		}()
+	}
+
+	goBackground(func() {
+		reloader.Watch(ctx)
+	})
+	goBackground(func() {
+		grpcIdentities.WatchInvalidations(ctx, identities, variables.IdentityWatchRetryTimeout)
+	})
+
+	var searchIndex usecase.SearchIndex = repository.GetSqlSearchIndex(filmsRepository)
+	if config.Search.Backend == variables.SearchBackendMemory {
+		searchIndex = search.GetMemoryIndex()
+	}
+
+	core := usecase.GetCore(filmsRepository, identities, searchIndex, logger)
+	if config.Search.Backend == variables.SearchBackendMemory {
+		// Logged by the rebuild itself
+		_, _, err = core.RebuildSearchIndex(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	goBackground(func() {
+		core.RunTrashRetention(ctx, config.Trash.PurgeInterval, func() time.Duration {
+			return reloader.Current().Trash.Retention
+		})
+	})
+
+	api := delivery.GetFilmsApi(core, []health.IHealthChecker{filmsRepository, grpcIdentities}, logs.Levels, reloader, logger)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- api.ListenAndServe(&config.App)
+	}()
+
+	var serveErr error
+	select {
+	case serveErr = <-errs:
+		if serveErr != nil {
+			logger.Error(variables.ListenAndServeError, "error", serveErr)
+		}
+	case <-ctx.Done():
+		logger.Info(variables.ShutdownStartedMessage)
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), variables.ShutdownTimeout)
+	defer cancel()
+
+	err = api.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.Error(variables.ShutdownError, "error", err)
+	}
+	logger.Info(variables.ShutdownFinishedMessage)
+	return errors.Join(serveErr, err)
+}
 
-	err = api.ListenAndServe(configFilms)
+func closeWithLog(closer io.Closer, logger *slog.Logger) {
+	err := closer.Close()
 	if err != nil {
-		logger.Error(err.Error())
+		logger.Error(variables.ShutdownError, "error", err)
 	}
 }
@@ -5,59 +5,167 @@ import (
 	"filmoteka/pkg/variables"
 	"flag"
 	"fmt"
-	"gopkg.in/yaml.v2"
+	"io/fs"
 	"os"
-	"syscall"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
 )
 
-func readYAMLFile[T any](filePath string) (*T, error) {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("error reading YAML file: %w", err)
+// Loader builds a service config from defaults, the YAML file, FILMOTEKA_*
+// environment variables and command line flags, each source overriding the
+// previous one. Flags are parsed once, so Load can be repeated.
+type Loader[T any] struct {
+	path      string
+	pathIsSet bool
+	flags     map[string]string
+	args      []string
+	defaults  func() *T
+	validate  func(config *T, problems *problems)
+}
+
+func newLoader[T any](name string, defaultPath string, args []string, defaults func() *T, validate func(config *T, problems *problems)) (*Loader[T], error) {
+	loader := &Loader[T]{
+		flags:    make(map[string]string),
+		defaults: defaults,
+		validate: validate,
+	}
+
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	flagSet.StringVar(&loader.path, variables.ConfigPathFlag, defaultPath, "Path to the YAML config")
+	for _, field := range fieldsOf(defaults()) {
+		isBool := field.value.Kind() == reflect.Bool
+		loader.registerFlag(flagSet, field.path, isBool, "Overrides "+field.path+", env "+field.env)
+		if field.secret {
+			loader.registerFlag(flagSet, field.path+variables.ConfigSecretFileSuffix, false, "Reads "+field.path+" from file, env "+field.env+strings.ToUpper(variables.ConfigSecretFileSuffix))
+		}
 	}
 
-	var config T
-	err = yaml.Unmarshal(data, &config)
+	err := flagSet.Parse(args)
 	if err != nil {
-		return nil, fmt.Errorf("error unmarshalling YAML data: %w", err)
+		return nil, err
+	}
+
+	loader.args = flagSet.Args()
+	flagSet.Visit(func(setFlag *flag.Flag) {
+		if setFlag.Name == variables.ConfigPathFlag {
+			loader.pathIsSet = true
+		}
+	})
+	if path, ok := os.LookupEnv(variables.ConfigPathEnv); ok && !loader.pathIsSet {
+		loader.path = path
+		loader.pathIsSet = true
 	}
 
-	return &config, nil
+	return loader, nil
 }
 
-func ParseFlagsAndReadYAMLFile[T any](fileName string, defaultFilePath string, flags *flag.FlagSet) (*T, error) {
-	flag.Parse()
-	var path string
-	flag.StringVar(&path, fileName, defaultFilePath, "Путь к конфигу"+fileName)
+func (loader *Loader[T]) registerFlag(flagSet *flag.FlagSet, name string, isBool bool, usage string) {
+	flagSet.Var(&flagValue{name: name, isBool: isBool, values: loader.flags}, name, usage)
+}
 
-	config, err := readYAMLFile[T](path)
-	if ok := errors.As(err, &err); ok && err == syscall.ENOENT {
-		return nil, fmt.Errorf("Failed to parse '%s' from provided path: %w", fileName, err)
+func (loader *Loader[T]) Path() string {
+	return loader.path
+}
+
+// Args returns the arguments left after the flags, such as a subcommand.
+func (loader *Loader[T]) Args() []string {
+	return loader.args
+}
+
+// flagValue keeps the raw value until Load, so it goes through the same
+// parsing as env vars. Bool fields may be passed without a value.
+type flagValue struct {
+	name   string
+	isBool bool
+	values map[string]string
+}
+
+func (value *flagValue) String() string {
+	return ""
+}
+
+func (value *flagValue) Set(raw string) error {
+	value.values[value.name] = raw
+	return nil
+}
+
+func (value *flagValue) IsBoolFlag() bool {
+	return value.isBool
+}
+
+// Load returns an error listing every invalid field, not only the first one.
+func (loader *Loader[T]) Load() (*T, error) {
+	config := loader.defaults()
+
+	err := loader.readFile(config)
+	if err != nil {
+		return nil, err
 	}
 
+	var problems problems
+	fields := fieldsOf(config)
+	applyEnv(fields, &problems)
+	applyFlags(fields, loader.flags, &problems)
+	loader.validate(config, &problems)
+
+	if err := problems.err(); err != nil {
+		return nil, fmt.Errorf("%s:\n%w", variables.InvalidConfigError, err)
+	}
 	return config, nil
 }
 
-func ReadAuthAppConfig() (*variables.AppConfig, error) {
-	return ParseFlagsAndReadYAMLFile[variables.AppConfig]("auth_config_path", "configs/AuthorizationAppConfig.yml", flag.CommandLine)
-}
+// readFile tolerates a missing file only at the default path, so a service
+// can run from env vars alone while a mistyped -config still fails.
+func (loader *Loader[T]) readFile(config *T) error {
+	data, err := os.ReadFile(loader.path)
+	if errors.Is(err, fs.ErrNotExist) && !loader.pathIsSet {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", variables.ReadConfigFileError, err)
+	}
 
-func ReadGrpcConfig() (*variables.GrpcConfig, error) {
-	return ParseFlagsAndReadYAMLFile[variables.GrpcConfig]("grpc_config_path", "configs/GrpcConfig.yml", flag.CommandLine)
+	err = yaml.UnmarshalStrict(data, config)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", variables.ParseConfigFileError, loader.path, err)
+	}
+	return nil
 }
 
-func ReadFilmsAppConfig() (*variables.AppConfig, error) {
-	return ParseFlagsAndReadYAMLFile[variables.AppConfig]("films_config_path", "configs/FilmsAppConfig.yml", flag.CommandLine)
+func applyEnv(fields []field, problems *problems) {
+	for _, field := range fields {
+		if value, ok := os.LookupEnv(field.env); ok {
+			problems.add(field.path, field.set(value))
+		}
+		if !field.secret {
+			continue
+		}
+		if path, ok := os.LookupEnv(field.env + strings.ToUpper(variables.ConfigSecretFileSuffix)); ok {
+			problems.add(field.path, field.setFromFile(path))
+		}
+	}
 }
 
-func ReadRelationalAuthDataBaseConfig() (*variables.RelationalDataBaseConfig, error) {
-	return ParseFlagsAndReadYAMLFile[variables.RelationalDataBaseConfig]("sql_config_auth_path", "configs/AuthorizationSqlDataBaseConfig.yml", flag.CommandLine)
+func applyFlags(fields []field, flags map[string]string, problems *problems) {
+	for _, field := range fields {
+		if value, ok := flags[field.path]; ok {
+			problems.add(field.path, field.set(value))
+		}
+		if !field.secret {
+			continue
+		}
+		if path, ok := flags[field.path+variables.ConfigSecretFileSuffix]; ok {
+			problems.add(field.path, field.setFromFile(path))
+		}
+	}
 }
 
-func ReadRelationalFilmsDataBaseConfig() (*variables.RelationalDataBaseConfig, error) {
-	return ParseFlagsAndReadYAMLFile[variables.RelationalDataBaseConfig]("sql_config_films_path", "configs/FilmsSqlDataBaseConfig.yml", flag.CommandLine)
+func GetFilmsConfigLoader(args []string) (*Loader[variables.FilmsConfig], error) {
+	return newLoader(variables.FilmsServiceName, variables.FilmsConfigPath, args, defaultFilmsConfig, validateFilmsConfig)
 }
 
-func ReadCacheDatabaseConfig() (*variables.CacheDataBaseConfig, error) {
-	return ParseFlagsAndReadYAMLFile[variables.CacheDataBaseConfig]("cache_config_path", "configs/AuthorizationCacheDataBaseConfig.yml", flag.CommandLine)
+func GetAuthorizationConfigLoader(args []string) (*Loader[variables.AuthorizationConfig], error) {
+	return newLoader(variables.AuthServiceName, variables.AuthorizationConfigPath, args, defaultAuthorizationConfig, validateAuthorizationConfig)
 }
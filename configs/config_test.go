@@ -0,0 +1,164 @@
+package configs
+
+import (
+	"filmoteka/pkg/variables"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testConfig = `
+database:
+  user: "boss"
+`
+
+func writeTestFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// unsetEnv removes a variable for the test, restoring it afterwards
+func unsetEnv(t *testing.T, key string) {
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+func loadFilmsConfig(t *testing.T, args ...string) (*variables.FilmsConfig, error) {
+	t.Helper()
+	loader, err := GetFilmsConfigLoader(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loader.Load()
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		file bool
+		env  bool
+		flag bool
+		want int
+	}{
+		{"default", false, false, false, 5432},
+		{"file over default", true, false, false, 5433},
+		{"env over file", true, true, false, 5434},
+		{"flag over env", true, true, true, 5435},
+		{"flag over file", true, false, true, 5435},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content := testConfig
+			if test.file {
+				content += "  port: 5433\n"
+			}
+			args := []string{"-config", writeTestFile(t, "films.yml", content)}
+
+			unsetEnv(t, "FILMOTEKA_DATABASE_PORT")
+			if test.env {
+				t.Setenv("FILMOTEKA_DATABASE_PORT", "5434")
+			}
+			if test.flag {
+				args = append(args, "-database.port", "5435")
+			}
+
+			config, err := loadFilmsConfig(t, args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Database.Port != test.want {
+				t.Errorf("port = %d, want %d", config.Database.Port, test.want)
+			}
+			if config.Database.User != "boss" || config.Trash.PurgeInterval == 0 {
+				t.Errorf("config = %+v, want the file and the defaults kept for other fields", config)
+			}
+		})
+	}
+}
+
+func TestLoadBoolFlagsAndSecretFiles(t *testing.T) {
+	unsetEnv(t, "FILMOTEKA_DATABASE_PASSWORD")
+	t.Setenv("FILMOTEKA_DATABASE_PASSWORD_FILE", writeTestFile(t, "env-secret", "from env\n"))
+	configPath := writeTestFile(t, "films.yml", testConfig)
+
+	loader, err := GetFilmsConfigLoader([]string{"-config", configPath, "-database.auto_migrate", "up", "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args := loader.Args(); !slices.Equal(args, []string{"up", "2"}) {
+		t.Errorf("args = %q, want the subcommand after the flags", args)
+	}
+
+	config, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.Database.AutoMigrate {
+		t.Error("a bool flag without a value did not set the field")
+	}
+	if config.Database.Password != "from env" {
+		t.Errorf("password = %q, want the env secret file without its newline", config.Database.Password)
+	}
+
+	flagSecret := writeTestFile(t, "flag-secret", "from flag")
+	config, err = loadFilmsConfig(t, "-config", configPath, "-database.password_file", flagSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Database.Password != "from flag" {
+		t.Errorf("password = %q, want the flag secret file over the env one", config.Database.Password)
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	unsetEnv(t, variables.ConfigPathEnv)
+	t.Setenv("FILMOTEKA_DATABASE_USER", "boss")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"unknown key", []string{"-config", writeTestFile(t, "films.yml", testConfig+"  prot: 5433\n")}, variables.ParseConfigFileError},
+		{"missing file at a set path", []string{"-config", filepath.Join(t.TempDir(), "missing.yml")}, variables.ReadConfigFileError},
+		{"missing file at the default path", nil, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := loadFilmsConfig(t, test.args...)
+			if test.want == "" && err != nil {
+				t.Errorf("err = %v, want the defaults and env to be enough", err)
+			}
+			if test.want != "" && (err == nil || !strings.Contains(err.Error(), test.want)) {
+				t.Errorf("err = %v, want %s", err, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadCollectsEveryProblem(t *testing.T) {
+	t.Setenv("FILMOTEKA_DATABASE_MAX_OPEN_CONNS", "many")
+	configPath := writeTestFile(t, "films.yml", testConfig+`
+  port: 0
+  sslmode: "sometimes"
+trash:
+  purge_interval: 0s
+`)
+
+	_, err := loadFilmsConfig(t, "-config", configPath, "-search.backend", "elastic")
+	if err == nil {
+		t.Fatal("an invalid config was loaded")
+	}
+	for _, path := range []string{"database.max_open_conns", "database.port", "database.sslmode", "trash.purge_interval", "search.backend"} {
+		if !strings.Contains(err.Error(), path+":") {
+			t.Errorf("err = %v, want a problem with %s", err, path)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package configs
+
+import (
+	"filmoteka/pkg/variables"
+	"log/slog"
+	"time"
+)
+
+func defaultAppConfig(address string, logFile string) variables.AppConfig {
+	return variables.AppConfig{
+		Address: address,
+		Tracing: variables.TracingConfig{
+			Exporter:    variables.TracingExporterNone,
+			Endpoint:    "localhost:4317",
+			SampleRatio: 1,
+		},
+		Logging: variables.LoggingConfig{
+			Level:          slog.LevelInfo.String(),
+			Format:         variables.LogFormatJson,
+			Output:         variables.LogOutputFile,
+			File:           logFile,
+			MaxSizeMB:      100,
+			MaxAgeDays:     14,
+			MaxBackups:     10,
+			RotateInterval: 24 * time.Hour,
+		},
+	}
+}
+
+func defaultDataBaseConfig(dbName string) variables.RelationalDataBaseConfig {
+	return variables.RelationalDataBaseConfig{
+		DbName:       dbName,
+		Host:         "127.0.0.1",
+		Port:         5432,
+		Sslmode:      "prefer",
+		MaxOpenConns: 10,
+		Timer:        15,
+	}
+}
+
+func defaultGrpcConfig() variables.GrpcConfig {
+	return variables.GrpcConfig{
+		Host:           "localhost",
+		Port:           "50051",
+		ConnectionType: "tcp",
+	}
+}
+
+func defaultPaginationConfig() variables.PaginationConfig {
+	return variables.PaginationConfig{
+		DefaultPageSize: 10,
+		MaxPageSize:     100,
+	}
+}
+
+func defaultFilmsConfig() *variables.FilmsConfig {
+	return &variables.FilmsConfig{
+		App:        defaultAppConfig("127.0.0.1:8081", "films.log"),
+		Database:   defaultDataBaseConfig("films_service"),
+		Grpc:       defaultGrpcConfig(),
+		Pagination: defaultPaginationConfig(),
+		Trash: variables.TrashConfig{
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Search: variables.SearchConfig{
+			Backend: variables.SearchBackendPostgres,
+		},
+	}
+}
+
+func defaultAuthorizationConfig() *variables.AuthorizationConfig {
+	return &variables.AuthorizationConfig{
+		App:      defaultAppConfig("127.0.0.1:8080", "authorization.log"),
+		Database: defaultDataBaseConfig("auth_service"),
+		Cache: variables.CacheDataBaseConfig{
+			Addr:  "localhost:6379",
+			Timer: 15,
+		},
+		Grpc:       defaultGrpcConfig(),
+		Pagination: defaultPaginationConfig(),
+		Session: variables.SessionConfig{
+			TTL: 24 * time.Hour,
+		},
+	}
+}
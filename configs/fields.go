@@ -0,0 +1,121 @@
+package configs
+
+import (
+	"filmoteka/pkg/variables"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field is a leaf of a config struct, named after its YAML path.
+type field struct {
+	path       string
+	env        string
+	secret     bool
+	reloadable bool
+	value      reflect.Value
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func fieldsOf(config any) []field {
+	var fields []field
+	collectFields(reflect.ValueOf(config).Elem(), nil, false, &fields)
+	return fields
+}
+
+func collectFields(value reflect.Value, path []string, reloadable bool, fields *[]field) {
+	for i := 0; i < value.NumField(); i++ {
+		structField := value.Type().Field(i)
+		name, _, _ := strings.Cut(structField.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		// A reloadable struct makes all of its fields reloadable
+		fieldPath := append(append([]string{}, path...), name)
+		fieldReloadable := reloadable || structField.Tag.Get("reload") == "true"
+		if structField.Type.Kind() == reflect.Struct {
+			collectFields(value.Field(i), fieldPath, fieldReloadable, fields)
+			continue
+		}
+
+		*fields = append(*fields, field{
+			path:       strings.Join(fieldPath, "."),
+			env:        variables.ConfigEnvPrefix + strings.ToUpper(strings.Join(fieldPath, "_")),
+			secret:     structField.Tag.Get("secret") == "true",
+			reloadable: fieldReloadable,
+			value:      value.Field(i),
+		})
+	}
+}
+
+func (field field) setFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return field.set(strings.TrimRight(string(data), "\r\n"))
+}
+
+func (field field) set(raw string) error {
+	value := field.value
+
+	if value.Type() == durationType {
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(duration))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(parsed)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(parsed)
+	case reflect.Map:
+		// Maps are written as key=value pairs separated by commas
+		parsed := reflect.MakeMap(value.Type())
+		for _, pair := range strings.Split(raw, ",") {
+			if pair == "" {
+				continue
+			}
+			key, item, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("%s: %q", variables.ConfigMapPairError, pair)
+			}
+			parsed.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)), reflect.ValueOf(strings.TrimSpace(item)))
+		}
+		value.Set(parsed)
+	default:
+		return fmt.Errorf("%s: %s", variables.ConfigFieldTypeError, value.Kind())
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package configs
+
+import (
+	"context"
+	"filmoteka/pkg/variables"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Reloader holds the running config and rebuilds it when the file changes or
+// on SIGHUP. Fields tagged reload:"true" are swapped in with the rest of the
+// config in one step. Other changed fields keep their running value.
+type Reloader[T any] struct {
+	loader    *Loader[T]
+	current   atomic.Pointer[T]
+	mutex     sync.Mutex
+	callbacks []func(config *T)
+	logger    *slog.Logger
+}
+
+func GetReloader[T any](loader *Loader[T], config *T, logger *slog.Logger) *Reloader[T] {
+	reloader := &Reloader[T]{
+		loader: loader,
+		logger: logger,
+	}
+	reloader.current.Store(config)
+	return reloader
+}
+
+func (reloader *Reloader[T]) Current() *T {
+	return reloader.current.Load()
+}
+
+// OnReload callbacks run after the new config is stored. They must be
+// registered before Watch starts.
+func (reloader *Reloader[T]) OnReload(callback func(config *T)) {
+	reloader.callbacks = append(reloader.callbacks, callback)
+}
+
+func (reloader *Reloader[T]) Reload() error {
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+
+	candidate, err := reloader.loader.Load()
+	if err != nil {
+		reloader.logger.Error(variables.ConfigReloadError, "error", err)
+		return err
+	}
+
+	current := reloader.current.Load()
+	currentFields := fieldsOf(current)
+	for i, field := range fieldsOf(candidate) {
+		if field.reloadable || reflect.DeepEqual(field.value.Interface(), currentFields[i].value.Interface()) {
+			continue
+		}
+		reloader.logger.Warn(variables.ConfigRestartError, "field", field.path)
+		field.value.Set(currentFields[i].value)
+	}
+
+	reloader.current.Store(candidate)
+	for _, callback := range reloader.callbacks {
+		callback(candidate)
+	}
+	reloader.logger.Info(variables.ConfigReloadedMessage, "path", reloader.loader.Path())
+	return nil
+}
+
+// Watch reloads on SIGHUP and on writes to the config file until ctx is done.
+// The directory is watched rather than the file, since editors and config
+// maps replace the file instead of writing to it.
+func (reloader *Reloader[T]) Watch(ctx context.Context) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	var events chan fsnotify.Event
+	var watchErrors chan error
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		defer watcher.Close()
+		err = watcher.Add(filepath.Dir(reloader.loader.Path()))
+	}
+	if err != nil {
+		reloader.logger.Warn(variables.ConfigWatchError, "error", err)
+	} else {
+		events = watcher.Events
+		watchErrors = watcher.Errors
+	}
+
+	// Several events arrive for one save, so reload once they settle
+	debounce := time.NewTimer(variables.ConfigReloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	configName := filepath.Clean(reloader.loader.Path())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangups:
+			_ = reloader.Reload()
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if filepath.Clean(event.Name) == configName && event.Op != fsnotify.Chmod {
+				debounce.Reset(variables.ConfigReloadDebounce)
+			}
+		case err, ok := <-watchErrors:
+			if !ok {
+				watchErrors = nil
+				continue
+			}
+			reloader.logger.Warn(variables.ConfigWatchError, "error", err)
+		case <-debounce.C:
+			_ = reloader.Reload()
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package configs
+
+import (
+	"errors"
+	"filmoteka/pkg/variables"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strconv"
+)
+
+type problems struct {
+	errs []error
+}
+
+func (problems *problems) add(field string, err error) {
+	if err != nil {
+		problems.errs = append(problems.errs, fmt.Errorf("%s: %w", field, err))
+	}
+}
+
+func (problems *problems) check(ok bool, field string, message string) {
+	if !ok {
+		problems.errs = append(problems.errs, fmt.Errorf("%s: %s", field, message))
+	}
+}
+
+func (problems *problems) err() error {
+	return errors.Join(problems.errs...)
+}
+
+func validateFilmsConfig(config *variables.FilmsConfig, problems *problems) {
+	validateAppConfig("app", &config.App, problems)
+	validateDataBaseConfig("database", &config.Database, problems)
+	validateGrpcConfig("grpc", &config.Grpc, problems)
+	validatePaginationConfig("pagination", &config.Pagination, problems)
+
+	problems.check(config.Trash.Retention >= 0, "trash.retention", variables.ConfigNegativeError)
+	problems.check(config.Trash.PurgeInterval > 0, "trash.purge_interval", variables.ConfigPositiveError)
+	problems.check(slices.Contains(variables.SearchBackends, config.Search.Backend), "search.backend", variables.ConfigUnknownValueError)
+}
+
+func validateAuthorizationConfig(config *variables.AuthorizationConfig, problems *problems) {
+	validateAppConfig("app", &config.App, problems)
+	validateDataBaseConfig("database", &config.Database, problems)
+	validateCacheConfig("cache", &config.Cache, problems)
+	validateGrpcConfig("grpc", &config.Grpc, problems)
+	validatePaginationConfig("pagination", &config.Pagination, problems)
+	problems.check(config.Session.TTL > 0, "session.ttl", variables.ConfigPositiveError)
+}
+
+func validatePaginationConfig(prefix string, config *variables.PaginationConfig, problems *problems) {
+	problems.check(config.MaxPageSize > 0, prefix+".max_page_size", variables.ConfigPositiveError)
+	problems.check(config.DefaultPageSize > 0 && config.DefaultPageSize <= config.MaxPageSize, prefix+".default_page_size", variables.ConfigPageSizeError)
+}
+
+func validateAppConfig(prefix string, config *variables.AppConfig, problems *problems) {
+	_, _, err := net.SplitHostPort(config.Address)
+	problems.add(prefix+".address", err)
+
+	tracing := config.Tracing
+	problems.check(slices.Contains(variables.TracingExporters, tracing.Exporter), prefix+".tracing.exporter", variables.ConfigUnknownValueError)
+	problems.check(tracing.Exporter != variables.TracingExporterOtlp || tracing.Endpoint != "", prefix+".tracing.endpoint", variables.ConfigRequiredError)
+	problems.check(tracing.SampleRatio >= 0 && tracing.SampleRatio <= 1, prefix+".tracing.sample_ratio", variables.ConfigRatioError)
+
+	for permission, role := range config.Permissions {
+		problems.check(slices.Contains(variables.Permissions, permission), prefix+".permissions."+permission, variables.ConfigPermissionError)
+		problems.check(role == variables.UserRole || role == variables.AdminRole, prefix+".permissions."+permission, variables.ConfigRoleError)
+	}
+
+	logging := config.Logging
+	var level slog.Level
+	problems.add(prefix+".logging.level", level.UnmarshalText([]byte(logging.Level)))
+	for module, moduleLevel := range logging.Modules {
+		problems.add(prefix+".logging.modules."+module, level.UnmarshalText([]byte(moduleLevel)))
+	}
+	problems.check(slices.Contains(variables.LogFormats, logging.Format), prefix+".logging.format", variables.ConfigUnknownValueError)
+	problems.check(slices.Contains(variables.LogOutputs, logging.Output), prefix+".logging.output", variables.ConfigUnknownValueError)
+	problems.check(logging.Output == variables.LogOutputStdout || logging.File != "", prefix+".logging.file", variables.ConfigRequiredError)
+	problems.check(logging.MaxSizeMB >= 0, prefix+".logging.max_size_mb", variables.ConfigNegativeError)
+	problems.check(logging.MaxAgeDays >= 0, prefix+".logging.max_age_days", variables.ConfigNegativeError)
+	problems.check(logging.MaxBackups >= 0, prefix+".logging.max_backups", variables.ConfigNegativeError)
+	problems.check(logging.RotateInterval >= 0, prefix+".logging.rotate_interval", variables.ConfigNegativeError)
+}
+
+func validateDataBaseConfig(prefix string, config *variables.RelationalDataBaseConfig, problems *problems) {
+	problems.check(config.Host != "", prefix+".host", variables.ConfigRequiredError)
+	problems.check(config.User != "", prefix+".user", variables.ConfigRequiredError)
+	problems.check(config.DbName != "", prefix+".dbname", variables.ConfigRequiredError)
+	problems.check(config.Port > 0 && config.Port <= 65535, prefix+".port", variables.ConfigPortError)
+	problems.check(slices.Contains(variables.SslModes, config.Sslmode), prefix+".sslmode", variables.ConfigUnknownValueError)
+	problems.check(config.MaxOpenConns > 0, prefix+".max_open_conns", variables.ConfigPositiveError)
+	problems.check(config.Timer > 0, prefix+".timer", variables.ConfigPositiveError)
+}
+
+func validateCacheConfig(prefix string, config *variables.CacheDataBaseConfig, problems *problems) {
+	_, _, err := net.SplitHostPort(config.Addr)
+	problems.add(prefix+".addr", err)
+	problems.check(config.DbNumber >= 0, prefix+".db", variables.ConfigNegativeError)
+	problems.check(config.Timer > 0, prefix+".timer", variables.ConfigPositiveError)
+}
+
+func validateGrpcConfig(prefix string, config *variables.GrpcConfig, problems *problems) {
+	port, err := strconv.Atoi(config.Port)
+	problems.check(err == nil && port > 0 && port <= 65535, prefix+".port", variables.ConfigPortError)
+	problems.check(slices.Contains(variables.GrpcConnectionTypes, config.ConnectionType), prefix+".connection_type", variables.ConfigUnknownValueError)
+}
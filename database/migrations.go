@@ -0,0 +1,31 @@
+package database
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// Migrations are named <version>_<name>.up.sql and <version>_<name>.down.sql.
+var (
+	//go:embed migrations/auth/*.sql
+	authMigrations embed.FS
+
+	//go:embed migrations/films/*.sql
+	filmsMigrations embed.FS
+)
+
+func AuthMigrations() fs.FS {
+	return subtree(authMigrations, "migrations/auth")
+}
+
+func FilmsMigrations() fs.FS {
+	return subtree(filmsMigrations, "migrations/films")
+}
+
+func subtree(files embed.FS, dir string) fs.FS {
+	sub, err := fs.Sub(files, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
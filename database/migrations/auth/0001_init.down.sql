@@ -0,0 +1,8 @@
+ALTER TABLE profile_role
+    DROP CONSTRAINT IF EXISTS fk_profile,
+    DROP CONSTRAINT IF EXISTS fk_role;
+
+DROP TABLE IF EXISTS profile;
+DROP TABLE IF EXISTS role;
+DROP TABLE IF EXISTS password;
+DROP TABLE IF EXISTS profile_role;
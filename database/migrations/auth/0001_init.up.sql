@@ -0,0 +1,40 @@
+-- Tables may already exist when the database was created by the old scripts
+CREATE TABLE IF NOT EXISTS password (
+    id SERIAL PRIMARY KEY,
+    value BYTEA
+);
+
+CREATE TABLE IF NOT EXISTS role (
+    id SERIAL PRIMARY KEY,
+    value TEXT
+);
+
+CREATE TABLE IF NOT EXISTS profile_role (
+    id SERIAL PRIMARY KEY,
+    profile_id INT,
+    role_id INT
+);
+
+CREATE TABLE IF NOT EXISTS profile (
+    id SERIAL PRIMARY KEY,
+    login TEXT NOT NULL UNIQUE,
+    password_id INT NOT NULL,
+    profile_role_id INT,
+    CONSTRAINT fk_password FOREIGN KEY (password_id) REFERENCES password (id),
+    CONSTRAINT fk_profile_role FOREIGN KEY (profile_role_id) REFERENCES profile_role (id)
+);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_profile') THEN
+        ALTER TABLE profile_role ADD CONSTRAINT fk_profile FOREIGN KEY (profile_id) REFERENCES profile (id);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_role') THEN
+        ALTER TABLE profile_role ADD CONSTRAINT fk_role FOREIGN KEY (role_id) REFERENCES role (id);
+    END IF;
+END
+$$;
+
+INSERT INTO role (value)
+SELECT value FROM (VALUES ('user'), ('admin')) AS roles (value)
+WHERE NOT EXISTS (SELECT 1 FROM role);
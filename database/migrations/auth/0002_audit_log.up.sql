@@ -0,0 +1,25 @@
+CREATE TABLE audit_log (
+    id         BIGSERIAL PRIMARY KEY,
+    actor_id   BIGINT,
+    action     TEXT NOT NULL,
+    entity     TEXT NOT NULL,
+    entity_id  BIGINT NOT NULL,
+    changes    JSONB NOT NULL DEFAULT '{}',
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity, entity_id, id DESC);
+CREATE INDEX audit_log_actor_id_idx ON audit_log (actor_id, id DESC);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+-- The log is append-only, entries can never be changed or removed
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,21 @@
+-- Tables may already exist when the database was created by the old scripts
+CREATE TABLE IF NOT EXISTS film (
+    id SERIAL PRIMARY KEY,
+    name TEXT,
+    description TEXT,
+    rating float,
+    releaseDate DATE
+);
+
+CREATE TABLE IF NOT EXISTS actor (
+    id SERIAL PRIMARY KEY,
+    name TEXT,
+    gender TEXT,
+    birthdate DATE
+);
+
+CREATE TABLE IF NOT EXISTS film_actor (
+    id SERIAL PRIMARY KEY,
+    film_id INTEGER REFERENCES film (id),
+    actor_id INTEGER REFERENCES actor (id)
+);
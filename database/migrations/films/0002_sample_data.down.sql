@@ -0,0 +1,3 @@
+DELETE FROM film_actor WHERE id BETWEEN 1 AND 13;
+DELETE FROM film WHERE id BETWEEN 1 AND 10;
+DELETE FROM actor WHERE id BETWEEN 1 AND 10;
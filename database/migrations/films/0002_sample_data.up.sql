@@ -0,0 +1,46 @@
+INSERT INTO film (id, name, description, rating, releaseDate) VALUES
+    (1, 'Film 1', 'Description 1', 4.5, '2022-01-01'),
+    (2, 'Film 2', 'Description 2', 3.8, '2022-02-15'),
+    (3, 'Film 3', 'Description 3', 4.2, '2022-03-10'),
+    (4, 'Film 4', 'Description 4', 3.5, '2022-04-20'),
+    (5, 'Film 5', 'Description 5', 4.0, '2022-05-05'),
+    (6, 'Film 6', 'Description 6', 4.7, '2022-06-30'),
+    (7, 'Film 7', 'Description 7', 3.9, '2022-07-15'),
+    (8, 'Film 8', 'Description 8', 4.1, '2022-08-25'),
+    (9, 'Film 9', 'Description 9', 3.6, '2022-09-10'),
+    (10, 'Film 10', 'Description 10', 4.3, '2022-10-31')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO actor (id, name, gender, birthdate) VALUES
+    (1, 'Actor 1', 'Male', '1990-01-01'),
+    (2, 'Actor 2', 'Female', '1992-02-15'),
+    (3, 'Actor 3', 'Male', '1985-03-10'),
+    (4, 'Actor 4', 'Female', '1988-04-20'),
+    (5, 'Actor 5', 'Male', '1995-05-05'),
+    (6, 'Actor 6', 'Female', '1993-06-30'),
+    (7, 'Actor 7', 'Male', '1991-07-15'),
+    (8, 'Actor 8', 'Female', '1987-08-25'),
+    (9, 'Actor 9', 'Male', '1994-09-10'),
+    (10, 'Actor 10', 'Female', '1989-10-31')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO film_actor (id, film_id, actor_id) VALUES
+    (1, 1, 1),
+    (2, 1, 2),
+    (3, 2, 3),
+    (4, 2, 8),
+    (5, 2, 4),
+    (6, 3, 5),
+    (7, 3, 6),
+    (8, 4, 7),
+    (9, 4, 8),
+    (10, 4, 6),
+    (11, 5, 9),
+    (12, 5, 1),
+    (13, 5, 10)
+ON CONFLICT (id) DO NOTHING;
+
+-- Explicit ids do not advance the sequences
+SELECT setval(pg_get_serial_sequence('film', 'id'), (SELECT MAX(id) FROM film));
+SELECT setval(pg_get_serial_sequence('actor', 'id'), (SELECT MAX(id) FROM actor));
+SELECT setval(pg_get_serial_sequence('film_actor', 'id'), (SELECT MAX(id) FROM film_actor));
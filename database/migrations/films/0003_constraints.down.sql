@@ -0,0 +1,20 @@
+DROP INDEX IF EXISTS film_release_date_idx;
+DROP INDEX IF EXISTS film_rating_idx;
+DROP INDEX IF EXISTS film_name_idx;
+DROP INDEX IF EXISTS film_actor_actor_id_idx;
+
+ALTER TABLE film_actor
+    DROP CONSTRAINT IF EXISTS film_actor_film_id_actor_id_key,
+    DROP CONSTRAINT IF EXISTS film_actor_film_id_fkey,
+    DROP CONSTRAINT IF EXISTS film_actor_actor_id_fkey,
+    ADD CONSTRAINT film_actor_film_id_fkey FOREIGN KEY (film_id) REFERENCES film (id),
+    ADD CONSTRAINT film_actor_actor_id_fkey FOREIGN KEY (actor_id) REFERENCES actor (id),
+    ALTER COLUMN film_id DROP NOT NULL,
+    ALTER COLUMN actor_id DROP NOT NULL;
+
+ALTER TABLE actor
+    ALTER COLUMN name DROP NOT NULL;
+
+ALTER TABLE film
+    DROP CONSTRAINT IF EXISTS film_rating_check,
+    ALTER COLUMN name DROP NOT NULL;
@@ -0,0 +1,36 @@
+-- Clean up rows the new constraints would reject
+DELETE FROM film_actor WHERE film_id IS NULL OR actor_id IS NULL;
+
+DELETE FROM film_actor duplicate
+USING film_actor original
+WHERE duplicate.film_id = original.film_id
+  AND duplicate.actor_id = original.actor_id
+  AND duplicate.id > original.id;
+
+UPDATE film SET rating = LEAST(GREATEST(rating, 0), 10) WHERE rating < 0 OR rating > 10;
+UPDATE film SET name = '' WHERE name IS NULL;
+UPDATE actor SET name = '' WHERE name IS NULL;
+
+ALTER TABLE film
+    ALTER COLUMN name SET NOT NULL,
+    ADD CONSTRAINT film_rating_check CHECK (rating >= 0 AND rating <= 10);
+
+ALTER TABLE actor
+    ALTER COLUMN name SET NOT NULL;
+
+ALTER TABLE film_actor
+    ALTER COLUMN film_id SET NOT NULL,
+    ALTER COLUMN actor_id SET NOT NULL,
+    DROP CONSTRAINT IF EXISTS film_actor_film_id_fkey,
+    DROP CONSTRAINT IF EXISTS film_actor_actor_id_fkey,
+    ADD CONSTRAINT film_actor_film_id_fkey FOREIGN KEY (film_id) REFERENCES film (id) ON DELETE CASCADE,
+    ADD CONSTRAINT film_actor_actor_id_fkey FOREIGN KEY (actor_id) REFERENCES actor (id) ON DELETE CASCADE,
+    ADD CONSTRAINT film_actor_film_id_actor_id_key UNIQUE (film_id, actor_id);
+
+-- film_id lookups are served by the unique constraint index
+CREATE INDEX IF NOT EXISTS film_actor_actor_id_idx ON film_actor (actor_id);
+
+-- Columns used by sort_by
+CREATE INDEX IF NOT EXISTS film_name_idx ON film (name);
+CREATE INDEX IF NOT EXISTS film_rating_idx ON film (rating DESC);
+CREATE INDEX IF NOT EXISTS film_release_date_idx ON film (releaseDate);
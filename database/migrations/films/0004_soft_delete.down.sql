@@ -0,0 +1,9 @@
+-- Trashed rows would reappear as live ones, so they are purged first
+DELETE FROM film WHERE deleted_at IS NOT NULL;
+DELETE FROM actor WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS actor_deleted_at_idx;
+DROP INDEX IF EXISTS film_deleted_at_idx;
+
+ALTER TABLE actor DROP COLUMN deleted_at;
+ALTER TABLE film DROP COLUMN deleted_at;
@@ -0,0 +1,6 @@
+ALTER TABLE film ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE actor ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Trash listing and the retention job only look at deleted rows
+CREATE INDEX film_deleted_at_idx ON film (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX actor_deleted_at_idx ON actor (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,21 @@
+CREATE TABLE film_revision (
+    id          BIGSERIAL PRIMARY KEY,
+    film_id     INTEGER NOT NULL REFERENCES film (id) ON DELETE CASCADE,
+    revision    INTEGER NOT NULL,
+    name        TEXT NOT NULL,
+    description TEXT NOT NULL,
+    rating      FLOAT NOT NULL,
+    releaseDate DATE,
+    crew        JSONB NOT NULL DEFAULT '[]',
+    author_id   BIGINT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT film_revision_film_id_revision_key UNIQUE (film_id, revision)
+);
+
+-- Existing films start their history from the current version
+INSERT INTO film_revision (film_id, revision, name, description, rating, releaseDate, crew)
+SELECT film.id, 1, film.name, COALESCE(film.description, ''), COALESCE(film.rating, 0), film.releaseDate,
+       COALESCE(jsonb_agg(film_actor.actor_id ORDER BY film_actor.actor_id) FILTER (WHERE film_actor.actor_id IS NOT NULL), '[]')
+FROM film
+LEFT JOIN film_actor ON film_actor.film_id = film.id
+GROUP BY film.id;
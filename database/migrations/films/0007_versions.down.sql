@@ -0,0 +1,2 @@
+ALTER TABLE film DROP COLUMN IF EXISTS version;
+ALTER TABLE actor DROP COLUMN IF EXISTS version;
@@ -0,0 +1,21 @@
+UPDATE film_revision
+SET crew = (
+    SELECT COALESCE(jsonb_agg(DISTINCT (member ->> 'actor_id')::bigint ORDER BY (member ->> 'actor_id')::bigint), '[]')
+    FROM jsonb_array_elements(film_revision.crew) AS crew (member)
+);
+
+-- Only one link per film and person survives
+DELETE FROM film_actor duplicate
+USING film_actor original
+WHERE duplicate.film_id = original.film_id
+  AND duplicate.actor_id = original.actor_id
+  AND duplicate.id > original.id;
+
+ALTER TABLE film_actor
+    DROP CONSTRAINT IF EXISTS film_actor_film_id_actor_id_role_key,
+    DROP CONSTRAINT IF EXISTS film_actor_billing_order_check,
+    DROP CONSTRAINT IF EXISTS film_actor_role_check,
+    DROP COLUMN IF EXISTS billing_order,
+    DROP COLUMN IF EXISTS character_name,
+    DROP COLUMN IF EXISTS role,
+    ADD CONSTRAINT film_actor_film_id_actor_id_key UNIQUE (film_id, actor_id);
@@ -0,0 +1,16 @@
+ALTER TABLE film_actor
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'actor',
+    ADD COLUMN character_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN billing_order INTEGER NOT NULL DEFAULT 0,
+    ADD CONSTRAINT film_actor_role_check
+        CHECK (role IN ('actor', 'director', 'writer', 'producer', 'composer', 'cinematographer', 'editor')),
+    ADD CONSTRAINT film_actor_billing_order_check CHECK (billing_order >= 0),
+    DROP CONSTRAINT IF EXISTS film_actor_film_id_actor_id_key,
+    ADD CONSTRAINT film_actor_film_id_actor_id_role_key UNIQUE (film_id, actor_id, role);
+
+-- Revisions keep crew members instead of bare actor ids
+UPDATE film_revision
+SET crew = (
+    SELECT COALESCE(jsonb_agg(jsonb_build_object('actor_id', member, 'role', 'actor', 'billing_order', 0) ORDER BY ordinality), '[]')
+    FROM jsonb_array_elements(film_revision.crew) WITH ORDINALITY AS crew (member, ordinality)
+);
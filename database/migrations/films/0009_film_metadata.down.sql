@@ -0,0 +1,20 @@
+ALTER TABLE film_revision
+    DROP COLUMN IF EXISTS countries,
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS genres,
+    DROP COLUMN IF EXISTS original_language,
+    DROP COLUMN IF EXISTS age_rating,
+    DROP COLUMN IF EXISTS runtime;
+
+DROP INDEX IF EXISTS film_runtime_idx;
+
+DROP TABLE IF EXISTS film_country;
+DROP TABLE IF EXISTS film_tag;
+DROP TABLE IF EXISTS film_genre;
+DROP TABLE IF EXISTS genre;
+
+ALTER TABLE film
+    DROP CONSTRAINT IF EXISTS film_runtime_check,
+    DROP COLUMN IF EXISTS original_language,
+    DROP COLUMN IF EXISTS age_rating,
+    DROP COLUMN IF EXISTS runtime;
@@ -0,0 +1,60 @@
+ALTER TABLE film
+    ADD COLUMN runtime INTEGER,
+    ADD COLUMN age_rating TEXT NOT NULL DEFAULT '',
+    ADD COLUMN original_language TEXT NOT NULL DEFAULT '',
+    ADD CONSTRAINT film_runtime_check CHECK (runtime > 0);
+
+CREATE TABLE genre (
+    id   SERIAL PRIMARY KEY,
+    slug TEXT NOT NULL,
+    name TEXT NOT NULL,
+    CONSTRAINT genre_slug_key UNIQUE (slug)
+);
+
+-- Genres in use can't be removed from the dictionary
+CREATE TABLE film_genre (
+    film_id  INTEGER NOT NULL REFERENCES film (id) ON DELETE CASCADE,
+    genre_id INTEGER NOT NULL REFERENCES genre (id),
+    PRIMARY KEY (film_id, genre_id)
+);
+
+CREATE TABLE film_tag (
+    film_id INTEGER NOT NULL REFERENCES film (id) ON DELETE CASCADE,
+    tag     TEXT NOT NULL,
+    PRIMARY KEY (film_id, tag)
+);
+
+-- Countries are ISO 3166-1 alpha-2 codes
+CREATE TABLE film_country (
+    film_id INTEGER NOT NULL REFERENCES film (id) ON DELETE CASCADE,
+    country CHAR(2) NOT NULL,
+    PRIMARY KEY (film_id, country)
+);
+
+-- Filters look films up by genre, tag and country
+CREATE INDEX film_genre_genre_id_idx ON film_genre (genre_id);
+CREATE INDEX film_tag_tag_idx ON film_tag (tag);
+CREATE INDEX film_country_country_idx ON film_country (country);
+CREATE INDEX film_runtime_idx ON film (runtime);
+
+ALTER TABLE film_revision
+    ADD COLUMN runtime INTEGER,
+    ADD COLUMN age_rating TEXT NOT NULL DEFAULT '',
+    ADD COLUMN original_language TEXT NOT NULL DEFAULT '',
+    ADD COLUMN genres JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN tags JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN countries JSONB NOT NULL DEFAULT '[]';
+
+INSERT INTO genre (slug, name) VALUES
+    ('action', 'Action'),
+    ('adventure', 'Adventure'),
+    ('animation', 'Animation'),
+    ('comedy', 'Comedy'),
+    ('crime', 'Crime'),
+    ('documentary', 'Documentary'),
+    ('drama', 'Drama'),
+    ('fantasy', 'Fantasy'),
+    ('horror', 'Horror'),
+    ('romance', 'Romance'),
+    ('science-fiction', 'Science fiction'),
+    ('thriller', 'Thriller');
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS actor_search_vector_idx;
+DROP INDEX IF EXISTS film_search_vector_idx;
+
+ALTER TABLE actor DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE film DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,15 @@
+-- The russian configuration stems Cyrillic words with the Russian stemmer
+-- and Latin words with the English one, so it covers both languages.
+ALTER TABLE film
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('russian', COALESCE(description, '')), 'B')
+    ) STORED;
+
+ALTER TABLE actor
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', COALESCE(name, '')), 'A')
+    ) STORED;
+
+CREATE INDEX film_search_vector_idx ON film USING GIN (search_vector);
+CREATE INDEX actor_search_vector_idx ON actor USING GIN (search_vector);
@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Trigram indexes serve fuzzy matching and suggestions by name
+CREATE INDEX film_name_trgm_idx ON film USING GIN (name gin_trgm_ops);
+CREATE INDEX actor_name_trgm_idx ON actor USING GIN (name gin_trgm_ops);
@@ -2,74 +2,102 @@ package delivery_grpc
 
 import (
 	"context"
-	"filmoteka/configs"
+	"errors"
 	pbAuth "filmoteka/modules/authorization/proto/authorization"
 	"filmoteka/modules/authorization/repository/profile"
 	"filmoteka/modules/authorization/repository/session"
+	"filmoteka/modules/authorization/usecase"
+	"filmoteka/pkg/metrics"
 	"filmoteka/pkg/variables"
 	"fmt"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 	"log/slog"
 	"net"
 	"time"
 )
 
 type authorizationGrpc struct {
-	grpcServer *grpc.Server
-	logger     *slog.Logger
+	grpcServer        *grpc.Server
+	config            *variables.GrpcConfig
+	healthServer      *health.Server
+	stopping          chan struct{}
+	profileRepository *profile.ProfileRelationalRepository
+	sessionRepository *session.SessionCacheRepository
+	logger            *slog.Logger
 }
 
 type authorizationGrpcServer struct {
 	pbAuth.UnimplementedAuthorizationServer
 	profileRepository *profile.ProfileRelationalRepository
 	sessionRepository *session.SessionCacheRepository
+	invalidations     *usecase.InvalidationBroker
+	stopping          <-chan struct{}
 	logger            *slog.Logger
 }
 
-func NewServer(configRelational *variables.RelationalDataBaseConfig, configSession *variables.CacheDataBaseConfig, logger *slog.Logger) (*authorizationGrpc, error) {
+func NewServer(configRelational *variables.RelationalDataBaseConfig, configSession *variables.CacheDataBaseConfig, configGrpc *variables.GrpcConfig, invalidations *usecase.InvalidationBroker, logger *slog.Logger) (*authorizationGrpc, error) {
 	session, err := session.GetSessionRepository(configSession, logger)
 
 	if err != nil {
-		logger.Error(variables.SessionRepositoryNotActiveError)
-		return nil, fmt.Errorf(variables.GrpcListenAndServeError, ": %w", err)
+		logger.Error(variables.SessionRepositoryNotActiveError, "error", err)
+		return nil, fmt.Errorf("%s: %w", variables.GrpcListenAndServeError, err)
 	}
 
 	users, err := profile.GetProfileRepository(configRelational, logger)
 	if err != nil {
-		logger.Error(variables.ProfileRepositoryNotActiveError)
-		return nil, fmt.Errorf(variables.GrpcListenAndServeError, ": %w", err)
+		logger.Error(variables.ProfileRepositoryNotActiveError, "error", err)
+		return nil, fmt.Errorf("%s: %w", variables.GrpcListenAndServeError, err)
 	}
 
-	grpcServer := grpc.NewServer(grpc.KeepaliveParams(keepalive.ServerParameters{
-		Time:    20 * time.Second,
-		Timeout: 10 * time.Second,
-	}))
+	grpcServer := grpc.NewServer(
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    20 * time.Second,
+			Timeout: 10 * time.Second,
+		}),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(metrics.UnaryServerInterceptor),
+		grpc.ChainStreamInterceptor(metrics.StreamServerInterceptor),
+	)
+	stopping := make(chan struct{})
 	pbAuth.RegisterAuthorizationServer(grpcServer, &authorizationGrpcServer{
-		logger:            logger,
+		logger:            logger.With(variables.ModuleLogger, variables.GrpcModuleLogger),
 		sessionRepository: session,
 		profileRepository: users,
+		invalidations:     invalidations,
+		stopping:          stopping,
 	})
 
-	return &authorizationGrpc{grpcServer: grpcServer, logger: logger}, nil
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(pbAuth.Authorization_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+
+	return &authorizationGrpc{
+		grpcServer:        grpcServer,
+		config:            configGrpc,
+		healthServer:      healthServer,
+		stopping:          stopping,
+		profileRepository: users,
+		sessionRepository: session,
+		logger:            logger.With(variables.ModuleLogger, variables.GrpcModuleLogger),
+	}, nil
 }
 
 func (server *authorizationGrpc) ListenAndServeGrpc() error {
-	grpcConfig, err := configs.ReadGrpcConfig()
-	if err != nil {
-		server.logger.Error(variables.ReadGrpcConfigError, ": %v", err)
-		return fmt.Errorf(variables.GrpcListenAndServeError, ": %w", err)
-	}
-
-	lis, err := net.Listen(grpcConfig.ConnectionType, ":"+grpcConfig.Port)
+	lis, err := net.Listen(server.config.ConnectionType, ":"+server.config.Port)
 	if err != nil {
-		server.logger.Error(variables.GrpcListenAndServeError, ": %v", err)
-		return fmt.Errorf(variables.GrpcListenAndServeError, ": %w", err)
+		server.logger.Error(variables.GrpcListenAndServeError, "error", err)
+		return fmt.Errorf("%s: %w", variables.GrpcListenAndServeError, err)
 	}
 
 	if err := server.grpcServer.Serve(lis); err != nil {
-		server.logger.Error(variables.GrpcListenAndServeError, ": %v", err)
-		return fmt.Errorf(variables.GrpcListenAndServeError, ": %w", err)
+		server.logger.Error(variables.GrpcListenAndServeError, "error", err)
+		return fmt.Errorf("%s: %w", variables.GrpcListenAndServeError, err)
 	}
 
 	return nil
@@ -81,9 +109,9 @@ func (server *authorizationGrpcServer) GetId(ctx context.Context, req *pbAuth.Fi
 		return nil, err
 	}
 
-	id, err := server.profileRepository.GetUserProfileId(login)
+	id, err := server.profileRepository.GetUserProfileId(ctx, login)
 	if err != nil {
-		server.logger.Error(variables.ProfileNotFoundError, ": %v", err)
+		server.logger.Error(variables.ProfileNotFoundError, "error", err)
 		return nil, err
 	}
 	return &pbAuth.FindIdResponse{
@@ -92,9 +120,9 @@ func (server *authorizationGrpcServer) GetId(ctx context.Context, req *pbAuth.Fi
 }
 
 func (server *authorizationGrpcServer) GetRole(ctx context.Context, req *pbAuth.RoleRequest) (*pbAuth.RoleResponse, error) {
-	role, err := server.profileRepository.GetUserRole(req.Id)
+	role, err := server.profileRepository.GetUserRole(ctx, req.Id)
 	if err != nil {
-		server.logger.Error(variables.GetProfileRoleError, ": %v", err)
+		server.logger.Error(variables.GetProfileRoleError, "error", err)
 		return nil, err
 	}
 
@@ -102,3 +130,58 @@ func (server *authorizationGrpcServer) GetRole(ctx context.Context, req *pbAuth.
 		Role: role,
 	}, nil
 }
+
+func (server *authorizationGrpcServer) WatchInvalidations(req *pbAuth.WatchInvalidationsRequest, stream pbAuth.Authorization_WatchInvalidationsServer) error {
+	subscriber := server.invalidations.Subscribe()
+	defer server.invalidations.Unsubscribe(subscriber)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-server.stopping:
+			// Watchers reconnect on Unavailable and purge their caches,
+			// so no invalidation is lost with this instance
+			return status.Error(codes.Unavailable, variables.InvalidationsShutdownError)
+		case event, ok := <-subscriber:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, variables.InvalidationsWatchError)
+			}
+
+			err := stream.Send(&pbAuth.InvalidationEvent{Sid: event.Sid, Id: event.Id})
+			if err != nil {
+				server.logger.Error(variables.InvalidationsWatchError, "error", err)
+				return err
+			}
+		}
+	}
+}
+
+// Shutdown waits for in-flight calls until ctx is done, then stops the server
+// and closes the repositories it owns.
+func (server *authorizationGrpc) Shutdown(ctx context.Context) error {
+	err := server.stop(ctx)
+	return errors.Join(err, server.sessionRepository.Close(), server.profileRepository.Close())
+}
+
+// stop reports NOT_SERVING and ends the invalidation streams first, as
+// they never finish on their own and GracefulStop would wait for them.
+func (server *authorizationGrpc) stop(ctx context.Context) error {
+	server.healthServer.Shutdown()
+	close(server.stopping)
+
+	stopped := make(chan struct{})
+	go func() {
+		server.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	var err error
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.grpcServer.Stop()
+		err = ctx.Err()
+	}
+	return err
+}
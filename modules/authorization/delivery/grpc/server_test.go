@@ -0,0 +1,122 @@
+package delivery_grpc
+
+import (
+	"context"
+	pbAuth "filmoteka/modules/authorization/proto/authorization"
+	"filmoteka/modules/authorization/usecase"
+	"filmoteka/pkg/models"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func getTestServer(t *testing.T, invalidations *usecase.InvalidationBroker) (*authorizationGrpc, pbAuth.AuthorizationClient) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	stopping := make(chan struct{})
+	grpcServer := grpc.NewServer()
+	pbAuth.RegisterAuthorizationServer(grpcServer, &authorizationGrpcServer{
+		invalidations: invalidations,
+		stopping:      stopping,
+		logger:        logger,
+	})
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(pbAuth.Authorization_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+
+	listener := bufconn.Listen(1 << 16)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	connection, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { connection.Close() })
+
+	server := &authorizationGrpc{grpcServer: grpcServer, healthServer: healthServer, stopping: stopping, logger: logger}
+	return server, pbAuth.NewAuthorizationClient(connection)
+}
+
+// watch opens an invalidations stream and waits until it is subscribed
+func watch(t *testing.T, client pbAuth.AuthorizationClient, invalidations *usecase.InvalidationBroker) pbAuth.Authorization_WatchInvalidationsClient {
+	stream, err := client.WatchInvalidations(context.Background(), &pbAuth.WatchInvalidationsRequest{})
+	if err != nil {
+		t.Fatalf("WatchInvalidations: %v", err)
+	}
+
+	received := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-received:
+				return
+			case <-time.After(10 * time.Millisecond):
+				invalidations.Publish(models.Invalidation{Sid: "probe"})
+			}
+		}
+	}()
+	_, err = stream.Recv()
+	close(received)
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	return stream
+}
+
+func TestShutdownEndsInvalidationStreams(t *testing.T) {
+	invalidations := usecase.GetInvalidationBroker(16)
+	server, client := getTestServer(t, invalidations)
+	stream := watch(t, client, invalidations)
+
+	timeout := 5 * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	started := time.Now()
+	err := server.stop(ctx)
+	if err != nil {
+		t.Fatalf("stop waited for the open stream: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed >= timeout {
+		t.Errorf("stop took %v, want under %v", elapsed, timeout)
+	}
+
+	for {
+		_, err = stream.Recv()
+		if err != nil {
+			break
+		}
+	}
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("stream ended with %v, want %v", err, codes.Unavailable)
+	}
+}
+
+func TestShutdownReportsNotServing(t *testing.T) {
+	server, _ := getTestServer(t, usecase.GetInvalidationBroker(16))
+
+	err := server.stop(context.Background())
+	if err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	response, err := server.healthServer.Check(context.Background(),
+		&healthpb.HealthCheckRequest{Service: pbAuth.Authorization_ServiceDesc.ServiceName})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if response.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("status = %v, want %v", response.Status, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+}
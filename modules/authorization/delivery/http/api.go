@@ -2,17 +2,25 @@ package delivery
 
 import (
 	"context"
+	"errors"
+	"filmoteka/configs"
 	"filmoteka/modules/authorization/usecase"
+	"filmoteka/pkg/audit"
+	"filmoteka/pkg/health"
+	"filmoteka/pkg/logging"
 	"filmoteka/pkg/middleware"
 	"filmoteka/pkg/models"
 	communication "filmoteka/pkg/requests"
 	"filmoteka/pkg/util"
 	"filmoteka/pkg/variables"
 	"log/slog"
+	"net"
 	"net/http"
 	"time"
 
 	_ "filmoteka/docs"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // Core interface
@@ -20,57 +28,102 @@ type ICore interface {
 	KillSession(ctx context.Context, sid string) error
 	FindActiveSession(ctx context.Context, sid string) (bool, error)
 	CreateSession(ctx context.Context, login string) (models.Session, error)
-	CreateUserAccount(login string, password string) error
-	FindUserByLogin(login string) (bool, error)
-	FindUserAccount(login string, password string) (*models.UserItem, bool, error)
+	CreateUserAccount(ctx context.Context, login string, password string) error
+	FindUserByLogin(ctx context.Context, login string) (bool, error)
+	FindUserAccount(ctx context.Context, login string, password string) (*models.UserItem, bool, error)
 	GetUserId(ctx context.Context, sid string) (int64, error)
 	GetUserRole(ctx context.Context, id int64) (string, error)
+	ChangeUserRole(ctx context.Context, id int64, role string) error
+	GetAudit(ctx context.Context, filter models.AuditFilter, offset uint64, limit uint64) (communication.AuditListResponse, error)
 }
 
 type API struct {
 	core   ICore
 	logger *slog.Logger
+	config *configs.Reloader[variables.AuthorizationConfig]
 	mux    *http.ServeMux
+	server *http.Server
 }
 
 func (api *API) ListenAndServe(appConfig *variables.AppConfig) error {
-	err := http.ListenAndServe(appConfig.Address, api.mux)
+	listener, err := net.Listen(variables.ListenNetworkType, appConfig.Address)
 	if err != nil {
-		api.logger.Error(variables.ListenAndServeError, err.Error())
+		api.logger.Error(variables.ListenAndServeError, "error", err)
+		return err
+	}
+
+	err = api.server.Serve(listener)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		api.logger.Error(variables.ListenAndServeError, "error", err)
 		return err
 	}
 	return nil
 }
 
-func GetAuthorizationApi(authCore *usecase.Core, authLogger *slog.Logger) *API {
+func (api *API) Shutdown(ctx context.Context) error {
+	return api.server.Shutdown(ctx)
+}
+
+func (api *API) permissions() map[string]string {
+	return api.config.Current().App.Permissions
+}
+
+func GetAuthorizationApi(authCore *usecase.Core, levels *logging.Levels, config *configs.Reloader[variables.AuthorizationConfig], authLogger *slog.Logger) *API {
 	api := &API{
 		core:   authCore,
-		logger: authLogger,
+		logger: authLogger.With(variables.ModuleLogger, variables.DeliveryModuleLogger),
+		config: config,
 		mux:    http.NewServeMux(),
 	}
+	api.server = &http.Server{
+		Handler: middleware.TracingMiddleware(
+			middleware.RequestIdMiddleware(
+				middleware.AccessLogMiddleware(middleware.MetricsMiddleware(api.mux), api.mux, api.logger)),
+			api.mux, variables.AuthServiceName),
+		ReadTimeout:  variables.HttpReadTimeout,
+		WriteTimeout: variables.HttpWriteTimeout,
+		IdleTimeout:  variables.HttpIdleTimeout,
+	}
 
 	// Signin handler
-	api.mux.Handle("/signin", middleware.MethodMiddleware(
-		http.HandlerFunc(api.Signin),
-		http.MethodPost,
-		api.logger))
+	api.mux.Handle("POST /signin", http.HandlerFunc(api.Signin))
 
 	// Signup handler
-	api.mux.Handle("/signup", middleware.MethodMiddleware(
-		http.HandlerFunc(api.Signup),
-		http.MethodPost,
-		api.logger))
+	api.mux.Handle("POST /signup", http.HandlerFunc(api.Signup))
 
 	// Logout handler
-	api.mux.Handle("/logout", middleware.MethodMiddleware(
-		middleware.AuthorizationMiddleware(
-			http.HandlerFunc(api.LogoutSession),
-			api.core, api.logger),
-		http.MethodPost,
-		api.logger))
+	api.mux.Handle("POST /logout", middleware.AuthorizationMiddleware(
+		http.HandlerFunc(api.LogoutSession),
+		api.core, api.logger))
+
+	// Role handler
+	api.mux.Handle("POST /role", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.ChangeRole), api.core, variables.PermissionRoleChange, api.permissions, api.logger),
+		api.core, api.logger))
+
+	// Audit handler
+	api.mux.Handle("GET /api/v1/audit", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.GetAudit), api.core, variables.PermissionAuditRead, api.permissions, api.logger),
+		api.core, api.logger))
+
+	// Metrics handler
+	api.mux.Handle("GET "+variables.MetricsRoute, promhttp.Handler())
+
+	// Health handlers
+	api.mux.Handle("GET /healthz", health.LivenessHandler(api.logger))
+
+	api.mux.Handle("GET /readyz", health.ReadinessHandler(authCore.HealthCheckers(), api.logger))
+
+	// Log level handler
+	api.mux.Handle("POST "+variables.LogLevelRoute, middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			logging.LevelsHandler(levels, api.logger), api.core, variables.PermissionLogLevel, api.permissions, api.logger),
+		api.core, api.logger))
 
 	// Serve the Swagger JSON file
-	api.mux.HandleFunc("/swagger.yaml", func(w http.ResponseWriter, r *http.Request) {
+	api.mux.HandleFunc("GET /swagger.yaml", func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "../../docs/swagger.yaml")
 	})
 
@@ -96,7 +149,7 @@ func (api *API) Signin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, found, err := api.core.FindUserAccount(signinRequest.Login, signinRequest.Password)
+	user, found, err := api.core.FindUserAccount(r.Context(), signinRequest.Login, signinRequest.Password)
 	if err != nil {
 		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
 		return
@@ -138,7 +191,7 @@ func (api *API) Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	found, err := api.core.FindUserByLogin(signupRequest.Login)
+	found, err := api.core.FindUserByLogin(r.Context(), signupRequest.Login)
 	if err != nil {
 		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
 		return
@@ -149,7 +202,7 @@ func (api *API) Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = api.core.CreateUserAccount(signupRequest.Login, signupRequest.Password)
+	err = api.core.CreateUserAccount(r.Context(), signupRequest.Login, signupRequest.Password)
 	if err != nil && err.Error() == variables.InvalidEmailOrPasswordError {
 		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.InvalidEmailOrPasswordError, err, api.logger)
 		return
@@ -200,3 +253,76 @@ func (api *API) LogoutSession(w http.ResponseWriter, r *http.Request) {
 	http.SetCookie(w, session)
 	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
 }
+
+// @Summary Change-Role
+// @Tags authorization
+// @Security ApiKeyAuth
+// @Description Change user role
+// @ID change-role
+// @Accept json
+// @Produce json
+// @Param input body communication.ChangeRoleRequest true "user id and role"
+// @Success 200 {string} string "Role changed"
+// @Failure 400 {string} string variables.InvalidRoleError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 500 {string} string variables.RoleNotChangedError
+// @Router /role [post]
+func (api *API) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	var changeRoleRequest communication.ChangeRoleRequest
+
+	err := util.GetRequestBody(w, r, &changeRoleRequest, api.logger)
+	if err != nil {
+		return
+	}
+
+	err = api.core.ChangeUserRole(r.Context(), changeRoleRequest.Id, changeRoleRequest.Role)
+	if errors.Is(err, variables.ErrInvalidInput) {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, err.Error(), err, api.logger)
+		return
+	}
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.RoleNotChangedError, err, api.logger)
+		return
+	}
+	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Audit
+// @Tags audit
+// @Security ApiKeyAuth
+// @Description Get account and role changes, newest first
+// @ID authorization-audit-list
+// @Accept json
+// @Produce json
+// @Param actor_id query int false "user who made the change"
+// @Param action query string false "create or update"
+// @Param entity query string false "user"
+// @Param entity_id query int false "changed user id"
+// @Param request_id query string false "request id of the change"
+// @Param from query string false "RFC 3339 time, inclusive"
+// @Param to query string false "RFC 3339 time, exclusive"
+// @Param page query int false "page number"
+// @Param page_size query int false "page size"
+// @Success 200 {object} communication.AuditListResponse
+// @Failure 400 {string} string variables.AuditFilterError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 500 {string} string variables.AuditListError
+// @Router /api/v1/audit [get]
+func (api *API) GetAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := audit.ParseFilter(r)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.AuditFilterError, err, api.logger)
+		return
+	}
+
+	size, page := util.Pagination(r, api.config.Current().Pagination)
+
+	entries, err := api.core.GetAudit(r.Context(), filter, uint64((page-1)*size), size)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.AuditListError, err, api.logger)
+		return
+	}
+	util.SendResponse(w, r, http.StatusOK, entries, variables.StatusOkMessage, nil, api.logger)
+}
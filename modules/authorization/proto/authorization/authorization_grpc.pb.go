@@ -19,8 +19,9 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	Authorization_GetId_FullMethodName   = "/authorization.Authorization/GetId"
-	Authorization_GetRole_FullMethodName = "/authorization.Authorization/GetRole"
+	Authorization_GetId_FullMethodName              = "/authorization.Authorization/GetId"
+	Authorization_GetRole_FullMethodName            = "/authorization.Authorization/GetRole"
+	Authorization_WatchInvalidations_FullMethodName = "/authorization.Authorization/WatchInvalidations"
 )
 
 // AuthorizationClient is the client API for Authorization service.
@@ -29,6 +30,7 @@ const (
 type AuthorizationClient interface {
 	GetId(ctx context.Context, in *FindIdRequest, opts ...grpc.CallOption) (*FindIdResponse, error)
 	GetRole(ctx context.Context, in *RoleRequest, opts ...grpc.CallOption) (*RoleResponse, error)
+	WatchInvalidations(ctx context.Context, in *WatchInvalidationsRequest, opts ...grpc.CallOption) (Authorization_WatchInvalidationsClient, error)
 }
 
 type authorizationClient struct {
@@ -57,12 +59,45 @@ func (c *authorizationClient) GetRole(ctx context.Context, in *RoleRequest, opts
 	return out, nil
 }
 
+func (c *authorizationClient) WatchInvalidations(ctx context.Context, in *WatchInvalidationsRequest, opts ...grpc.CallOption) (Authorization_WatchInvalidationsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Authorization_ServiceDesc.Streams[0], Authorization_WatchInvalidations_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &authorizationWatchInvalidationsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Authorization_WatchInvalidationsClient interface {
+	Recv() (*InvalidationEvent, error)
+	grpc.ClientStream
+}
+
+type authorizationWatchInvalidationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *authorizationWatchInvalidationsClient) Recv() (*InvalidationEvent, error) {
+	m := new(InvalidationEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // AuthorizationServer is the server API for Authorization service.
 // All implementations must embed UnimplementedAuthorizationServer
 // for forward compatibility
 type AuthorizationServer interface {
 	GetId(context.Context, *FindIdRequest) (*FindIdResponse, error)
 	GetRole(context.Context, *RoleRequest) (*RoleResponse, error)
+	WatchInvalidations(*WatchInvalidationsRequest, Authorization_WatchInvalidationsServer) error
 	mustEmbedUnimplementedAuthorizationServer()
 }
 
@@ -76,6 +111,9 @@ func (UnimplementedAuthorizationServer) GetId(context.Context, *FindIdRequest) (
 func (UnimplementedAuthorizationServer) GetRole(context.Context, *RoleRequest) (*RoleResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetRole not implemented")
 }
+func (UnimplementedAuthorizationServer) WatchInvalidations(*WatchInvalidationsRequest, Authorization_WatchInvalidationsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchInvalidations not implemented")
+}
 func (UnimplementedAuthorizationServer) mustEmbedUnimplementedAuthorizationServer() {}
 
 // UnsafeAuthorizationServer may be embedded to opt out of forward compatibility for this service.
@@ -125,6 +163,27 @@ func _Authorization_GetRole_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Authorization_WatchInvalidations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchInvalidationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AuthorizationServer).WatchInvalidations(m, &authorizationWatchInvalidationsServer{stream})
+}
+
+type Authorization_WatchInvalidationsServer interface {
+	Send(*InvalidationEvent) error
+	grpc.ServerStream
+}
+
+type authorizationWatchInvalidationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *authorizationWatchInvalidationsServer) Send(m *InvalidationEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Authorization_ServiceDesc is the grpc.ServiceDesc for Authorization service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -141,6 +200,12 @@ var Authorization_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _Authorization_GetRole_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchInvalidations",
+			Handler:       _Authorization_WatchInvalidations_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "authorization.proto",
 }
@@ -1,9 +1,14 @@
 package profile
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"filmoteka/pkg/audit"
+	"filmoteka/pkg/metrics"
 	"filmoteka/pkg/models"
+	communication "filmoteka/pkg/requests"
+	"filmoteka/pkg/tracing"
 	"filmoteka/pkg/variables"
 	"fmt"
 	"log/slog"
@@ -13,7 +18,7 @@ import (
 )
 
 type ProfileRelationalRepository struct {
-	db *sql.DB
+	db *tracing.DB
 }
 
 func GetProfileRepository(configDatabase *variables.RelationalDataBaseConfig, logger *slog.Logger) (*ProfileRelationalRepository, error) {
@@ -22,20 +27,25 @@ func GetProfileRepository(configDatabase *variables.RelationalDataBaseConfig, lo
 
 	db, err := sql.Open("pgx", dsn)
 	if err != nil {
-		logger.Error(variables.SqlOpenError, err.Error())
+		logger.Error(variables.SqlOpenError, "error", err)
 		return nil, err
 	}
 
 	err = db.Ping()
 	if err != nil {
-		logger.Error(variables.SqlPingError, err.Error())
+		logger.Error(variables.SqlPingError, "error", err)
 		return nil, err
 	}
 
 	db.SetMaxOpenConns(configDatabase.MaxOpenConns)
 
+	err = metrics.RegisterDBStats(db, configDatabase.DbName)
+	if err != nil {
+		logger.Error(variables.MetricsRegisterError, "error", err)
+	}
+
 	profileDb := ProfileRelationalRepository{
-		db: db,
+		db: tracing.WrapDB(db, configDatabase.DbName),
 	}
 
 	errs := make(chan error)
@@ -44,7 +54,7 @@ func GetProfileRepository(configDatabase *variables.RelationalDataBaseConfig, lo
 	}()
 
 	if err := <-errs; err != nil {
-		logger.Error(err.Error())
+		logger.Error(variables.SqlMaxPingRetriesError, "error", err)
 		return nil, err
 	}
 
@@ -62,93 +72,187 @@ func (repository *ProfileRelationalRepository) pingDb(timer uint32, logger *slog
 		}
 
 		retries++
-		logger.Error(variables.SqlPingError, err.Error())
+		logger.Error(variables.SqlPingError, "error", err)
 		time.Sleep(time.Duration(timer) * time.Second)
 	}
 
-	logger.Error(variables.SqlMaxPingRetriesError, err)
-	return fmt.Errorf(variables.SqlMaxPingRetriesError, err.Error())
+	return fmt.Errorf("%s %w", variables.SqlMaxPingRetriesError, err)
 }
 
-func (repository *ProfileRelationalRepository) CreateUser(login string, password []byte) error {
-	_, err := repository.db.Exec(
-		`INSERT INTO password(value)
-			   VALUES ($1)`, password)
+// State of an account recorded in the audit log
+type userState struct {
+	Login string `json:"login,omitempty"`
+	Role  string `json:"role"`
+}
+
+func (repository *ProfileRelationalRepository) inTransaction(ctx context.Context, run func(tx *sql.Tx) error) error {
+	tx, err := repository.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf(variables.SqlProfileCreateError, err)
+		return err
 	}
 
-	_, errProfile := repository.db.Exec(
-		`INSERT INTO profile(login, password_id)
-			   VALUES ($1, (SELECT id FROM password WHERE value = $2 LIMIT 1))`,
-		login, password)
-	if errProfile != nil {
-		return fmt.Errorf(variables.SqlProfileCreateError, err)
+	err = run(tx)
+	if err != nil {
+		return errors.Join(err, tx.Rollback())
 	}
+	return tx.Commit()
+}
+
+func (repository *ProfileRelationalRepository) CreateUser(ctx context.Context, login string, password []byte) error {
+	err := repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		var passwordId int64
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO password(value)
+			   VALUES ($1) RETURNING id`, password).Scan(&passwordId)
+		if err != nil {
+			return err
+		}
+
+		var profileId int64
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO profile(login, password_id)
+			   VALUES ($1, $2) RETURNING id`,
+			login, passwordId).Scan(&profileId)
+		if err != nil {
+			return err
+		}
 
-	_, errRole := repository.db.Exec(`INSERT INTO profile_role(profile_id, role_id)
-                                             VALUES ((SELECT id FROM profile WHERE login = $1), $2)`, login, variables.UserRoleId)
-	if errRole != nil {
-		return fmt.Errorf(variables.SqlProfileCreateError, err)
+		_, err = tx.ExecContext(ctx, `INSERT INTO profile_role(profile_id, role_id)
+                                 VALUES ($1, $2)`, profileId, variables.UserRoleId)
+		if err != nil {
+			return err
+		}
+
+		// A new account is created by its owner
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:   variables.AuditActionCreate,
+			Entity:   variables.AuditEntityUser,
+			EntityId: profileId,
+			ActorId:  profileId,
+			After:    userState{Login: login, Role: variables.UserRole},
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("%s %w", variables.SqlProfileCreateError, err)
 	}
+
 	return nil
 }
 
-func (repository *ProfileRelationalRepository) FindUser(login string) (bool, error) {
+func (repository *ProfileRelationalRepository) FindUser(ctx context.Context, login string) (bool, error) {
 	userItem := &models.UserItem{}
 
-	err := repository.db.QueryRow(
+	err := repository.db.QueryRowContext(ctx,
 		`SELECT login FROM profile
 			   WHERE login = $1`, login).Scan(&userItem.Login)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return false, nil
 		}
-		return false, fmt.Errorf(variables.ProfileNotFoundError, err.Error())
+		return false, fmt.Errorf("%s: %w", variables.ProfileNotFoundError, err)
 	}
 	return true, nil
 }
 
-func (repository *ProfileRelationalRepository) GetUser(login string, password []byte) (*models.UserItem, bool, error) {
+func (repository *ProfileRelationalRepository) GetUser(ctx context.Context, login string, password []byte) (*models.UserItem, bool, error) {
 	userItem := &models.UserItem{}
 
-	err := repository.db.QueryRow(
+	err := repository.db.QueryRowContext(ctx,
 		`SELECT login FROM profile
 			JOIN password ON profile.password_id = password.id
 			WHERE profile.login = $1 AND password.value = $2`, login, password).Scan(&userItem.Login)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, false, fmt.Errorf(variables.InvalidEmailOrPasswordError, ": %w", err)
+			return nil, false, fmt.Errorf("%s: %w", variables.InvalidEmailOrPasswordError, err)
 		}
-		return nil, false, fmt.Errorf(variables.ProfileNotFoundError, ": %w", err)
+		return nil, false, fmt.Errorf("%s: %w", variables.ProfileNotFoundError, err)
 	}
 
 	return userItem, true, nil
 }
 
-func (repository *ProfileRelationalRepository) GetUserProfileId(login string) (int64, error) {
+func (repository *ProfileRelationalRepository) GetUserProfileId(ctx context.Context, login string) (int64, error) {
 	var userId int64
 
-	err := repository.db.QueryRow("SELECT id FROM profile WHERE login = $1", login).Scan(&userId)
+	err := repository.db.QueryRowContext(ctx, "SELECT id FROM profile WHERE login = $1", login).Scan(&userId)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return 0, fmt.Errorf(variables.ProfileIdNotFoundByLoginError, " %s", login)
+			return 0, fmt.Errorf("%s %s", variables.ProfileIdNotFoundByLoginError, login)
 		}
-		return 0, fmt.Errorf(variables.FindProfileIdByLoginError, " %w", err)
+		return 0, fmt.Errorf("%s %w", variables.FindProfileIdByLoginError, err)
 	}
 	return userId, nil
 }
 
-func (repository *ProfileRelationalRepository) GetUserRole(id int64) (string, error) {
+func (repository *ProfileRelationalRepository) GetUserRole(ctx context.Context, id int64) (string, error) {
 	var role string
 
-	err := repository.db.QueryRow(`SELECT role.value FROM profile
+	err := repository.db.QueryRowContext(ctx, `SELECT role.value FROM profile
 		JOIN profile_role ON profile.id = profile_role.profile_id
 		JOIN role ON profile_role.role_id = role.id
 		WHERE profile.id = $1`, id).Scan(&role)
 	if err != nil {
-		return "", fmt.Errorf(variables.ProfileRoleNotFoundByLoginError, " %w", err)
+		return "", fmt.Errorf("%s %w", variables.ProfileRoleNotFoundByLoginError, err)
 	}
 
 	return role, nil
 }
+
+func (repository *ProfileRelationalRepository) SetUserRole(ctx context.Context, id int64, role string) error {
+	err := repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		var before string
+		err := tx.QueryRowContext(ctx, `SELECT role.value FROM profile_role
+		JOIN role ON profile_role.role_id = role.id
+		WHERE profile_role.profile_id = $1 FOR UPDATE OF profile_role`, id).Scan(&before)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s %d", variables.ProfileRoleNotFoundByLoginError, id)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE profile_role
+		SET role_id = (SELECT id FROM role WHERE value = $2)
+		WHERE profile_id = $1`, id, role)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(ctx, tx, audit.Entry{
+			Action:   variables.AuditActionUpdate,
+			Entity:   variables.AuditEntityUser,
+			EntityId: id,
+			Before:   userState{Role: before},
+			After:    userState{Role: role},
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("%s %w", variables.ProfileRoleNotUpdatedError, err)
+	}
+
+	return nil
+}
+
+func (repository *ProfileRelationalRepository) GetAudit(ctx context.Context, filter models.AuditFilter, offset uint64, limit uint64) (communication.AuditListResponse, error) {
+	entries, err := audit.List(ctx, repository.db, filter, offset, limit)
+	if err != nil {
+		return communication.AuditListResponse{}, err
+	}
+	return communication.AuditListResponse{Entries: entries}, nil
+}
+
+func (repository *ProfileRelationalRepository) Close() error {
+	err := repository.db.Close()
+	if err != nil {
+		return fmt.Errorf("%s %w", variables.SqlCloseError, err)
+	}
+	return nil
+}
+
+func (repository *ProfileRelationalRepository) Name() string {
+	return variables.PostgresHealthChecker
+}
+
+func (repository *ProfileRelationalRepository) CheckHealth(ctx context.Context) error {
+	return repository.db.PingContext(ctx)
+}
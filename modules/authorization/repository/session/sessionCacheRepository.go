@@ -2,6 +2,7 @@ package session
 
 import (
 	"context"
+	"filmoteka/pkg/metrics"
 	"filmoteka/pkg/models"
 	"filmoteka/pkg/variables"
 	"fmt"
@@ -51,16 +52,16 @@ func (sessionCacheRepository *SessionCacheRepository) pingRedis(timer int, logge
 		reconnectErrString = reconnectErr.Error()
 
 		retries++
-		logger.Error(variables.AuthorizationCachePingRetryError, pingErr.Error(), reconnectErr.Error())
+		logger.Error(variables.AuthorizationCachePingRetryError, "ping_error", pingErr, "reconnect_error", reconnectErr)
 		time.Sleep(time.Duration(timer) * time.Second)
 	}
 
-	return fmt.Errorf(variables.AuthorizationCachePingMaxRetriesError, pingErrString, reconnectErrString)
+	return fmt.Errorf("%s: %s, %s", variables.AuthorizationCachePingMaxRetriesError, pingErrString, reconnectErrString)
 }
 
 func GetSessionRepository(sessionConfig *variables.CacheDataBaseConfig, logger *slog.Logger) (*SessionCacheRepository, error) {
 	redisClient := redis.NewClient(&redis.Options{
-		Addr:     sessionConfig.Host,
+		Addr:     sessionConfig.Addr,
 		Password: sessionConfig.Password,
 		DB:       sessionConfig.DbNumber,
 	})
@@ -75,6 +76,11 @@ func GetSessionRepository(sessionConfig *variables.CacheDataBaseConfig, logger *
 		sessionRedisClient: redisClient,
 	}
 
+	err = metrics.RegisterRedisPoolStats(sessionConfig.Addr, sessionCacheRepository.poolStats)
+	if err != nil {
+		logger.Error(variables.MetricsRegisterError, "error", err)
+	}
+
 	errs := make(chan error)
 
 	go func() {
@@ -82,7 +88,7 @@ func GetSessionRepository(sessionConfig *variables.CacheDataBaseConfig, logger *
 	}()
 
 	if err := <-errs; err != nil {
-		logger.Error(err.Error())
+		logger.Error(variables.AuthorizationCachePingMaxRetriesError, "error", err)
 		return nil, err
 	}
 
@@ -90,7 +96,7 @@ func GetSessionRepository(sessionConfig *variables.CacheDataBaseConfig, logger *
 }
 
 func (sessionCacheRepository *SessionCacheRepository) SaveSessionCache(ctx context.Context, createdSessionObject models.Session, logger *slog.Logger) (bool, error) {
-	sessionCacheRepository.sessionRedisClient.Set(ctx, createdSessionObject.SID, createdSessionObject.Login, 24*time.Hour)
+	sessionCacheRepository.sessionRedisClient.Set(ctx, createdSessionObject.SID, createdSessionObject.Login, time.Until(createdSessionObject.ExpiresAt))
 
 	sessionAdded, errCheck := sessionCacheRepository.GetSessionCache(ctx, createdSessionObject.SID, logger)
 
@@ -104,12 +110,12 @@ func (sessionCacheRepository *SessionCacheRepository) SaveSessionCache(ctx conte
 func (sessionCacheRepository *SessionCacheRepository) GetSessionCache(ctx context.Context, sid string, logger *slog.Logger) (bool, error) {
 	_, err := sessionCacheRepository.sessionRedisClient.Get(ctx, sid).Result()
 	if err == redis.Nil {
-		logger.Error(variables.SessionNotFoundError, sid)
+		logger.Warn(variables.SessionNotFoundError)
 		return false, nil
 	}
 
 	if err != nil {
-		logger.Error(variables.StatusInternalServerError, err)
+		logger.Error(variables.StatusInternalServerError, "error", err)
 		return false, err
 	}
 
@@ -119,7 +125,7 @@ func (sessionCacheRepository *SessionCacheRepository) GetSessionCache(ctx contex
 func (sessionCacheRepository *SessionCacheRepository) DeleteSessionCache(ctx context.Context, sid string, logger *slog.Logger) (bool, error) {
 	_, err := sessionCacheRepository.sessionRedisClient.Del(ctx, sid).Result()
 	if err != nil {
-		logger.Error(variables.SessionRemoveError, err)
+		logger.Error(variables.SessionRemoveError, "error", err)
 		return false, err
 	}
 
@@ -129,9 +135,29 @@ func (sessionCacheRepository *SessionCacheRepository) DeleteSessionCache(ctx con
 func (sessionCacheRepository *SessionCacheRepository) GetUserLogin(ctx context.Context, sid string, logger *slog.Logger) (string, error) {
 	value, err := sessionCacheRepository.sessionRedisClient.Get(ctx, sid).Result()
 	if err != nil {
-		logger.Error(variables.SessionNotFoundError + sid)
+		logger.Warn(variables.SessionNotFoundError, "error", err)
 		return "", err
 	}
 
 	return value, nil
 }
+
+func (sessionCacheRepository *SessionCacheRepository) Close() error {
+	err := sessionCacheRepository.sessionRedisClient.Close()
+	if err != nil {
+		return fmt.Errorf("%s %w", variables.CacheCloseError, err)
+	}
+	return nil
+}
+
+func (sessionCacheRepository *SessionCacheRepository) Name() string {
+	return variables.RedisHealthChecker
+}
+
+func (sessionCacheRepository *SessionCacheRepository) CheckHealth(ctx context.Context) error {
+	return sessionCacheRepository.sessionRedisClient.Ping(ctx).Err()
+}
+
+func (sessionCacheRepository *SessionCacheRepository) poolStats() *redis.PoolStats {
+	return sessionCacheRepository.sessionRedisClient.PoolStats()
+}
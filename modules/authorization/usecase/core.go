@@ -2,9 +2,13 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"filmoteka/modules/authorization/repository/profile"
 	"filmoteka/modules/authorization/repository/session"
+	"filmoteka/pkg/health"
+	"filmoteka/pkg/metrics"
 	"filmoteka/pkg/models"
+	communication "filmoteka/pkg/requests"
 	"filmoteka/pkg/util"
 	"filmoteka/pkg/variables"
 	"fmt"
@@ -16,11 +20,15 @@ import (
 
 // Relational data base interface
 type IProfileRelationalRepository interface {
-	CreateUser(login string, password []byte) error
-	FindUser(login string) (bool, error)
-	GetUser(login string, password []byte) (*models.UserItem, bool, error)
-	GetUserProfileId(login string) (int64, error)
-	GetUserRole(id int64) (string, error)
+	CreateUser(ctx context.Context, login string, password []byte) error
+	FindUser(ctx context.Context, login string) (bool, error)
+	GetUser(ctx context.Context, login string, password []byte) (*models.UserItem, bool, error)
+	GetUserProfileId(ctx context.Context, login string) (int64, error)
+	GetUserRole(ctx context.Context, id int64) (string, error)
+	SetUserRole(ctx context.Context, id int64, role string) error
+	GetAudit(ctx context.Context, filter models.AuditFilter, offset uint64, limit uint64) (communication.AuditListResponse, error)
+	Close() error
+	health.IHealthChecker
 }
 
 // Cache data base interface
@@ -29,47 +37,54 @@ type ISessionCacheRepository interface {
 	GetSessionCache(ctx context.Context, sid string, logger *slog.Logger) (bool, error)
 	DeleteSessionCache(ctx context.Context, sid string, logger *slog.Logger) (bool, error)
 	GetUserLogin(ctx context.Context, sid string, logger *slog.Logger) (string, error)
+	Close() error
+	health.IHealthChecker
 }
 
 type Core struct {
-	sessions ISessionCacheRepository
-	logger   *slog.Logger
-	mutex    sync.RWMutex
-	profiles IProfileRelationalRepository
+	sessions      ISessionCacheRepository
+	sessionTTL    func() time.Duration
+	logger        *slog.Logger
+	mutex         sync.RWMutex
+	profiles      IProfileRelationalRepository
+	invalidations *InvalidationBroker
 }
 
-func GetCore(profileConfig *variables.RelationalDataBaseConfig, sessionConfig *variables.CacheDataBaseConfig, logger *slog.Logger) (*Core, error) {
+func GetCore(profileConfig *variables.RelationalDataBaseConfig, sessionConfig *variables.CacheDataBaseConfig, invalidations *InvalidationBroker, sessionTTL func() time.Duration, logger *slog.Logger) (*Core, error) {
 	sessionRepository, err := session.GetSessionRepository(sessionConfig, logger)
 	if err != nil {
-		logger.Error(variables.SessionRepositoryNotActiveError)
+		logger.Error(variables.SessionRepositoryNotActiveError, "error", err)
 		return nil, err
 	}
 
 	profileRepository, err := profile.GetProfileRepository(profileConfig, logger)
 	if err != nil {
-		logger.Error(variables.ProfileRepositoryNotActiveError)
+		logger.Error(variables.ProfileRepositoryNotActiveError, "error", err)
 		return nil, err
 	}
 
 	core := Core{
-		sessions: sessionRepository,
-		logger:   logger.With(variables.ModuleLogger, variables.CoreModuleLogger),
-		profiles: profileRepository,
+		sessions:      sessionRepository,
+		sessionTTL:    sessionTTL,
+		logger:        logger.With(variables.ModuleLogger, variables.CoreModuleLogger),
+		profiles:      profileRepository,
+		invalidations: invalidations,
 	}
 
 	return &core, nil
 }
 
 func (core *Core) CreateSession(ctx context.Context, login string) (models.Session, error) {
+	logger := util.ContextLogger(ctx, core.logger)
 	sid := util.RandStringRunes(32)
 
 	newSession := models.Session{
 		Login:     login,
 		SID:       sid,
-		ExpiresAt: time.Now().Add(time.Hour * 24),
+		ExpiresAt: time.Now().Add(core.sessionTTL()),
 	}
 	core.mutex.Lock()
-	sessionAdded, err := core.sessions.SaveSessionCache(ctx, newSession, core.logger)
+	sessionAdded, err := core.sessions.SaveSessionCache(ctx, newSession, logger)
 	defer core.mutex.Unlock()
 
 	if !sessionAdded && err != nil {
@@ -84,20 +99,23 @@ func (core *Core) CreateSession(ctx context.Context, login string) (models.Sessi
 }
 
 func (core *Core) KillSession(ctx context.Context, sid string) error {
+	logger := util.ContextLogger(ctx, core.logger)
 	core.mutex.Lock()
-	_, err := core.sessions.DeleteSessionCache(ctx, sid, core.logger)
+	_, err := core.sessions.DeleteSessionCache(ctx, sid, logger)
 	defer core.mutex.Unlock()
 
 	if err != nil {
 		return err
 	}
 
+	core.invalidations.Publish(models.Invalidation{Sid: sid})
 	return nil
 }
 
 func (core *Core) FindActiveSession(ctx context.Context, sid string) (bool, error) {
+	logger := util.ContextLogger(ctx, core.logger)
 	core.mutex.RLock()
-	found, err := core.sessions.GetSessionCache(ctx, sid, core.logger)
+	found, err := core.sessions.GetSessionCache(ctx, sid, logger)
 	defer core.mutex.RUnlock()
 
 	if err != nil {
@@ -107,67 +125,116 @@ func (core *Core) FindActiveSession(ctx context.Context, sid string) (bool, erro
 	return found, nil
 }
 
-func (core *Core) CreateUserAccount(login string, password string) error {
+func (core *Core) CreateUserAccount(ctx context.Context, login string, password string) error {
+	logger := util.ContextLogger(ctx, core.logger)
 	matched, err := regexp.MatchString(variables.LoginRegexp, login)
 	if err != nil {
-		core.logger.Error(variables.StatusInternalServerError, err.Error())
-		return fmt.Errorf(variables.StatusInternalServerError, " %w", err)
+		logger.Error(variables.StatusInternalServerError, "error", err)
+		return fmt.Errorf("%s %w", variables.StatusInternalServerError, err)
 	}
 	if !matched {
-		core.logger.Error(variables.InvalidEmailOrPasswordError)
+		logger.Warn(variables.InvalidEmailOrPasswordError)
 		return fmt.Errorf(variables.InvalidEmailOrPasswordError)
 	}
 
 	hashPassword := util.HashPassword(password)
-	err = core.profiles.CreateUser(login, hashPassword)
+	err = core.profiles.CreateUser(ctx, login, hashPassword)
 	if err != nil {
-		core.logger.Error(variables.CreateProfileError, err.Error())
+		logger.Error(variables.CreateProfileError, "error", err)
 		return err
 	}
 
+	metrics.SignupsTotal.Inc()
 	return nil
 }
 
-func (core *Core) FindUserByLogin(login string) (bool, error) {
-	found, err := core.profiles.FindUser(login)
+func (core *Core) FindUserByLogin(ctx context.Context, login string) (bool, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	found, err := core.profiles.FindUser(ctx, login)
 	if err != nil {
-		core.logger.Error(variables.ProfileNotFoundError, err.Error())
+		logger.Error(variables.ProfileNotFoundError, "error", err)
 		return false, err
 	}
 
 	return found, nil
 }
 
-func (core *Core) FindUserAccount(login string, password string) (*models.UserItem, bool, error) {
+func (core *Core) FindUserAccount(ctx context.Context, login string, password string) (*models.UserItem, bool, error) {
+	logger := util.ContextLogger(ctx, core.logger)
 	hashPassword := util.HashPassword(password)
-	user, found, err := core.profiles.GetUser(login, hashPassword)
+	user, found, err := core.profiles.GetUser(ctx, login, hashPassword)
 	if err != nil {
-		core.logger.Error(variables.ProfileNotFoundError, err.Error())
+		metrics.LoginsTotal.WithLabelValues(variables.LoginResultFailure).Inc()
+		logger.Error(variables.ProfileNotFoundError, "error", err)
 		return nil, false, err
 	}
+
+	if !found {
+		metrics.LoginsTotal.WithLabelValues(variables.LoginResultFailure).Inc()
+		return user, found, nil
+	}
+	metrics.LoginsTotal.WithLabelValues(variables.LoginResultSuccess).Inc()
 	return user, found, nil
 }
 
 func (core *Core) GetUserId(ctx context.Context, sid string) (int64, error) {
-	login, err := core.sessions.GetUserLogin(ctx, sid, core.logger)
+	logger := util.ContextLogger(ctx, core.logger)
+	login, err := core.sessions.GetUserLogin(ctx, sid, logger)
 	if err != nil {
 		return 0, err
 	}
 
-	id, err := core.profiles.GetUserProfileId(login)
+	id, err := core.profiles.GetUserProfileId(ctx, login)
 	if err != nil {
-		core.logger.Error(variables.GetProfileError, " id: %v", err)
+		logger.Error(variables.GetProfileError, "error", err)
 		return 0, err
 	}
 	return id, nil
 }
 
 func (core *Core) GetUserRole(ctx context.Context, id int64) (string, error) {
-	role, err := core.profiles.GetUserRole(id)
+	logger := util.ContextLogger(ctx, core.logger)
+	role, err := core.profiles.GetUserRole(ctx, id)
 	if err != nil {
-		core.logger.Error(variables.GetProfileRoleError, err.Error())
-		return "", fmt.Errorf(variables.GetProfileRoleError, " %w", err)
+		logger.Error(variables.GetProfileRoleError, "error", err)
+		return "", fmt.Errorf("%s %w", variables.GetProfileRoleError, err)
 	}
 
 	return role, nil
 }
+
+func (core *Core) ChangeUserRole(ctx context.Context, id int64, role string) error {
+	logger := util.ContextLogger(ctx, core.logger)
+	if role != variables.UserRole && role != variables.AdminRole {
+		logger.Warn(variables.InvalidRoleError, "role", role)
+		return util.InvalidInputError(fmt.Sprintf("%s: %s", variables.InvalidRoleError, role))
+	}
+
+	err := core.profiles.SetUserRole(ctx, id, role)
+	if err != nil {
+		logger.Error(variables.ChangeProfileRoleError, "error", err)
+		return err
+	}
+
+	core.invalidations.Publish(models.Invalidation{Id: id})
+	return nil
+}
+
+func (core *Core) GetAudit(ctx context.Context, filter models.AuditFilter, offset uint64, limit uint64) (communication.AuditListResponse, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	entries, err := core.profiles.GetAudit(ctx, filter, offset, limit)
+	if err != nil {
+		logger.Error(variables.AuditListError, "error", err)
+		return communication.AuditListResponse{}, err
+	}
+	return entries, nil
+}
+
+// Close releases the cache connection first, then the relational pool.
+func (core *Core) Close() error {
+	return errors.Join(core.sessions.Close(), core.profiles.Close())
+}
+
+func (core *Core) HealthCheckers() []health.IHealthChecker {
+	return []health.IHealthChecker{core.profiles, core.sessions}
+}
@@ -0,0 +1,20 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"filmoteka/pkg/variables"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestChangeUserRoleRejectsUnknownRole(t *testing.T) {
+	// No repositories, an unknown role must be turned down before them
+	core := &Core{logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	err := core.ChangeUserRole(context.Background(), 1, "owner")
+	if !errors.Is(err, variables.ErrInvalidInput) {
+		t.Errorf("err = %v, want a failed input check", err)
+	}
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"filmoteka/pkg/models"
+	"sync"
+)
+
+// InvalidationBroker fans out killed sessions and changed roles to every connected watcher.
+type InvalidationBroker struct {
+	mutex       sync.Mutex
+	subscribers map[chan models.Invalidation]struct{}
+	bufferSize  int
+}
+
+func GetInvalidationBroker(bufferSize int) *InvalidationBroker {
+	return &InvalidationBroker{
+		subscribers: make(map[chan models.Invalidation]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+func (broker *InvalidationBroker) Subscribe() chan models.Invalidation {
+	subscriber := make(chan models.Invalidation, broker.bufferSize)
+
+	broker.mutex.Lock()
+	broker.subscribers[subscriber] = struct{}{}
+	broker.mutex.Unlock()
+
+	return subscriber
+}
+
+func (broker *InvalidationBroker) Unsubscribe(subscriber chan models.Invalidation) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	if _, found := broker.subscribers[subscriber]; found {
+		delete(broker.subscribers, subscriber)
+		close(subscriber)
+	}
+}
+
+// Publish never blocks: a watcher that can't keep up is dropped, so it
+// reconnects and purges its cache instead of serving stale identities.
+func (broker *InvalidationBroker) Publish(event models.Invalidation) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	for subscriber := range broker.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			delete(broker.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"filmoteka/pkg/models"
+	"testing"
+)
+
+func TestPublishDropsSlowSubscriber(t *testing.T) {
+	broker := GetInvalidationBroker(1)
+	slow := broker.Subscribe()
+	fast := broker.Subscribe()
+
+	broker.Publish(models.Invalidation{Sid: "first"})
+	<-fast
+	broker.Publish(models.Invalidation{Sid: "second"})
+
+	if event, open := <-slow; !open || event.Sid != "first" {
+		t.Fatalf("slow subscriber got %+v, %v, want the buffered first event", event, open)
+	}
+	if _, open := <-slow; open {
+		t.Error("slow subscriber was kept after its buffer filled up")
+	}
+	if event := <-fast; event.Sid != "second" {
+		t.Errorf("fast subscriber got %+v, want the second event", event)
+	}
+
+	broker.Publish(models.Invalidation{Id: 7})
+	if event := <-fast; event.Id != 7 {
+		t.Errorf("fast subscriber got %+v after the drop, want role 7", event)
+	}
+	// unsubscribing a dropped watcher must not close its channel twice
+	broker.Unsubscribe(slow)
+	broker.Unsubscribe(fast)
+	if _, open := <-fast; open {
+		t.Error("Unsubscribe left the channel open")
+	}
+}
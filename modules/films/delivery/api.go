@@ -2,27 +2,51 @@ package delivery
 
 import (
 	"context"
+	"errors"
+	"filmoteka/configs"
+	"filmoteka/pkg/audit"
+	"filmoteka/pkg/health"
+	"filmoteka/pkg/logging"
 	"filmoteka/pkg/middleware"
+	"filmoteka/pkg/models"
 	communication "filmoteka/pkg/requests"
 	"filmoteka/pkg/util"
 	"filmoteka/pkg/variables"
 	"log/slog"
+	"net"
 	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // Core interface
 
 //go:generate mockgen -source=api.go -destination=../mocks/core_mock.go -package=mocks
 type ICore interface {
-	GetFilms(begin uint64, end uint64, sortType string) (communication.FilmsListResponse, error)
-	FindFilm(filmName string, actorName string) (communication.FindFilmResponse, error)
-	AddFilm(title string, description string, rating float64, releaseDate string, crew []int64) error
-	EditFilm(id int64, title string, description string, rating float64, releaseDate string, crew []int64) error
-	GetActors(begin uint64, end uint64) (communication.ActorsListResponse, error)
-	AddActor(name string, gender string, birthdate string) error
-	EditActor(id int64, name string, gender string, birthdate string, films []int64) error
-	DeleteActor(id int64) error
-	DeleteFilm(id int64) error
+	GetFilms(ctx context.Context, offset uint64, limit uint64, sort []models.SortKey, filter models.FilmFilter) (communication.FilmsListResponse, error)
+	FindFilm(ctx context.Context, query string, offset uint64, limit uint64) (communication.FindFilmResponse, error)
+	Search(ctx context.Context, query string, filmsPage uint64, actorsPage uint64, pageSize uint64) (communication.SearchResponse, error)
+	Suggest(ctx context.Context, query string, limit uint64) (communication.SuggestResponse, error)
+	RebuildSearchIndex(ctx context.Context) (int, int, error)
+	AddFilm(ctx context.Context, title string, description string, rating float64, releaseDate string, crew []models.CrewMember, metadata models.FilmMetadata) (int64, error)
+	GetFilm(ctx context.Context, id int64) (models.FilmItem, error)
+	EditFilm(ctx context.Context, id int64, version int64, patch models.FilmPatch) (int64, error)
+	GetActors(ctx context.Context, offset uint64, limit uint64) (communication.ActorsListResponse, error)
+	AddActor(ctx context.Context, name string, gender string, birthdate string) (int64, error)
+	GetActor(ctx context.Context, id int64) (models.ActorItem, error)
+	EditActor(ctx context.Context, id int64, version int64, patch models.ActorPatch) (int64, error)
+	DeleteActor(ctx context.Context, id int64, version int64) error
+	DeleteFilm(ctx context.Context, id int64, version int64) error
+	GetTrash(ctx context.Context, offset uint64, limit uint64) (communication.TrashListResponse, error)
+	RestoreFromTrash(ctx context.Context, itemType string, id int64) error
+	PurgeFromTrash(ctx context.Context, itemType string, id int64) error
+	GetAudit(ctx context.Context, filter models.AuditFilter, offset uint64, limit uint64) (communication.AuditListResponse, error)
+	GetFilmRevisions(ctx context.Context, id int64, offset uint64, limit uint64) (communication.FilmRevisionsResponse, error)
+	RevertFilm(ctx context.Context, id int64, version int64, revision int64) (int64, error)
+	GetGenres(ctx context.Context) (communication.GenresListResponse, error)
+	AddGenre(ctx context.Context, slug string, name string) (int64, error)
+	EditGenre(ctx context.Context, id int64, slug string, name string) error
+	DeleteGenre(ctx context.Context, id int64) error
 	GetUserRole(ctx context.Context, id int64) (string, error)
 	GetUserId(ctx context.Context, sid string) (int64, error)
 }
@@ -30,95 +54,233 @@ type ICore interface {
 type API struct {
 	core   ICore
 	logger *slog.Logger
+	config *configs.Reloader[variables.FilmsConfig]
 	mux    *http.ServeMux
+	server *http.Server
 }
 
 func (api *API) ListenAndServe(appConfig *variables.AppConfig) error {
-	err := http.ListenAndServe(appConfig.Address, api.mux)
+	listener, err := net.Listen(variables.ListenNetworkType, appConfig.Address)
 	if err != nil {
-		//api.logger.Error(variables.ListenAndServeError, err.Error())
+		return err
+	}
+
+	err = api.server.Serve(listener)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
 	return nil
 }
 
-func GetFilmsApi(filmsCore ICore, filmsLogger *slog.Logger) *API {
+func (api *API) Shutdown(ctx context.Context) error {
+	return api.server.Shutdown(ctx)
+}
+
+func (api *API) permissions() map[string]string {
+	return api.config.Current().App.Permissions
+}
+
+func GetFilmsApi(filmsCore ICore, checkers []health.IHealthChecker, levels *logging.Levels, config *configs.Reloader[variables.FilmsConfig], filmsLogger *slog.Logger) *API {
 	api := &API{
 		core:   filmsCore,
-		logger: filmsLogger,
+		logger: filmsLogger.With(variables.ModuleLogger, variables.DeliveryModuleLogger),
+		config: config,
 		mux:    http.NewServeMux(),
 	}
+	api.server = &http.Server{
+		Handler: middleware.TracingMiddleware(
+			middleware.RequestIdMiddleware(
+				middleware.AccessLogMiddleware(middleware.MetricsMiddleware(api.mux), api.mux, api.logger)),
+			api.mux, variables.FilmsServiceName),
+		ReadTimeout:  variables.HttpReadTimeout,
+		WriteTimeout: variables.HttpWriteTimeout,
+		IdleTimeout:  variables.HttpIdleTimeout,
+	}
+
+	// Metrics handler
+	api.mux.Handle("GET "+variables.MetricsRoute, promhttp.Handler())
+
+	// Health handlers
+	api.mux.Handle("GET /healthz", health.LivenessHandler(api.logger))
+
+	api.mux.Handle("GET /readyz", health.ReadinessHandler(checkers, api.logger))
+
+	// Log level handler
+	api.mux.Handle("POST "+variables.LogLevelRoute, middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			logging.LevelsHandler(levels, api.logger), api.core, variables.PermissionLogLevel, api.permissions, api.logger),
+		api.core, api.logger))
 
 	// Actors handlers
-	api.mux.Handle("/api/v1/actors", middleware.MethodMiddleware(
-		middleware.AuthorizationMiddleware(
-			http.HandlerFunc(api.GetActors),
-			api.core, api.logger),
-		http.MethodGet,
-		api.logger))
+	api.mux.Handle("GET /api/v1/actors", middleware.AuthorizationMiddleware(
+		http.HandlerFunc(api.GetActors),
+		api.core, api.logger))
 
-	api.mux.Handle("/api/v1/actors/add", middleware.MethodMiddleware(
-		middleware.AuthorizationMiddleware(
-			middleware.PermissionsMiddleware(
-				http.HandlerFunc(api.AddInfoAboutActor), api.core, variables.AdminRole, api.logger),
-			api.core, api.logger),
-		http.MethodPost,
-		api.logger))
+	api.mux.Handle("POST /api/v1/actors", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.AddInfoAboutActor), api.core, variables.PermissionCatalogWrite, api.permissions, api.logger),
+		api.core, api.logger))
 
-	api.mux.Handle("/api/v1/actors/edit", middleware.MethodMiddleware(
-		middleware.AuthorizationMiddleware(
-			middleware.PermissionsMiddleware(
-				http.HandlerFunc(api.EditInfoAboutActor), api.core, variables.AdminRole, api.logger),
-			api.core, api.logger),
-		http.MethodPost,
-		api.logger))
+	api.mux.Handle("GET /api/v1/actors/{id}", middleware.AuthorizationMiddleware(
+		http.HandlerFunc(api.GetActor),
+		api.core, api.logger))
+
+	api.mux.Handle("PATCH /api/v1/actors/{id}", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.PatchActor), api.core, variables.PermissionCatalogWrite, api.permissions, api.logger),
+		api.core, api.logger))
+
+	api.mux.Handle("PUT /api/v1/actors/{id}", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.ReplaceActor), api.core, variables.PermissionCatalogWrite, api.permissions, api.logger),
+		api.core, api.logger))
+
+	api.mux.Handle("DELETE /api/v1/actors/{id}", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.DeleteActor), api.core, variables.PermissionCatalogWrite, api.permissions, api.logger),
+		api.core, api.logger))
+
+	// Films handlers
+	api.mux.Handle("GET /api/v1/films", middleware.AuthorizationMiddleware(
+		http.HandlerFunc(api.GetFilms),
+		api.core, api.logger))
+
+	api.mux.Handle("POST /api/v1/films", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.AddFilm), api.core, variables.PermissionCatalogWrite, api.permissions, api.logger),
+		api.core, api.logger))
+
+	api.mux.Handle("GET /api/v1/films/search", middleware.AuthorizationMiddleware(
+		http.HandlerFunc(api.SearchFilms),
+		api.core, api.logger))
+
+	// Search handlers
+	api.mux.Handle("GET /api/v1/search", middleware.AuthorizationMiddleware(
+		http.HandlerFunc(api.Search),
+		api.core, api.logger))
 
-	api.mux.Handle("/api/v1/actors/remove", middleware.MethodMiddleware(
+	api.mux.Handle("GET /api/v1/search/suggest", middleware.AuthorizationMiddleware(
+		http.HandlerFunc(api.Suggest),
+		api.core, api.logger))
+
+	api.mux.Handle("POST /api/v1/search/rebuild", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.RebuildSearchIndex), api.core, variables.PermissionSearchManage, api.permissions, api.logger),
+		api.core, api.logger))
+
+	api.mux.Handle("GET /api/v1/films/{id}", middleware.AuthorizationMiddleware(
+		http.HandlerFunc(api.GetFilm),
+		api.core, api.logger))
+
+	api.mux.Handle("PATCH /api/v1/films/{id}", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.PatchFilm), api.core, variables.PermissionCatalogWrite, api.permissions, api.logger),
+		api.core, api.logger))
+
+	api.mux.Handle("PUT /api/v1/films/{id}", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.ReplaceFilm), api.core, variables.PermissionCatalogWrite, api.permissions, api.logger),
+		api.core, api.logger))
+
+	api.mux.Handle("DELETE /api/v1/films/{id}", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.DeleteFilm), api.core, variables.PermissionCatalogWrite, api.permissions, api.logger),
+		api.core, api.logger))
+
+	api.mux.Handle("GET /api/v1/films/{id}/revisions", middleware.AuthorizationMiddleware(
+		http.HandlerFunc(api.GetFilmRevisions),
+		api.core, api.logger))
+
+	api.mux.Handle("POST /api/v1/films/{id}/revert", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.RevertFilm), api.core, variables.PermissionCatalogWrite, api.permissions, api.logger),
+		api.core, api.logger))
+
+	// Deprecated RPC style aliases, ids are passed in the body. Only POST is
+	// served, other methods get 405 rather than the {id} routes.
+	api.handleAlias("/api/v1/actors/add", middleware.DeprecatedMiddleware(
 		middleware.AuthorizationMiddleware(
 			middleware.PermissionsMiddleware(
-				http.HandlerFunc(api.RemoveInfoAboutActor), api.core, variables.AdminRole, api.logger),
+				http.HandlerFunc(api.AddInfoAboutActor), api.core, variables.PermissionCatalogWrite, api.permissions, api.logger),
 			api.core, api.logger),
-		http.MethodPost,
-		api.logger))
+		variables.ActorsResourcePath))
 
-	// Films handlers
-	api.mux.Handle("/api/v1/films", middleware.MethodMiddleware(
+	api.handleAlias("/api/v1/actors/edit", middleware.DeprecatedMiddleware(
 		middleware.AuthorizationMiddleware(
-			http.HandlerFunc(api.GetFilms),
+			middleware.PermissionsMiddleware(
+				http.HandlerFunc(api.EditInfoAboutActor), api.core, variables.PermissionCatalogWrite, api.permissions, api.logger),
 			api.core, api.logger),
-		http.MethodGet,
-		api.logger))
+		variables.ActorsResourcePath))
 
-	api.mux.Handle("/api/v1/films/search", middleware.MethodMiddleware(
+	api.handleAlias("/api/v1/actors/remove", middleware.DeprecatedMiddleware(
 		middleware.AuthorizationMiddleware(
-			http.HandlerFunc(api.SearchFilms),
+			middleware.PermissionsMiddleware(
+				http.HandlerFunc(api.RemoveInfoAboutActor), api.core, variables.PermissionCatalogWrite, api.permissions, api.logger),
 			api.core, api.logger),
-		http.MethodGet,
-		api.logger))
+		variables.ActorsResourcePath))
 
-	api.mux.Handle("/api/v1/films/add", middleware.MethodMiddleware(
+	api.handleAlias("/api/v1/films/add", middleware.DeprecatedMiddleware(
 		middleware.AuthorizationMiddleware(
 			middleware.PermissionsMiddleware(
-				http.HandlerFunc(api.AddFilm), api.core, variables.AdminRole, api.logger),
+				http.HandlerFunc(api.AddFilm), api.core, variables.PermissionCatalogWrite, api.permissions, api.logger),
 			api.core, api.logger),
-		http.MethodPost,
-		api.logger))
+		variables.FilmsResourcePath))
 
-	api.mux.Handle("/api/v1/films/edit", middleware.MethodMiddleware(
+	api.handleAlias("/api/v1/films/edit", middleware.DeprecatedMiddleware(
 		middleware.AuthorizationMiddleware(
 			middleware.PermissionsMiddleware(
-				http.HandlerFunc(api.EditFilm), api.core, variables.AdminRole, api.logger),
+				http.HandlerFunc(api.EditFilm), api.core, variables.PermissionCatalogWrite, api.permissions, api.logger),
 			api.core, api.logger),
-		http.MethodPost,
-		api.logger))
+		variables.FilmsResourcePath))
 
-	api.mux.Handle("/api/v1/films/remove", middleware.MethodMiddleware(
+	api.handleAlias("/api/v1/films/remove", middleware.DeprecatedMiddleware(
 		middleware.AuthorizationMiddleware(
 			middleware.PermissionsMiddleware(
-				http.HandlerFunc(api.RemoveFilm), api.core, variables.AdminRole, api.logger),
+				http.HandlerFunc(api.RemoveFilm), api.core, variables.PermissionCatalogWrite, api.permissions, api.logger),
 			api.core, api.logger),
-		http.MethodPost,
-		api.logger))
+		variables.FilmsResourcePath))
+
+	// Trash handlers
+	api.mux.Handle("GET /api/v1/trash", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.GetTrash), api.core, variables.PermissionTrashManage, api.permissions, api.logger),
+		api.core, api.logger))
+
+	api.mux.Handle("POST /api/v1/trash/restore", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.RestoreFromTrash), api.core, variables.PermissionTrashManage, api.permissions, api.logger),
+		api.core, api.logger))
+
+	api.mux.Handle("POST /api/v1/trash/purge", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.PurgeFromTrash), api.core, variables.PermissionTrashManage, api.permissions, api.logger),
+		api.core, api.logger))
+
+	// Genre dictionary handlers
+	api.mux.Handle("GET /api/v1/genres", middleware.AuthorizationMiddleware(
+		http.HandlerFunc(api.GetGenres),
+		api.core, api.logger))
+
+	api.mux.Handle("POST /api/v1/genres", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.AddGenre), api.core, variables.PermissionGenresManage, api.permissions, api.logger),
+		api.core, api.logger))
+
+	api.mux.Handle("PUT /api/v1/genres/{id}", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.EditGenre), api.core, variables.PermissionGenresManage, api.permissions, api.logger),
+		api.core, api.logger))
+
+	api.mux.Handle("DELETE /api/v1/genres/{id}", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.DeleteGenre), api.core, variables.PermissionGenresManage, api.permissions, api.logger),
+		api.core, api.logger))
+
+	// Audit handler
+	api.mux.Handle("GET /api/v1/audit", middleware.AuthorizationMiddleware(
+		middleware.PermissionsMiddleware(
+			http.HandlerFunc(api.GetAudit), api.core, variables.PermissionAuditRead, api.permissions, api.logger),
+		api.core, api.logger))
 
 	return api
 }
@@ -134,9 +296,9 @@ func GetFilmsApi(filmsCore ICore, filmsLogger *slog.Logger) *API {
 // @Failure 500 {string} string variables.StatusInternalServerError
 // @Router /api/v1/actors [get]
 func (api *API) GetActors(w http.ResponseWriter, r *http.Request) {
-	size, page := util.Pagination(r)
+	size, page := util.Pagination(r, api.config.Current().Pagination)
 
-	actors, err := api.core.GetActors(uint64((page-1)*size), size)
+	actors, err := api.core.GetActors(r.Context(), uint64((page-1)*size), size)
 	if err != nil {
 		util.SendResponse(w, r, http.StatusNotFound, nil, variables.ActorsNotFoundError, err, api.logger)
 		return
@@ -150,16 +312,44 @@ func (api *API) GetActors(w http.ResponseWriter, r *http.Request) {
 // @ID films-list
 // @Accept json
 // @Produce json
-// @Param sort_by query string true "sort order"
+// @Param sort query string false "comma separated sort keys title, rating, release_date, runtime, a leading dash sorts descending"
+// @Param sort_by query string false "legacy sort order name, rating or release_date"
+// @Param title_prefix query string false "title prefix"
+// @Param min_rating query number false "minimal rating"
+// @Param max_rating query number false "maximal rating"
+// @Param released_from query string false "released on or after, YYYY-MM-DD"
+// @Param released_to query string false "released on or before, YYYY-MM-DD"
+// @Param crew query string false "comma separated actor ids"
+// @Param crew_match query string false "any or all of the crew ids" Enums(any, all)
+// @Param genre query string false "genre slug"
+// @Param tag query string false "tag"
+// @Param country query string false "ISO 3166-1 alpha-2 production country"
+// @Param min_runtime query int false "minimal runtime in minutes"
+// @Param max_runtime query int false "maximal runtime in minutes"
+// @Param age_rating query string false "age rating"
+// @Param language query string false "ISO 639-1 original language"
 // @Success 200 {string} string "Sorted Films List"
+// @Failure 400 {string} string variables.FilmFilterError
+// @Failure 400 {string} string variables.FilmSortError
 // @Failure 404 {string} string variables.FilmsNotFoundError
 // @Failure 500 {string} string variables.StatusInternalServerError
 // @Router /api/v1/films [get]
 func (api *API) GetFilms(w http.ResponseWriter, r *http.Request) {
-	sortedBy := r.URL.Query().Get("sort_by")
-	pageSize, page := util.Pagination(r)
+	pageSize, page := util.Pagination(r, api.config.Current().Pagination)
+
+	sort, err := parseFilmSort(r)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.FilmSortError, err, api.logger)
+		return
+	}
 
-	films, err := api.core.GetFilms(uint64((page-1)*pageSize), pageSize, sortedBy)
+	filter, err := parseFilmFilter(r)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.FilmFilterError, err, api.logger)
+		return
+	}
+
+	films, err := api.core.GetFilms(r.Context(), uint64((page-1)*pageSize), pageSize, sort, filter)
 	if err != nil {
 		util.SendResponse(w, r, http.StatusNotFound, nil, variables.FilmsNotFoundError, err, api.logger)
 		return
@@ -169,21 +359,29 @@ func (api *API) GetFilms(w http.ResponseWriter, r *http.Request) {
 
 // @Summary Search-Films
 // @Tags films
-// @Description Search films
+// @Description Full text search over film titles, descriptions and crew names, ranked by relevance
 // @ID films-search
 // @Accept json
 // @Produce json
-// @Param film_name query string true "film name"
-// @Param actor_name query string true "actor name"
-// @Success 200 {string} string "Films list"
+// @Param q query string false "search query, quoted phrases, or and -word are supported"
+// @Param film_name query string false "film name, used without q"
+// @Param actor_name query string false "actor name, used without q"
+// @Param page query int false "page number"
+// @Param page_size query int false "page size"
+// @Success 200 {object} communication.FindFilmResponse "Films ranked by relevance"
+// @Failure 400 {string} string variables.SearchQueryError
 // @Failure 404 {string} string variables.FilmsNotFoundError
 // @Failure 500 {string} string variables.StatusInternalServerError
 // @Router /api/v1/films/search [get]
 func (api *API) SearchFilms(w http.ResponseWriter, r *http.Request) {
-	filmName := r.URL.Query().Get("film_name")
-	actorName := r.URL.Query().Get("actor_name")
+	query, err := searchQuery(r)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.SearchQueryError, err, api.logger)
+		return
+	}
+	pageSize, page := util.Pagination(r, api.config.Current().Pagination)
 
-	film, err := api.core.FindFilm(filmName, actorName)
+	film, err := api.core.FindFilm(r.Context(), query, (page-1)*pageSize, pageSize)
 	if err != nil {
 		util.SendResponse(w, r, http.StatusNotFound, nil, variables.FilmNotFoundError, err, api.logger)
 		return
@@ -191,6 +389,92 @@ func (api *API) SearchFilms(w http.ResponseWriter, r *http.Request) {
 	util.SendResponse(w, r, http.StatusOK, film, variables.StatusOkMessage, nil, api.logger)
 }
 
+// @Summary Search
+// @Tags search
+// @Description Search films and actors, with facet counts over the matching films
+// @ID search
+// @Accept json
+// @Produce json
+// @Param q query string true "search query, quoted phrases, or and -word are supported"
+// @Param films_page query int false "films page number"
+// @Param actors_page query int false "actors page number"
+// @Param page_size query int false "page size of both sections"
+// @Success 200 {object} communication.SearchResponse "Films, actors and facets"
+// @Failure 400 {string} string variables.SearchQueryError
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /api/v1/search [get]
+func (api *API) Search(w http.ResponseWriter, r *http.Request) {
+	query, err := searchQuery(r)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.SearchQueryError, err, api.logger)
+		return
+	}
+	pageSize, _ := util.Pagination(r, api.config.Current().Pagination)
+
+	result, err := api.core.Search(r.Context(), query,
+		sectionPage(r, variables.SearchFilmsPageParam), sectionPage(r, variables.SearchActorsPageParam), pageSize)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.SearchError, err, api.logger)
+		return
+	}
+	util.SendResponse(w, r, http.StatusOK, result, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Suggest
+// @Tags search
+// @Description Suggest films and actors for the typed text, misspellings included
+// @ID search-suggest
+// @Accept json
+// @Produce json
+// @Param q query string true "typed text"
+// @Param limit query int false "number of suggestions, up to 20"
+// @Success 200 {object} communication.SuggestResponse "Films and actors by similarity and popularity"
+// @Failure 400 {string} string variables.SearchQueryError
+// @Failure 400 {string} string variables.SuggestLimitError
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /api/v1/search/suggest [get]
+func (api *API) Suggest(w http.ResponseWriter, r *http.Request) {
+	query, err := searchQuery(r)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.SearchQueryError, err, api.logger)
+		return
+	}
+
+	limit, err := suggestLimit(r)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.SuggestLimitError, err, api.logger)
+		return
+	}
+
+	suggestions, err := api.core.Suggest(r.Context(), query, limit)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.SuggestionsNotFoundError, err, api.logger)
+		return
+	}
+	util.SendResponse(w, r, http.StatusOK, suggestions, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Rebuild-Search-Index
+// @Tags search
+// @Security ApiKeyAuth
+// @Description Index the whole catalogue anew
+// @ID search-rebuild
+// @Produce json
+// @Success 200 {object} communication.SearchRebuildResponse "Indexed films and actors"
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 500 {string} string variables.SearchIndexRebuildError
+// @Router /api/v1/search/rebuild [post]
+func (api *API) RebuildSearchIndex(w http.ResponseWriter, r *http.Request) {
+	films, actors, err := api.core.RebuildSearchIndex(r.Context())
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.SearchIndexRebuildError, err, api.logger)
+		return
+	}
+	util.SendResponse(w, r, http.StatusOK, communication.SearchRebuildResponse{Films: films, Actors: actors},
+		variables.SearchIndexRebuiltMessage, nil, api.logger)
+}
+
 // @Summary Add-Actor
 // @Tags films
 // @Security ApiKeyAuth
@@ -198,14 +482,16 @@ func (api *API) SearchFilms(w http.ResponseWriter, r *http.Request) {
 // @ID add-new-actor
 // @Accept json
 // @Produce json
-// @Header 200 {integer} 1
-// @Success 200 {string} string "Actor added"
+// @Param input body communication.AddActorRequest true "actor"
+// @Success 201 {string} string "Actor added"
+// @Header 201 {string} Location "path of the new actor"
 // @Failure 401 {string} string variables.StatusUnauthorizedError
 // @Failure 400 {string} string variables.StatusBadRequestError
 // @Failure 403 {string} string variables.StatusForbiddenError
 // @Failure 409 {string} string variables.ActorNotAddedError
+// @Failure 422 {string} string variables.ErrReferenceNotFound
 // @Failure 500 {string} string variables.StatusInternalServerError
-// @Router /api/v1/actors/add [post]
+// @Router /api/v1/actors [post]
 func (api *API) AddInfoAboutActor(w http.ResponseWriter, r *http.Request) {
 	var addActorRequest communication.AddActorRequest
 
@@ -214,40 +500,227 @@ func (api *API) AddInfoAboutActor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = api.core.AddActor(addActorRequest.Name, addActorRequest.Gender, addActorRequest.BirthDate)
+	id, err := api.core.AddActor(r.Context(), addActorRequest.Name, addActorRequest.Gender, addActorRequest.BirthDate)
 	if err != nil {
-		util.SendResponse(w, r, http.StatusConflict, nil, variables.ActorNotAddedError, err, api.logger)
+		api.sendCoreError(w, r, err, http.StatusConflict, variables.ActorNotAddedError)
 		return
 	}
-	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+	w.Header().Set(variables.LocationHeader, resourceLocation(variables.ActorsResourcePath, id))
+	util.SendResponse(w, r, http.StatusCreated, nil, variables.StatusOkMessage, nil, api.logger)
 }
 
 // @Summary Edit-Actor
 // @Tags films
 // @Security ApiKeyAuth
-// @Description Edit actors information
+// @Description Edit actors information, fields left out stay unchanged. Use PATCH /api/v1/actors/{id} instead
 // @ID edit-actor
 // @Accept json
 // @Produce json
+// @Param If-Match header string true "ETag of the version being changed, or *"
 // @Header 200 {integer} 1
+// @Param input body communication.EditActorRequest true "actor id and changed fields"
 // @Success 200 {string} string "Actor edited"
 // @Failure 401 {string} string variables.StatusUnauthorizedError
 // @Failure 400 {string} string variables.StatusBadRequestError
 // @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 404 {string} string variables.ErrNotFound
 // @Failure 409 {string} string variables.ActorNotEditedError
+// @Failure 422 {string} string variables.ErrReferenceNotFound
+// @Failure 412 {object} models.ActorItem "current version"
+// @Failure 428 {string} string variables.IfMatchRequiredError
 // @Failure 500 {string} string variables.StatusInternalServerError
+// @Deprecated
 // @Router /api/v1/actors/edit [post]
 func (api *API) EditInfoAboutActor(w http.ResponseWriter, r *http.Request) {
 	var editActorRequest communication.EditActorRequest
 
+	version, ok := api.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
 	err := util.GetRequestBody(w, r, &editActorRequest, api.logger)
 	if err != nil {
 		return
 	}
 
-	err = api.core.EditActor(editActorRequest.Id, editActorRequest.Name, editActorRequest.Gender, editActorRequest.BirthDate, editActorRequest.Films)
+	updated, err := api.core.EditActor(r.Context(), editActorRequest.Id, version, editActorRequest.ActorPatch)
 	if err != nil {
-		util.SendResponse(w, r, http.StatusConflict, nil, variables.ActorNotEditedError, err, api.logger)
+		api.sendActorEditError(w, r, editActorRequest.Id, err, http.StatusConflict, variables.ActorNotEditedError)
+		return
+	}
+	setETag(w, updated)
+	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Actor
+// @Tags films
+// @Security ApiKeyAuth
+// @Description Get an actor with its films, the version is returned as ETag
+// @ID get-actor
+// @Accept json
+// @Produce json
+// @Param id path int true "actor id"
+// @Success 200 {object} models.ActorItem
+// @Header 200 {string} ETag "actor version"
+// @Failure 400 {string} string variables.PathIdError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 404 {string} string variables.ErrNotFound
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /api/v1/actors/{id} [get]
+func (api *API) GetActor(w http.ResponseWriter, r *http.Request) {
+	id, ok := api.pathId(w, r)
+	if !ok {
+		return
+	}
+
+	actor, err := api.core.GetActor(r.Context(), id)
+	if err != nil {
+		api.sendCoreError(w, r, err, http.StatusInternalServerError, variables.ActorNotFoundError)
+		return
+	}
+	setETag(w, actor.Version)
+	util.SendResponse(w, r, http.StatusOK, actor, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Patch-Actor
+// @Tags films
+// @Security ApiKeyAuth
+// @Description Partially update an actor, fields left out or null stay unchanged and films change only when given
+// @ID patch-actor
+// @Accept json
+// @Produce json
+// @Param If-Match header string true "ETag of the version being changed, or *"
+// @Param id path int true "actor id"
+// @Param input body models.ActorPatch true "changed fields"
+// @Success 200 {string} string "Actor edited"
+// @Failure 400 {string} string variables.StatusBadRequestError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 404 {string} string variables.ErrNotFound
+// @Failure 409 {string} string variables.ActorNotEditedError
+// @Failure 422 {string} string variables.ErrReferenceNotFound
+// @Failure 412 {object} models.ActorItem "current version"
+// @Failure 428 {string} string variables.IfMatchRequiredError
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /api/v1/actors/{id} [patch]
+func (api *API) PatchActor(w http.ResponseWriter, r *http.Request) {
+	var actorPatch models.ActorPatch
+
+	id, ok := api.pathId(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := api.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	err := util.GetRequestBody(w, r, &actorPatch, api.logger)
+	if err != nil {
+		return
+	}
+
+	updated, err := api.core.EditActor(r.Context(), id, version, actorPatch)
+	if err != nil {
+		api.sendActorEditError(w, r, id, err, http.StatusConflict, variables.ActorNotEditedError)
+		return
+	}
+	setETag(w, updated)
+	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Replace-Actor
+// @Tags films
+// @Security ApiKeyAuth
+// @Description Replace an actor, fields left out are cleared
+// @ID replace-actor
+// @Accept json
+// @Produce json
+// @Param If-Match header string true "ETag of the version being changed, or *"
+// @Param id path int true "actor id"
+// @Param input body communication.ReplaceActorRequest true "actor"
+// @Success 200 {string} string "Actor replaced"
+// @Failure 400 {string} string variables.StatusBadRequestError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 404 {string} string variables.ErrNotFound
+// @Failure 409 {string} string variables.ActorNotEditedError
+// @Failure 422 {string} string variables.ErrReferenceNotFound
+// @Failure 412 {object} models.ActorItem "current version"
+// @Failure 428 {string} string variables.IfMatchRequiredError
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /api/v1/actors/{id} [put]
+func (api *API) ReplaceActor(w http.ResponseWriter, r *http.Request) {
+	var replaceActorRequest communication.ReplaceActorRequest
+
+	id, ok := api.pathId(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := api.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	err := util.GetRequestBody(w, r, &replaceActorRequest, api.logger)
+	if err != nil {
+		return
+	}
+
+	films := replaceActorRequest.Films
+	if films == nil {
+		films = []int64{}
+	}
+	updated, err := api.core.EditActor(r.Context(), id, version, models.ActorPatch{
+		Name:      &replaceActorRequest.Name,
+		Gender:    &replaceActorRequest.Gender,
+		BirthDate: &replaceActorRequest.BirthDate,
+		Films:     &films,
+	})
+	if err != nil {
+		api.sendActorEditError(w, r, id, err, http.StatusConflict, variables.ActorNotEditedError)
+		return
+	}
+	setETag(w, updated)
+	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Delete-Actor
+// @Tags films
+// @Security ApiKeyAuth
+// @Description Move an actor to the trash
+// @ID delete-actor
+// @Accept json
+// @Produce json
+// @Param If-Match header string true "ETag of the version being changed, or *"
+// @Param id path int true "actor id"
+// @Success 200 {string} string "Actor removed"
+// @Failure 400 {string} string variables.StatusBadRequestError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 404 {string} string variables.ErrNotFound
+// @Failure 409 {string} string variables.ActorNotDeletedError
+// @Failure 412 {object} models.ActorItem "current version"
+// @Failure 428 {string} string variables.IfMatchRequiredError
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /api/v1/actors/{id} [delete]
+func (api *API) DeleteActor(w http.ResponseWriter, r *http.Request) {
+	id, ok := api.pathId(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := api.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	err := api.core.DeleteActor(r.Context(), id, version)
+	if err != nil {
+		api.sendActorEditError(w, r, id, err, http.StatusConflict, variables.ActorNotDeletedError)
 		return
 	}
 	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
@@ -256,29 +729,39 @@ func (api *API) EditInfoAboutActor(w http.ResponseWriter, r *http.Request) {
 // @Summary Remove-Actor
 // @Tags films
 // @Security ApiKeyAuth
-// @Description Remove actors information
+// @Description Remove actors information. Use DELETE /api/v1/actors/{id} instead
 // @ID remove-actor
 // @Accept json
 // @Produce json
+// @Param If-Match header string true "ETag of the version being changed, or *"
 // @Header 200 {integer} 1
 // @Success 200 {string} string "Actor removed"
 // @Failure 401 {string} string variables.StatusUnauthorizedError
 // @Failure 400 {string} string variables.StatusBadRequestError
 // @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 404 {string} string variables.ErrNotFound
 // @Failure 409 {string} string variables.ActorNotDeletedError
+// @Failure 412 {object} models.ActorItem "current version"
+// @Failure 428 {string} string variables.IfMatchRequiredError
 // @Failure 500 {string} string variables.StatusInternalServerError
+// @Deprecated
 // @Router /api/v1/actors/remove [post]
 func (api *API) RemoveInfoAboutActor(w http.ResponseWriter, r *http.Request) {
 	var deleteActorRequest communication.DeleteActorRequest
 
+	version, ok := api.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
 	err := util.GetRequestBody(w, r, &deleteActorRequest, api.logger)
 	if err != nil {
 		return
 	}
 
-	err = api.core.DeleteActor(deleteActorRequest.Id)
+	err = api.core.DeleteActor(r.Context(), deleteActorRequest.Id, version)
 	if err != nil {
-		util.SendResponse(w, r, http.StatusConflict, nil, variables.ActorNotDeletedError, err, api.logger)
+		api.sendActorEditError(w, r, deleteActorRequest.Id, err, http.StatusConflict, variables.ActorNotDeletedError)
 		return
 	}
 	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
@@ -291,14 +774,16 @@ func (api *API) RemoveInfoAboutActor(w http.ResponseWriter, r *http.Request) {
 // @ID add-new-film
 // @Accept json
 // @Produce json
-// @Header 200 {integer} 1
-// @Success 200 {string} string "Film added"
+// @Param input body communication.AddFilmRequest true "film"
+// @Success 201 {string} string "Film added"
+// @Header 201 {string} Location "path of the new film"
 // @Failure 401 {string} string variables.StatusUnauthorizedError
 // @Failure 400 {string} string variables.StatusBadRequestError
 // @Failure 403 {string} string variables.StatusForbiddenError
 // @Failure 409 {string} string variables.FilmNotAddedError
+// @Failure 422 {string} string variables.ErrReferenceNotFound
 // @Failure 500 {string} string variables.StatusInternalServerError
-// @Router /api/v1/films/add [post]
+// @Router /api/v1/films [post]
 func (api *API) AddFilm(w http.ResponseWriter, r *http.Request) {
 	var addFilmRequest communication.AddFilmRequest
 
@@ -307,40 +792,241 @@ func (api *API) AddFilm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = api.core.AddFilm(addFilmRequest.Title, addFilmRequest.Description, addFilmRequest.Rating, addFilmRequest.ReleaseDate, addFilmRequest.Crew)
+	id, err := api.core.AddFilm(r.Context(), addFilmRequest.Title, addFilmRequest.Description, addFilmRequest.Rating, addFilmRequest.ReleaseDate, addFilmRequest.Crew, addFilmRequest.FilmMetadata)
 	if err != nil {
-		util.SendResponse(w, r, http.StatusConflict, nil, variables.FilmNotAddedError, err, api.logger)
+		api.sendCoreError(w, r, err, http.StatusConflict, variables.FilmNotAddedError)
 		return
 	}
-	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+	w.Header().Set(variables.LocationHeader, resourceLocation(variables.FilmsResourcePath, id))
+	util.SendResponse(w, r, http.StatusCreated, nil, variables.StatusOkMessage, nil, api.logger)
 }
 
 // @Summary Edit-Film
 // @Tags films
 // @Security ApiKeyAuth
-// @Description Add new film
+// @Description Edit film information, fields left out stay unchanged. Use PATCH /api/v1/films/{id} instead
 // @ID edit-new-film
 // @Accept json
 // @Produce json
+// @Param If-Match header string true "ETag of the version being changed, or *"
 // @Header 200 {integer} 1
+// @Param input body communication.EditFilmRequest true "film id and changed fields"
 // @Success 200 {string} string "Film edited"
 // @Failure 401 {string} string variables.StatusUnauthorizedError
 // @Failure 400 {string} string variables.StatusBadRequestError
 // @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 404 {string} string variables.ErrNotFound
 // @Failure 409 {string} string variables.FilmNotEditedError
+// @Failure 422 {string} string variables.ErrReferenceNotFound
+// @Failure 412 {object} models.FilmItem "current version"
+// @Failure 428 {string} string variables.IfMatchRequiredError
 // @Failure 500 {string} string variables.StatusInternalServerError
+// @Deprecated
 // @Router /api/v1/films/edit [post]
 func (api *API) EditFilm(w http.ResponseWriter, r *http.Request) {
 	var editFilmRequest communication.EditFilmRequest
 
+	version, ok := api.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
 	err := util.GetRequestBody(w, r, &editFilmRequest, api.logger)
 	if err != nil {
 		return
 	}
 
-	err = api.core.EditFilm(editFilmRequest.Id, editFilmRequest.Title, editFilmRequest.Description, editFilmRequest.Rating, editFilmRequest.ReleaseDate, editFilmRequest.Crew)
+	updated, err := api.core.EditFilm(r.Context(), editFilmRequest.Id, version, editFilmRequest.FilmPatch)
+	if err != nil {
+		api.sendFilmEditError(w, r, editFilmRequest.Id, err, http.StatusConflict, variables.FilmNotEditedError)
+		return
+	}
+	setETag(w, updated)
+	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Film
+// @Tags films
+// @Security ApiKeyAuth
+// @Description Get a film with its crew, the version is returned as ETag
+// @ID get-film
+// @Accept json
+// @Produce json
+// @Param id path int true "film id"
+// @Success 200 {object} models.FilmItem
+// @Header 200 {string} ETag "film version"
+// @Failure 400 {string} string variables.PathIdError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 404 {string} string variables.ErrNotFound
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /api/v1/films/{id} [get]
+func (api *API) GetFilm(w http.ResponseWriter, r *http.Request) {
+	id, ok := api.pathId(w, r)
+	if !ok {
+		return
+	}
+
+	film, err := api.core.GetFilm(r.Context(), id)
+	if err != nil {
+		api.sendCoreError(w, r, err, http.StatusInternalServerError, variables.FilmNotFoundError)
+		return
+	}
+	setETag(w, film.Version)
+	util.SendResponse(w, r, http.StatusOK, film, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Patch-Film
+// @Tags films
+// @Security ApiKeyAuth
+// @Description Partially update a film, fields left out or null stay unchanged and the crew changes only when given
+// @ID patch-film
+// @Accept json
+// @Produce json
+// @Param If-Match header string true "ETag of the version being changed, or *"
+// @Param id path int true "film id"
+// @Param input body models.FilmPatch true "changed fields"
+// @Success 200 {string} string "Film edited"
+// @Failure 400 {string} string variables.StatusBadRequestError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 404 {string} string variables.ErrNotFound
+// @Failure 409 {string} string variables.FilmNotEditedError
+// @Failure 422 {string} string variables.ErrReferenceNotFound
+// @Failure 412 {object} models.FilmItem "current version"
+// @Failure 428 {string} string variables.IfMatchRequiredError
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /api/v1/films/{id} [patch]
+func (api *API) PatchFilm(w http.ResponseWriter, r *http.Request) {
+	var filmPatch models.FilmPatch
+
+	id, ok := api.pathId(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := api.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	err := util.GetRequestBody(w, r, &filmPatch, api.logger)
+	if err != nil {
+		return
+	}
+
+	updated, err := api.core.EditFilm(r.Context(), id, version, filmPatch)
+	if err != nil {
+		api.sendFilmEditError(w, r, id, err, http.StatusConflict, variables.FilmNotEditedError)
+		return
+	}
+	setETag(w, updated)
+	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Replace-Film
+// @Tags films
+// @Security ApiKeyAuth
+// @Description Replace a film, fields left out are cleared
+// @ID replace-film
+// @Accept json
+// @Produce json
+// @Param If-Match header string true "ETag of the version being changed, or *"
+// @Param id path int true "film id"
+// @Param input body communication.ReplaceFilmRequest true "film"
+// @Success 200 {string} string "Film replaced"
+// @Failure 400 {string} string variables.StatusBadRequestError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 404 {string} string variables.ErrNotFound
+// @Failure 409 {string} string variables.FilmNotEditedError
+// @Failure 422 {string} string variables.ErrReferenceNotFound
+// @Failure 412 {object} models.FilmItem "current version"
+// @Failure 428 {string} string variables.IfMatchRequiredError
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /api/v1/films/{id} [put]
+func (api *API) ReplaceFilm(w http.ResponseWriter, r *http.Request) {
+	var replaceFilmRequest communication.ReplaceFilmRequest
+
+	id, ok := api.pathId(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := api.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	err := util.GetRequestBody(w, r, &replaceFilmRequest, api.logger)
+	if err != nil {
+		return
+	}
+
+	crew := replaceFilmRequest.Crew
+	if crew == nil {
+		crew = []models.CrewMember{}
+	}
+	metadata := replaceFilmRequest.FilmMetadata
+	for _, labels := range []*[]string{&metadata.Genres, &metadata.Tags, &metadata.Countries} {
+		if *labels == nil {
+			*labels = []string{}
+		}
+	}
+	updated, err := api.core.EditFilm(r.Context(), id, version, models.FilmPatch{
+		Title:       &replaceFilmRequest.Title,
+		Description: &replaceFilmRequest.Description,
+		Rating:      &replaceFilmRequest.Rating,
+		ReleaseDate: &replaceFilmRequest.ReleaseDate,
+		Crew:        &crew,
+
+		Genres:           &metadata.Genres,
+		Tags:             &metadata.Tags,
+		Countries:        &metadata.Countries,
+		Runtime:          &metadata.Runtime,
+		AgeRating:        &metadata.AgeRating,
+		OriginalLanguage: &metadata.OriginalLanguage,
+	})
 	if err != nil {
-		util.SendResponse(w, r, http.StatusConflict, nil, variables.FilmNotEditedError, err, api.logger)
+		api.sendFilmEditError(w, r, id, err, http.StatusConflict, variables.FilmNotEditedError)
+		return
+	}
+	setETag(w, updated)
+	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Delete-Film
+// @Tags films
+// @Security ApiKeyAuth
+// @Description Move a film to the trash
+// @ID delete-film
+// @Accept json
+// @Produce json
+// @Param If-Match header string true "ETag of the version being changed, or *"
+// @Param id path int true "film id"
+// @Success 200 {string} string "Film removed"
+// @Failure 400 {string} string variables.StatusBadRequestError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 404 {string} string variables.ErrNotFound
+// @Failure 409 {string} string variables.FilmNotDeletedError
+// @Failure 412 {object} models.FilmItem "current version"
+// @Failure 428 {string} string variables.IfMatchRequiredError
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /api/v1/films/{id} [delete]
+func (api *API) DeleteFilm(w http.ResponseWriter, r *http.Request) {
+	id, ok := api.pathId(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := api.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	err := api.core.DeleteFilm(r.Context(), id, version)
+	if err != nil {
+		api.sendFilmEditError(w, r, id, err, http.StatusConflict, variables.FilmNotDeletedError)
 		return
 	}
 	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
@@ -349,30 +1035,366 @@ func (api *API) EditFilm(w http.ResponseWriter, r *http.Request) {
 // @Summary Remove-Film
 // @Tags films
 // @Security ApiKeyAuth
-// @Description Remove films information
+// @Description Remove films information. Use DELETE /api/v1/films/{id} instead
 // @ID remove-film
 // @Accept json
 // @Produce json
+// @Param If-Match header string true "ETag of the version being changed, or *"
 // @Header 200 {integer} 1
 // @Params input body communication.DeleteFilmRequest true "Delete Film by Id"
 // @Success 200 {string} string "Film removed"
 // @Failure 401 {string} string variables.StatusUnauthorizedError
 // @Failure 400 {string} string variables.StatusBadRequestError
 // @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 404 {string} string variables.ErrNotFound
 // @Failure 409 {string} string variables.ActorNotDeletedError
+// @Failure 412 {object} models.FilmItem "current version"
+// @Failure 428 {string} string variables.IfMatchRequiredError
 // @Failure 500 {string} string variables.StatusInternalServerError
+// @Deprecated
 // @Router /api/v1/films/remove [post]
 func (api *API) RemoveFilm(w http.ResponseWriter, r *http.Request) {
 	var deleteFilmRequest communication.DeleteFilmRequest
 
+	version, ok := api.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
 	err := util.GetRequestBody(w, r, &deleteFilmRequest, api.logger)
 	if err != nil {
 		return
 	}
 
-	err = api.core.DeleteFilm(deleteFilmRequest.Id)
+	err = api.core.DeleteFilm(r.Context(), deleteFilmRequest.Id, version)
+	if err != nil {
+		api.sendFilmEditError(w, r, deleteFilmRequest.Id, err, http.StatusConflict, variables.FilmNotDeletedError)
+		return
+	}
+	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Film-Revisions
+// @Tags films
+// @Security ApiKeyAuth
+// @Description Get earlier versions of a film, newest first
+// @ID film-revisions
+// @Accept json
+// @Produce json
+// @Param id path int true "film id"
+// @Param page query int false "page number"
+// @Param page_size query int false "page size"
+// @Success 200 {object} communication.FilmRevisionsResponse
+// @Failure 400 {string} string variables.PathIdError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 404 {string} string variables.ErrNotFound
+// @Failure 500 {string} string variables.FilmRevisionsError
+// @Router /api/v1/films/{id}/revisions [get]
+func (api *API) GetFilmRevisions(w http.ResponseWriter, r *http.Request) {
+	id, ok := api.pathId(w, r)
+	if !ok {
+		return
+	}
+	size, page := util.Pagination(r, api.config.Current().Pagination)
+
+	revisions, err := api.core.GetFilmRevisions(r.Context(), id, uint64((page-1)*size), size)
+	if err != nil {
+		api.sendCoreError(w, r, err, http.StatusInternalServerError, variables.FilmRevisionsError)
+		return
+	}
+	util.SendResponse(w, r, http.StatusOK, revisions, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Revert-Film
+// @Tags films
+// @Security ApiKeyAuth
+// @Description Restore a film to one of its revisions, the revert is saved as a new revision
+// @ID revert-film
+// @Accept json
+// @Produce json
+// @Param If-Match header string true "ETag of the version being changed, or *"
+// @Param id path int true "film id"
+// @Param input body communication.RevertFilmRequest true "revision to restore"
+// @Success 200 {string} string "Film reverted"
+// @Failure 400 {string} string variables.StatusBadRequestError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 404 {string} string variables.ErrNotFound
+// @Failure 409 {string} string variables.FilmRevertError
+// @Failure 422 {string} string variables.ErrReferenceNotFound
+// @Failure 412 {object} models.FilmItem "current version"
+// @Failure 428 {string} string variables.IfMatchRequiredError
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /api/v1/films/{id}/revert [post]
+func (api *API) RevertFilm(w http.ResponseWriter, r *http.Request) {
+	var revertFilmRequest communication.RevertFilmRequest
+
+	id, ok := api.pathId(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := api.ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	err := util.GetRequestBody(w, r, &revertFilmRequest, api.logger)
+	if err != nil {
+		return
+	}
+
+	updated, err := api.core.RevertFilm(r.Context(), id, version, revertFilmRequest.Revision)
+	if err != nil {
+		api.sendFilmEditError(w, r, id, err, http.StatusConflict, variables.FilmRevertError)
+		return
+	}
+	setETag(w, updated)
+	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Trash
+// @Tags trash
+// @Security ApiKeyAuth
+// @Description Get deleted films and actors, newest first
+// @ID trash-list
+// @Accept json
+// @Produce json
+// @Param page query int false "page number"
+// @Param page_size query int false "page size"
+// @Success 200 {object} communication.TrashListResponse
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 500 {string} string variables.TrashListError
+// @Router /api/v1/trash [get]
+func (api *API) GetTrash(w http.ResponseWriter, r *http.Request) {
+	size, page := util.Pagination(r, api.config.Current().Pagination)
+
+	trash, err := api.core.GetTrash(r.Context(), uint64((page-1)*size), size)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.TrashListError, err, api.logger)
+		return
+	}
+	util.SendResponse(w, r, http.StatusOK, trash, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Restore-From-Trash
+// @Tags trash
+// @Security ApiKeyAuth
+// @Description Restore a deleted film or actor
+// @ID trash-restore
+// @Accept json
+// @Produce json
+// @Param input body communication.TrashItemRequest true "item type (film or actor) and id"
+// @Success 200 {string} string "Item restored"
+// @Failure 400 {string} string variables.TrashItemTypeError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 404 {string} string variables.ErrNotFound
+// @Failure 500 {string} string variables.TrashRestoreError
+// @Router /api/v1/trash/restore [post]
+func (api *API) RestoreFromTrash(w http.ResponseWriter, r *http.Request) {
+	var trashItemRequest communication.TrashItemRequest
+
+	err := util.GetRequestBody(w, r, &trashItemRequest, api.logger)
+	if err != nil {
+		return
+	}
+
+	err = api.core.RestoreFromTrash(r.Context(), trashItemRequest.Type, trashItemRequest.Id)
+	if err != nil {
+		api.sendCoreError(w, r, err, http.StatusInternalServerError, variables.TrashRestoreError)
+		return
+	}
+	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Purge-From-Trash
+// @Tags trash
+// @Security ApiKeyAuth
+// @Description Permanently delete a film or actor from the trash
+// @ID trash-purge
+// @Accept json
+// @Produce json
+// @Param input body communication.TrashItemRequest true "item type (film or actor) and id"
+// @Success 200 {string} string "Item purged"
+// @Failure 400 {string} string variables.TrashItemTypeError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 404 {string} string variables.ErrNotFound
+// @Failure 500 {string} string variables.TrashPurgeError
+// @Router /api/v1/trash/purge [post]
+func (api *API) PurgeFromTrash(w http.ResponseWriter, r *http.Request) {
+	var trashItemRequest communication.TrashItemRequest
+
+	err := util.GetRequestBody(w, r, &trashItemRequest, api.logger)
+	if err != nil {
+		return
+	}
+
+	err = api.core.PurgeFromTrash(r.Context(), trashItemRequest.Type, trashItemRequest.Id)
+	if err != nil {
+		api.sendCoreError(w, r, err, http.StatusInternalServerError, variables.TrashPurgeError)
+		return
+	}
+	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Audit
+// @Tags audit
+// @Security ApiKeyAuth
+// @Description Get catalogue changes, newest first
+// @ID films-audit-list
+// @Accept json
+// @Produce json
+// @Param actor_id query int false "user who made the change"
+// @Param action query string false "create, update, delete, restore or purge"
+// @Param entity query string false "film or actor"
+// @Param entity_id query int false "changed film or actor id"
+// @Param request_id query string false "request id of the change"
+// @Param from query string false "RFC 3339 time, inclusive"
+// @Param to query string false "RFC 3339 time, exclusive"
+// @Param page query int false "page number"
+// @Param page_size query int false "page size"
+// @Success 200 {object} communication.AuditListResponse
+// @Failure 400 {string} string variables.AuditFilterError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 500 {string} string variables.AuditListError
+// @Router /api/v1/audit [get]
+func (api *API) GetAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := audit.ParseFilter(r)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.AuditFilterError, err, api.logger)
+		return
+	}
+
+	size, page := util.Pagination(r, api.config.Current().Pagination)
+
+	entries, err := api.core.GetAudit(r.Context(), filter, uint64((page-1)*size), size)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.AuditListError, err, api.logger)
+		return
+	}
+	util.SendResponse(w, r, http.StatusOK, entries, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Genres
+// @Tags films
+// @Security ApiKeyAuth
+// @Description Get the genre dictionary
+// @ID genres-list
+// @Accept json
+// @Produce json
+// @Success 200 {object} communication.GenresListResponse
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /api/v1/genres [get]
+func (api *API) GetGenres(w http.ResponseWriter, r *http.Request) {
+	genres, err := api.core.GetGenres(r.Context())
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.GenresListError, err, api.logger)
+		return
+	}
+	util.SendResponse(w, r, http.StatusOK, genres, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Add-Genre
+// @Tags films
+// @Security ApiKeyAuth
+// @Description Add a genre to the dictionary
+// @ID add-genre
+// @Accept json
+// @Produce json
+// @Param input body communication.GenreRequest true "genre"
+// @Success 201 {string} string "Genre added"
+// @Header 201 {string} Location "path of the new genre"
+// @Failure 400 {string} string variables.StatusBadRequestError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 409 {string} string variables.ErrAlreadyExists
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /api/v1/genres [post]
+func (api *API) AddGenre(w http.ResponseWriter, r *http.Request) {
+	var genreRequest communication.GenreRequest
+
+	err := util.GetRequestBody(w, r, &genreRequest, api.logger)
+	if err != nil {
+		return
+	}
+
+	id, err := api.core.AddGenre(r.Context(), genreRequest.Slug, genreRequest.Name)
+	if err != nil {
+		api.sendCoreError(w, r, err, http.StatusBadRequest, variables.GenreNotAddedError)
+		return
+	}
+	w.Header().Set(variables.LocationHeader, resourceLocation(variables.GenresResourcePath, id))
+	util.SendResponse(w, r, http.StatusCreated, nil, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Edit-Genre
+// @Tags films
+// @Security ApiKeyAuth
+// @Description Change the slug or name of a genre, films keep it
+// @ID edit-genre
+// @Accept json
+// @Produce json
+// @Param id path int true "genre id"
+// @Param input body communication.GenreRequest true "genre"
+// @Success 200 {string} string "Genre edited"
+// @Failure 400 {string} string variables.StatusBadRequestError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 404 {string} string variables.ErrNotFound
+// @Failure 409 {string} string variables.ErrAlreadyExists
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /api/v1/genres/{id} [put]
+func (api *API) EditGenre(w http.ResponseWriter, r *http.Request) {
+	var genreRequest communication.GenreRequest
+
+	id, ok := api.pathId(w, r)
+	if !ok {
+		return
+	}
+
+	err := util.GetRequestBody(w, r, &genreRequest, api.logger)
+	if err != nil {
+		return
+	}
+
+	err = api.core.EditGenre(r.Context(), id, genreRequest.Slug, genreRequest.Name)
+	if err != nil {
+		api.sendCoreError(w, r, err, http.StatusBadRequest, variables.GenreNotEditedError)
+		return
+	}
+	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Delete-Genre
+// @Tags films
+// @Security ApiKeyAuth
+// @Description Remove a genre no film is linked to
+// @ID delete-genre
+// @Accept json
+// @Produce json
+// @Param id path int true "genre id"
+// @Success 200 {string} string "Genre removed"
+// @Failure 400 {string} string variables.PathIdError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.StatusForbiddenError
+// @Failure 404 {string} string variables.ErrNotFound
+// @Failure 409 {string} string variables.ErrStillReferenced
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /api/v1/genres/{id} [delete]
+func (api *API) DeleteGenre(w http.ResponseWriter, r *http.Request) {
+	id, ok := api.pathId(w, r)
+	if !ok {
+		return
+	}
+
+	err := api.core.DeleteGenre(r.Context(), id)
 	if err != nil {
-		util.SendResponse(w, r, http.StatusConflict, nil, variables.FilmNotDeletedError, err, api.logger)
+		api.sendCoreError(w, r, err, http.StatusInternalServerError, variables.GenreNotDeletedError)
 		return
 	}
 	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
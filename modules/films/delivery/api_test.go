@@ -0,0 +1,140 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"filmoteka/configs"
+	"filmoteka/pkg/logging"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/util"
+	"filmoteka/pkg/variables"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeCore answers every edit with err, any call it does not serve panics
+// on the nil embedded interface
+type fakeCore struct {
+	ICore
+	err   error
+	film  models.FilmItem
+	actor models.ActorItem
+	edits int
+}
+
+func (core *fakeCore) GetUserId(ctx context.Context, sid string) (int64, error) {
+	if sid != "admin-session" {
+		return 0, variables.ErrNotFound
+	}
+	return 1, nil
+}
+
+func (core *fakeCore) GetUserRole(ctx context.Context, id int64) (string, error) {
+	return variables.AdminRole, nil
+}
+
+func (core *fakeCore) GetFilm(ctx context.Context, id int64) (models.FilmItem, error) {
+	return core.film, nil
+}
+
+func (core *fakeCore) GetActor(ctx context.Context, id int64) (models.ActorItem, error) {
+	return core.actor, nil
+}
+
+func (core *fakeCore) EditFilm(ctx context.Context, id int64, version int64, patch models.FilmPatch) (int64, error) {
+	core.edits++
+	return version + 1, core.err
+}
+
+func (core *fakeCore) EditActor(ctx context.Context, id int64, version int64, patch models.ActorPatch) (int64, error) {
+	core.edits++
+	return version + 1, core.err
+}
+
+func (core *fakeCore) DeleteActor(ctx context.Context, id int64, version int64) error {
+	core.edits++
+	return core.err
+}
+
+func (core *fakeCore) RestoreFromTrash(ctx context.Context, itemType string, id int64) error {
+	return core.err
+}
+
+func getTestApi(core ICore) *API {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	config := configs.GetReloader(nil, &variables.FilmsConfig{}, logger)
+	return GetFilmsApi(core, nil, logging.GetLevels(slog.LevelInfo, nil), config, logger)
+}
+
+// serve sends an authorized request, headers come in name, value pairs
+func serve(api *API, method string, target string, body string, headers ...string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	request.AddCookie(&http.Cookie{Name: variables.SessionCookieName, Value: "admin-session"})
+	for i := 0; i+1 < len(headers); i += 2 {
+		request.Header.Set(headers[i], headers[i+1])
+	}
+
+	recorder := httptest.NewRecorder()
+	api.server.Handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestPatchChecksPathIdFirst(t *testing.T) {
+	for _, target := range []string{"/api/v1/actors/first", "/api/v1/films/first", "/api/v1/films/0/revert"} {
+		t.Run(target, func(t *testing.T) {
+			core := &fakeCore{}
+			method := http.MethodPatch
+			if strings.HasSuffix(target, "revert") {
+				method = http.MethodPost
+			}
+
+			recorder := serve(getTestApi(core), method, target, `not json`)
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d for a bad id before a missing If-Match", recorder.Code, http.StatusBadRequest)
+			}
+			if core.edits != 0 {
+				t.Error("a request with a bad id reached the core")
+			}
+		})
+	}
+}
+
+func TestCoreErrorStatuses(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"failed input check", util.InvalidInputError(variables.RatingSizeError), http.StatusBadRequest},
+		{"wrapped failed input check", errors.Join(errors.New("context"), util.InvalidInputError(variables.CrewRoleError)), http.StatusBadRequest},
+		{"missing record", variables.ErrNotFound, http.StatusNotFound},
+		{"missing reference", variables.ErrReferenceNotFound, http.StatusUnprocessableEntity},
+		{"violated constraint", variables.ErrConstraintViolated, http.StatusBadRequest},
+		{"other failure", errors.New("edit failed"), http.StatusConflict},
+	}
+	for _, test := range tests {
+		for _, target := range []string{"/api/v1/actors/1", "/api/v1/films/1"} {
+			t.Run(test.name+" "+target, func(t *testing.T) {
+				api := getTestApi(&fakeCore{err: test.err})
+
+				recorder := serve(api, http.MethodPatch, target, `{}`, variables.IfMatchHeader, `"1"`)
+				if recorder.Code != test.status {
+					t.Errorf("status = %d, want %d", recorder.Code, test.status)
+				}
+			})
+		}
+	}
+}
+
+func TestRestoreUnknownTrashTypeIsBadRequest(t *testing.T) {
+	api := getTestApi(&fakeCore{err: util.InvalidInputError(variables.TrashItemTypeError)})
+
+	recorder := serve(api, http.MethodPost, "/api/v1/trash/restore", `{"type":"genre","id":1}`)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+}
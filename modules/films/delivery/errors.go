@@ -0,0 +1,28 @@
+package delivery
+
+import (
+	"errors"
+	"filmoteka/pkg/util"
+	"filmoteka/pkg/variables"
+	"net/http"
+)
+
+// sendCoreError answers repository errors and failed input checks with
+// their own status and falls back to the handler's status for everything else.
+func (api *API) sendCoreError(w http.ResponseWriter, r *http.Request, err error, status int, message string) {
+	switch {
+	case errors.Is(err, variables.ErrInvalidInput):
+		status, message = http.StatusBadRequest, err.Error()
+	case errors.Is(err, variables.ErrNotFound):
+		status, message = http.StatusNotFound, variables.ErrNotFound.Error()
+	case errors.Is(err, variables.ErrAlreadyExists):
+		status, message = http.StatusConflict, variables.ErrAlreadyExists.Error()
+	case errors.Is(err, variables.ErrStillReferenced):
+		status, message = http.StatusConflict, variables.ErrStillReferenced.Error()
+	case errors.Is(err, variables.ErrReferenceNotFound):
+		status, message = http.StatusUnprocessableEntity, variables.ErrReferenceNotFound.Error()
+	case errors.Is(err, variables.ErrConstraintViolated):
+		status, message = http.StatusBadRequest, variables.ErrConstraintViolated.Error()
+	}
+	util.SendResponse(w, r, status, nil, message, err, api.logger)
+}
@@ -0,0 +1,72 @@
+package delivery
+
+import (
+	"errors"
+	"filmoteka/pkg/util"
+	"filmoteka/pkg/variables"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set(variables.ETagHeader, strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// ifMatchVersion reads the version an edit is conditioned on. The header
+// is required, "*" matches any version and is returned as zero. On failure
+// the response is already sent.
+func (api *API) ifMatchVersion(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	ifMatch := strings.TrimSpace(r.Header.Get(variables.IfMatchHeader))
+	if ifMatch == "" {
+		util.SendResponse(w, r, http.StatusPreconditionRequired, nil, variables.IfMatchRequiredError, nil, api.logger)
+		return 0, false
+	}
+	if ifMatch == variables.IfMatchAny {
+		return 0, true
+	}
+
+	// Weak tags never match, If-Match uses the strong comparison
+	var version int64
+	unquoted, err := strconv.Unquote(ifMatch)
+	if err == nil {
+		version, err = strconv.ParseInt(unquoted, 10, 64)
+	}
+	if err != nil || version <= 0 {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.IfMatchError, err, api.logger)
+		return 0, false
+	}
+	return version, true
+}
+
+// sendFilmEditError answers a lost edit race with 412 and the current
+// film, other errors as sendCoreError does.
+func (api *API) sendFilmEditError(w http.ResponseWriter, r *http.Request, id int64, err error, status int, message string) {
+	if !errors.Is(err, variables.ErrVersionMismatch) {
+		api.sendCoreError(w, r, err, status, message)
+		return
+	}
+
+	film, err := api.core.GetFilm(r.Context(), id)
+	if err != nil {
+		api.sendCoreError(w, r, err, http.StatusInternalServerError, message)
+		return
+	}
+	setETag(w, film.Version)
+	util.SendResponse(w, r, http.StatusPreconditionFailed, film, variables.ErrVersionMismatch.Error(), nil, api.logger)
+}
+
+func (api *API) sendActorEditError(w http.ResponseWriter, r *http.Request, id int64, err error, status int, message string) {
+	if !errors.Is(err, variables.ErrVersionMismatch) {
+		api.sendCoreError(w, r, err, status, message)
+		return
+	}
+
+	actor, err := api.core.GetActor(r.Context(), id)
+	if err != nil {
+		api.sendCoreError(w, r, err, http.StatusInternalServerError, message)
+		return
+	}
+	setETag(w, actor.Version)
+	util.SendResponse(w, r, http.StatusPreconditionFailed, actor, variables.ErrVersionMismatch.Error(), nil, api.logger)
+}
@@ -0,0 +1,76 @@
+package delivery
+
+import (
+	"encoding/json"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/variables"
+	"net/http"
+	"testing"
+)
+
+func TestLostEditReturnsCurrentRepresentation(t *testing.T) {
+	core := &fakeCore{
+		err:   variables.ErrVersionMismatch,
+		film:  models.FilmItem{Id: 1, Title: "Solaris", Version: 7},
+		actor: models.ActorItem{Id: 1, Name: "Natalya Bondarchuk", Version: 4},
+	}
+	api := getTestApi(core)
+
+	recorder := serve(api, http.MethodPatch, "/api/v1/films/1", `{"rating":8}`, variables.IfMatchHeader, `"6"`)
+	if recorder.Code != http.StatusPreconditionFailed {
+		t.Fatalf("film status = %d, want %d", recorder.Code, http.StatusPreconditionFailed)
+	}
+	if etag := recorder.Header().Get(variables.ETagHeader); etag != `"7"` {
+		t.Errorf("film ETag = %s, want \"7\"", etag)
+	}
+	var film models.FilmItem
+	err := json.Unmarshal(recorder.Body.Bytes(), &film)
+	if err != nil || film.Title != "Solaris" || film.Version != 7 {
+		t.Errorf("film body = %s, want the current film", recorder.Body)
+	}
+
+	recorder = serve(api, http.MethodDelete, "/api/v1/actors/1", ``, variables.IfMatchHeader, `"3"`)
+	if recorder.Code != http.StatusPreconditionFailed {
+		t.Fatalf("actor status = %d, want %d", recorder.Code, http.StatusPreconditionFailed)
+	}
+	if etag := recorder.Header().Get(variables.ETagHeader); etag != `"4"` {
+		t.Errorf("actor ETag = %s, want \"4\"", etag)
+	}
+	var actor models.ActorItem
+	err = json.Unmarshal(recorder.Body.Bytes(), &actor)
+	if err != nil || actor.Name != "Natalya Bondarchuk" || actor.Version != 4 {
+		t.Errorf("actor body = %s, want the current actor", recorder.Body)
+	}
+}
+
+func TestIfMatchHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch []string
+		status  int
+		version string
+	}{
+		{"missing", nil, http.StatusPreconditionRequired, ""},
+		{"any version", []string{variables.IfMatchHeader, variables.IfMatchAny}, http.StatusOK, `"1"`},
+		{"exact version", []string{variables.IfMatchHeader, `"6"`}, http.StatusOK, `"7"`},
+		{"weak tag", []string{variables.IfMatchHeader, `W/"6"`}, http.StatusBadRequest, ""},
+		{"unquoted", []string{variables.IfMatchHeader, `6`}, http.StatusBadRequest, ""},
+		{"zero version", []string{variables.IfMatchHeader, `"0"`}, http.StatusBadRequest, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			core := &fakeCore{}
+
+			recorder := serve(getTestApi(core), http.MethodPatch, "/api/v1/films/1", `{"rating":8}`, test.ifMatch...)
+			if recorder.Code != test.status {
+				t.Errorf("status = %d, want %d", recorder.Code, test.status)
+			}
+			if etag := recorder.Header().Get(variables.ETagHeader); etag != test.version {
+				t.Errorf("ETag = %q, want %q", etag, test.version)
+			}
+			if test.status != http.StatusOK && core.edits != 0 {
+				t.Error("an edit without a usable If-Match reached the core")
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package delivery
+
+import (
+	"errors"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/variables"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseFilmFilter reads the films list filter from the query string
+func parseFilmFilter(r *http.Request) (models.FilmFilter, error) {
+	query := r.URL.Query()
+	filter := models.FilmFilter{
+		TitlePrefix: strings.TrimSpace(query.Get(variables.FilmTitlePrefixParam)),
+		Genre:       strings.ToLower(query.Get(variables.FilmGenreParam)),
+		Tag:         strings.ToLower(query.Get(variables.FilmTagParam)),
+		Country:     strings.ToUpper(query.Get(variables.FilmCountryParam)),
+		AgeRating:   query.Get(variables.FilmAgeRatingParam),
+		Language:    strings.ToLower(query.Get(variables.FilmLanguageParam)),
+	}
+
+	var errs []error
+	parseRuntime := func(param string) int {
+		value := query.Get(param)
+		if value == "" {
+			return 0
+		}
+		runtime, err := strconv.Atoi(value)
+		if err != nil || runtime <= 0 {
+			errs = append(errs, fmt.Errorf("%s: %q", param, value))
+		}
+		return runtime
+	}
+
+	parseRating := func(param string) *float64 {
+		value := query.Get(param)
+		if value == "" {
+			return nil
+		}
+		rating, err := strconv.ParseFloat(value, 64)
+		if err != nil || rating < variables.FilmRatingBegin || rating > variables.FilmRatingEnd {
+			errs = append(errs, fmt.Errorf("%s: %q", param, value))
+			return nil
+		}
+		return &rating
+	}
+
+	parseDate := func(param string) string {
+		value := query.Get(param)
+		if value == "" {
+			return ""
+		}
+		_, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %q", param, value))
+		}
+		return value
+	}
+
+	filter.MinRuntime = parseRuntime(variables.FilmMinRuntimeParam)
+	filter.MaxRuntime = parseRuntime(variables.FilmMaxRuntimeParam)
+	filter.MinRating = parseRating(variables.FilmMinRatingParam)
+	filter.MaxRating = parseRating(variables.FilmMaxRatingParam)
+	filter.ReleasedFrom = parseDate(variables.FilmReleasedFromParam)
+	filter.ReleasedTo = parseDate(variables.FilmReleasedToParam)
+
+	if crew := query.Get(variables.FilmCrewParam); crew != "" {
+		seen := map[int64]bool{}
+		for _, value := range strings.Split(crew, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil || id <= 0 {
+				errs = append(errs, fmt.Errorf("%s: %q", variables.FilmCrewParam, value))
+				continue
+			}
+			if !seen[id] {
+				seen[id] = true
+				filter.CrewIds = append(filter.CrewIds, id)
+			}
+		}
+		if len(filter.CrewIds) > variables.FilmsMaxCrewFilter {
+			errs = append(errs, fmt.Errorf("%s: more than %d ids", variables.FilmCrewParam, variables.FilmsMaxCrewFilter))
+		}
+	}
+
+	switch match := query.Get(variables.FilmCrewMatchParam); match {
+	case "", variables.CrewMatchAny:
+	case variables.CrewMatchAll:
+		filter.CrewMatchAll = true
+	default:
+		errs = append(errs, fmt.Errorf("%s: %q", variables.FilmCrewMatchParam, match))
+	}
+
+	return filter, errors.Join(errs...)
+}
+
+// legacySortKeys keeps the old sort_by values working
+var legacySortKeys = map[string][]models.SortKey{
+	"name":         {{Field: variables.FilmSortTitle}},
+	"rating":       {{Field: variables.FilmSortRating, Descending: true}},
+	"release_date": {{Field: variables.FilmSortReleaseDate}},
+}
+
+// parseFilmSort reads comma separated sort keys like -rating,title, a
+// leading dash sorts descending. Without them the legacy sort_by applies
+// and films go by rating.
+func parseFilmSort(r *http.Request) ([]models.SortKey, error) {
+	query := r.URL.Query()
+	value := query.Get(variables.FilmSortParam)
+	if value == "" {
+		if keys, found := legacySortKeys[query.Get(variables.FilmSortByParam)]; found {
+			return keys, nil
+		}
+		return []models.SortKey{{Field: variables.FilmSortRating, Descending: true}}, nil
+	}
+
+	var keys []models.SortKey
+	seen := map[string]bool{}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		key := models.SortKey{Field: strings.TrimPrefix(field, variables.FilmSortDescending)}
+		key.Descending = key.Field != field
+
+		switch key.Field {
+		case variables.FilmSortTitle, variables.FilmSortRating, variables.FilmSortReleaseDate, variables.FilmSortRuntime:
+		default:
+			return nil, fmt.Errorf("%s: %q", variables.FilmSortParam, field)
+		}
+		if seen[key.Field] {
+			return nil, fmt.Errorf("%s: %q repeated", variables.FilmSortParam, key.Field)
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
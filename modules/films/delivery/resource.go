@@ -0,0 +1,44 @@
+package delivery
+
+import (
+	"filmoteka/pkg/util"
+	"filmoteka/pkg/variables"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// resourceMethods are served on {id} routes, which would take the name of
+// an alias such as "add" for a bad id
+var resourceMethods = []string{http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// pathId parses the {id} segment of a resource route, answering 400 when it
+// is not a positive number.
+func (api *API) pathId(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(variables.PathIdParam), 10, 64)
+	if err != nil || id <= 0 {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.PathIdError, err, api.logger)
+		return 0, false
+	}
+	return id, true
+}
+
+func resourceLocation(prefix string, id int64) string {
+	return prefix + "/" + strconv.FormatInt(id, 10)
+}
+
+// handleAlias registers a deprecated POST alias. The other methods of its
+// path are answered with 405 here, as no {id} route should see the path.
+func (api *API) handleAlias(path string, handler http.Handler) {
+	api.mux.Handle(http.MethodPost+" "+path, handler)
+	for _, method := range resourceMethods {
+		api.mux.Handle(method+" "+path, api.methodNotAllowed(http.MethodPost))
+	}
+}
+
+func (api *API) methodNotAllowed(allowed ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(variables.AllowHeader, strings.Join(allowed, ", "))
+		util.SendResponse(w, r, http.StatusMethodNotAllowed, nil, variables.MethodNotAllowedError, nil, api.logger)
+	})
+}
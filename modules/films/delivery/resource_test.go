@@ -0,0 +1,37 @@
+package delivery
+
+import (
+	"filmoteka/pkg/variables"
+	"net/http"
+	"testing"
+)
+
+func TestAliasPathsOnlyServePost(t *testing.T) {
+	api := getTestApi(&fakeCore{})
+
+	for _, path := range []string{"/api/v1/actors/add", "/api/v1/actors/edit", "/api/v1/actors/remove", "/api/v1/films/add", "/api/v1/films/edit", "/api/v1/films/remove"} {
+		for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			recorder := serve(api, method, path, ``, variables.IfMatchHeader, variables.IfMatchAny)
+			if recorder.Code != http.StatusMethodNotAllowed {
+				t.Errorf("%s %s status = %d, want %d", method, path, recorder.Code, http.StatusMethodNotAllowed)
+			}
+			if allow := recorder.Header().Get(variables.AllowHeader); allow != http.MethodPost {
+				t.Errorf("%s %s Allow = %q, want POST", method, path, allow)
+			}
+		}
+	}
+}
+
+func TestResourceRoutesStillTakeIds(t *testing.T) {
+	api := getTestApi(&fakeCore{})
+
+	recorder := serve(api, http.MethodGet, "/api/v1/films/7", ``)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("GET film status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+
+	recorder = serve(api, http.MethodGet, "/api/v1/films/added", ``)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("GET film with a bad id status = %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,53 @@
+package delivery
+
+import (
+	"filmoteka/pkg/variables"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// searchQuery reads the q parameter, the old film_name and actor_name pair
+// is searched together when q is missing.
+func searchQuery(r *http.Request) (string, error) {
+	params := r.URL.Query()
+	query := strings.TrimSpace(params.Get(variables.SearchQueryParam))
+	if query == "" {
+		query = strings.TrimSpace(params.Get(variables.SearchFilmNameParam) + " " + params.Get(variables.SearchActorNameParam))
+	}
+
+	length := utf8.RuneCountInString(query)
+	if length < variables.SearchQueryBegin || length > variables.SearchQueryEnd {
+		return "", fmt.Errorf("%s: %d characters", variables.SearchQueryParam, length)
+	}
+	return query, nil
+}
+
+func suggestLimit(r *http.Request) (uint64, error) {
+	value := r.URL.Query().Get(variables.SuggestLimitParam)
+	if value == "" {
+		return variables.SuggestLimitDefault, nil
+	}
+	limit, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || limit == 0 || limit > variables.SuggestLimitMax {
+		return 0, fmt.Errorf("%s: %q", variables.SuggestLimitParam, value)
+	}
+	return limit, nil
+}
+
+// sectionPage reads the page of one search section like util.Pagination
+// does, falling back to the shared page parameter
+func sectionPage(r *http.Request, param string) uint64 {
+	query := r.URL.Query()
+	value := query.Get(param)
+	if value == "" {
+		value = query.Get(variables.PaginationPageNumber)
+	}
+	page, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || page == 0 {
+		return 1
+	}
+	return page
+}
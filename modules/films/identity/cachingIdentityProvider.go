@@ -0,0 +1,204 @@
+package identity
+
+import (
+	"container/list"
+	"context"
+	"filmoteka/pkg/variables"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+type provider interface {
+	GetUserId(ctx context.Context, sid string) (int64, error)
+	GetUserRole(ctx context.Context, id int64) (string, error)
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// lruCache is a size-bounded map whose entries also expire after ttl.
+// Every removal bumps generation, so a value fetched before an
+// invalidation is never stored after it.
+type lruCache[K comparable, V any] struct {
+	mutex      sync.Mutex
+	capacity   int
+	ttl        time.Duration
+	order      *list.List
+	items      map[K]*list.Element
+	generation uint64
+}
+
+func newLruCache[K comparable, V any](capacity int, ttl time.Duration) *lruCache[K, V] {
+	return &lruCache[K, V]{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		items:    make(map[K]*list.Element),
+	}
+}
+
+func (cache *lruCache[K, V]) get(key K) (V, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	var empty V
+	element, found := cache.items[key]
+	if !found {
+		return empty, false
+	}
+
+	entry := element.Value.(*cacheEntry[K, V])
+	if time.Now().After(entry.expiresAt) {
+		cache.order.Remove(element)
+		delete(cache.items, key)
+		return empty, false
+	}
+
+	cache.order.MoveToFront(element)
+	return entry.value, true
+}
+
+func (cache *lruCache[K, V]) currentGeneration() uint64 {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return cache.generation
+}
+
+func (cache *lruCache[K, V]) set(key K, value V, generation uint64) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if generation != cache.generation {
+		return
+	}
+
+	expiresAt := time.Now().Add(cache.ttl)
+	if element, found := cache.items[key]; found {
+		entry := element.Value.(*cacheEntry[K, V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.items[key] = cache.order.PushFront(&cacheEntry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for cache.order.Len() > cache.capacity {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.items, oldest.Value.(*cacheEntry[K, V]).key)
+	}
+}
+
+func (cache *lruCache[K, V]) delete(key K) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.generation++
+	if element, found := cache.items[key]; found {
+		cache.order.Remove(element)
+		delete(cache.items, key)
+	}
+}
+
+func (cache *lruCache[K, V]) purge() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.generation++
+	cache.order.Init()
+	cache.items = make(map[K]*list.Element)
+}
+
+// CachingIdentityProvider remembers successful lookups of the wrapped provider.
+// Concurrent misses for the same key share a single upstream call.
+type CachingIdentityProvider struct {
+	next     provider
+	sessions *lruCache[string, int64]
+	roles    *lruCache[int64, string]
+	calls    singleflight.Group
+}
+
+func GetCachingIdentityProvider(next provider, capacity int, ttl time.Duration) *CachingIdentityProvider {
+	return &CachingIdentityProvider{
+		next:     next,
+		sessions: newLruCache[string, int64](capacity, ttl),
+		roles:    newLruCache[int64, string](capacity, ttl),
+	}
+}
+
+func (cache *CachingIdentityProvider) GetUserId(ctx context.Context, sid string) (int64, error) {
+	if id, found := cache.sessions.get(sid); found {
+		return id, nil
+	}
+
+	value, err := cache.share(ctx, "sid:"+sid, func(ctx context.Context) (any, error) {
+		generation := cache.sessions.currentGeneration()
+		id, err := cache.next.GetUserId(ctx, sid)
+		if err != nil {
+			return int64(0), err
+		}
+		cache.sessions.set(sid, id, generation)
+		return id, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return value.(int64), nil
+}
+
+func (cache *CachingIdentityProvider) GetUserRole(ctx context.Context, id int64) (string, error) {
+	if role, found := cache.roles.get(id); found {
+		return role, nil
+	}
+
+	value, err := cache.share(ctx, "role:"+strconv.FormatInt(id, 10), func(ctx context.Context) (any, error) {
+		generation := cache.roles.currentGeneration()
+		role, err := cache.next.GetUserRole(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		cache.roles.set(id, role, generation)
+		return role, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+// share runs fetch once for all concurrent misses of key. The call keeps
+// the values of the first caller's ctx but not its cancellation, so one
+// caller giving up fails only itself and not everyone waiting on the call.
+func (cache *CachingIdentityProvider) share(ctx context.Context, key string, fetch func(ctx context.Context) (any, error)) (any, error) {
+	results := cache.calls.DoChan(key, func() (any, error) {
+		detached, cancel := context.WithTimeout(context.WithoutCancel(ctx), variables.IdentityLookupTimeout)
+		defer cancel()
+		return fetch(detached)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		return result.Val, result.Err
+	}
+}
+
+func (cache *CachingIdentityProvider) InvalidateSession(sid string) {
+	cache.sessions.delete(sid)
+}
+
+func (cache *CachingIdentityProvider) InvalidateRole(id int64) {
+	cache.roles.delete(id)
+}
+
+func (cache *CachingIdentityProvider) Purge() {
+	cache.sessions.purge()
+	cache.roles.purge()
+}
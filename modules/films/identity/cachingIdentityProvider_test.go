@@ -0,0 +1,229 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingProvider counts upstream lookups. When release is set, every
+// lookup announces itself on started and waits for release first.
+type countingProvider struct {
+	*MemoryIdentityProvider
+	calls   atomic.Int64
+	started chan struct{}
+	release chan struct{}
+	ctxDone atomic.Bool
+}
+
+func getCountingProvider() *countingProvider {
+	upstream := &countingProvider{MemoryIdentityProvider: GetMemoryIdentityProvider()}
+	upstream.SetSession("session", 1)
+	upstream.SetRole(1, "user")
+	return upstream
+}
+
+func (upstream *countingProvider) block() {
+	upstream.started = make(chan struct{}, 16)
+	upstream.release = make(chan struct{})
+}
+
+func (upstream *countingProvider) GetUserId(ctx context.Context, sid string) (int64, error) {
+	upstream.calls.Add(1)
+	if upstream.release != nil {
+		upstream.started <- struct{}{}
+		<-upstream.release
+	}
+	upstream.ctxDone.Store(ctx.Err() != nil)
+	return upstream.MemoryIdentityProvider.GetUserId(ctx, sid)
+}
+
+func (upstream *countingProvider) GetUserRole(ctx context.Context, id int64) (string, error) {
+	upstream.calls.Add(1)
+	return upstream.MemoryIdentityProvider.GetUserRole(ctx, id)
+}
+
+func TestCacheServesRepeatedLookups(t *testing.T) {
+	upstream := getCountingProvider()
+	cache := GetCachingIdentityProvider(upstream, 10, time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		id, err := cache.GetUserId(ctx, "session")
+		if err != nil || id != 1 {
+			t.Fatalf("GetUserId = %d, %v, want 1", id, err)
+		}
+		role, err := cache.GetUserRole(ctx, 1)
+		if err != nil || role != "user" {
+			t.Fatalf("GetUserRole = %q, %v, want user", role, err)
+		}
+	}
+
+	if calls := upstream.calls.Load(); calls != 2 {
+		t.Errorf("upstream calls = %d, want 2", calls)
+	}
+}
+
+func TestCacheDoesNotKeepErrors(t *testing.T) {
+	upstream := getCountingProvider()
+	cache := GetCachingIdentityProvider(upstream, 10, time.Minute)
+	ctx := context.Background()
+
+	if _, err := cache.GetUserId(ctx, "missing"); err == nil {
+		t.Fatal("GetUserId found a missing session")
+	}
+	upstream.SetSession("missing", 2)
+	if id, err := cache.GetUserId(ctx, "missing"); err != nil || id != 2 {
+		t.Errorf("GetUserId = %d, %v, want 2", id, err)
+	}
+}
+
+func TestCacheEntriesExpire(t *testing.T) {
+	upstream := getCountingProvider()
+	cache := GetCachingIdentityProvider(upstream, 10, 20*time.Millisecond)
+	ctx := context.Background()
+
+	if _, err := cache.GetUserId(ctx, "session"); err != nil {
+		t.Fatal(err)
+	}
+	upstream.SetSession("session", 5)
+	if id, _ := cache.GetUserId(ctx, "session"); id != 1 {
+		t.Errorf("GetUserId before ttl = %d, want the cached 1", id)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if id, _ := cache.GetUserId(ctx, "session"); id != 5 {
+		t.Errorf("GetUserId after ttl = %d, want the fresh 5", id)
+	}
+	if calls := upstream.calls.Load(); calls != 2 {
+		t.Errorf("upstream calls = %d, want 2", calls)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newLruCache[string, int](2, time.Minute)
+	cache.set("a", 1, 0)
+	cache.set("b", 2, 0)
+	cache.get("a")
+	cache.set("c", 3, 0)
+
+	if _, found := cache.get("b"); found {
+		t.Error("least recently used entry b was kept")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, found := cache.get(key); !found {
+			t.Errorf("entry %s was evicted", key)
+		}
+	}
+}
+
+func TestCacheSkipsValuesFetchedBeforeRemoval(t *testing.T) {
+	cache := newLruCache[string, int](2, time.Minute)
+
+	generation := cache.currentGeneration()
+	cache.delete("a")
+	cache.set("a", 1, generation)
+	if _, found := cache.get("a"); found {
+		t.Error("value fetched before a delete was stored")
+	}
+
+	generation = cache.currentGeneration()
+	cache.purge()
+	cache.set("a", 1, generation)
+	if _, found := cache.get("a"); found {
+		t.Error("value fetched before a purge was stored")
+	}
+}
+
+func TestConcurrentMissesShareOneCall(t *testing.T) {
+	upstream := getCountingProvider()
+	upstream.block()
+	cache := GetCachingIdentityProvider(upstream, 10, time.Minute)
+
+	const callers = 8
+	var group sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			_, err := cache.GetUserId(context.Background(), "session")
+			errs <- err
+		}()
+	}
+
+	<-upstream.started
+	// give the other callers time to join the call in flight
+	time.Sleep(20 * time.Millisecond)
+	close(upstream.release)
+	group.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("GetUserId: %v", err)
+		}
+	}
+	if calls := upstream.calls.Load(); calls != 1 {
+		t.Errorf("upstream calls = %d, want 1", calls)
+	}
+}
+
+func TestCancelledCallerDoesNotFailOthers(t *testing.T) {
+	upstream := getCountingProvider()
+	upstream.block()
+	cache := GetCachingIdentityProvider(upstream, 10, time.Minute)
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := cache.GetUserId(first, "session")
+		firstErr <- err
+	}()
+	<-upstream.started
+
+	secondId := make(chan int64, 1)
+	go func() {
+		id, _ := cache.GetUserId(context.Background(), "session")
+		secondId <- id
+	}()
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller got %v, want %v", err, context.Canceled)
+	}
+
+	close(upstream.release)
+	if id := <-secondId; id != 1 {
+		t.Errorf("waiting caller got %d, want 1", id)
+	}
+	if upstream.ctxDone.Load() {
+		t.Error("upstream ctx was done by the cancelled caller")
+	}
+	if calls := upstream.calls.Load(); calls != 1 {
+		t.Errorf("upstream calls = %d, want 1", calls)
+	}
+}
+
+func TestInvalidationDuringFetchIsNotCached(t *testing.T) {
+	upstream := getCountingProvider()
+	upstream.block()
+	cache := GetCachingIdentityProvider(upstream, 10, time.Minute)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.GetUserId(context.Background(), "session")
+	}()
+	<-upstream.started
+	cache.InvalidateSession("session")
+	close(upstream.release)
+	<-done
+
+	if _, found := cache.sessions.get("session"); found {
+		t.Error("value fetched before the invalidation was cached")
+	}
+}
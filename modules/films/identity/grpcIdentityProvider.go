@@ -0,0 +1,129 @@
+package identity
+
+import (
+	"context"
+	"filmoteka/modules/authorization/proto/authorization"
+	"filmoteka/pkg/metrics"
+	"filmoteka/pkg/variables"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type invalidator interface {
+	InvalidateSession(sid string)
+	InvalidateRole(id int64)
+	Purge()
+}
+
+// GrpcIdentityProvider resolves sessions and roles through the authorization service.
+type GrpcIdentityProvider struct {
+	conn   *grpc.ClientConn
+	client authorization.AuthorizationClient
+	health healthpb.HealthClient
+	logger *slog.Logger
+}
+
+func GetGrpcIdentityProvider(configGrpc variables.GrpcConfig, logger *slog.Logger) (*GrpcIdentityProvider, error) {
+	conn, err := grpc.Dial(net.JoinHostPort(configGrpc.Host, configGrpc.Port),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithChainUnaryInterceptor(metrics.UnaryClientInterceptor),
+		grpc.WithChainStreamInterceptor(metrics.StreamClientInterceptor),
+	)
+	if err != nil {
+		logger.Error(variables.GrpcConnectError, "error", err)
+		return nil, fmt.Errorf("%s: %w", variables.GrpcConnectError, err)
+	}
+
+	return &GrpcIdentityProvider{
+		conn:   conn,
+		client: authorization.NewAuthorizationClient(conn),
+		health: healthpb.NewHealthClient(conn),
+		logger: logger.With(variables.ModuleLogger, variables.IdentityModuleLogger),
+	}, nil
+}
+
+func (provider *GrpcIdentityProvider) GetUserId(ctx context.Context, sid string) (int64, error) {
+	grpcResponse, err := provider.client.GetId(ctx, &authorization.FindIdRequest{Sid: sid})
+	if err != nil {
+		provider.logger.Error(variables.GrpcRecievError, "error", err)
+		return 0, fmt.Errorf("%s: %w", variables.GrpcRecievError, err)
+	}
+	return grpcResponse.GetValue(), nil
+}
+
+func (provider *GrpcIdentityProvider) GetUserRole(ctx context.Context, id int64) (string, error) {
+	grpcResponse, err := provider.client.GetRole(ctx, &authorization.RoleRequest{Id: id})
+	if err != nil {
+		provider.logger.Error(variables.GrpcRecievError, "error", err)
+		return "", fmt.Errorf("%s: %w", variables.GrpcRecievError, err)
+	}
+	return grpcResponse.GetRole(), nil
+}
+
+// WatchInvalidations applies invalidations pushed by the authorization service
+// until ctx is done. Whenever the stream breaks, events may have been missed,
+// so the whole cache is purged before reconnecting.
+func (provider *GrpcIdentityProvider) WatchInvalidations(ctx context.Context, cache invalidator, retryTimeout time.Duration) {
+	for {
+		err := provider.watch(ctx, cache)
+		cache.Purge()
+		if ctx.Err() != nil {
+			return
+		}
+		provider.logger.Error(variables.InvalidationsWatchError, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryTimeout):
+		}
+	}
+}
+
+func (provider *GrpcIdentityProvider) watch(ctx context.Context, cache invalidator) error {
+	stream, err := provider.client.WatchInvalidations(ctx, &authorization.WatchInvalidationsRequest{})
+	if err != nil {
+		return err
+	}
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		if event.GetSid() != "" {
+			cache.InvalidateSession(event.GetSid())
+		}
+		if event.GetId() != 0 {
+			cache.InvalidateRole(event.GetId())
+		}
+	}
+}
+
+func (provider *GrpcIdentityProvider) Name() string {
+	return variables.AuthGrpcHealthChecker
+}
+
+func (provider *GrpcIdentityProvider) CheckHealth(ctx context.Context) error {
+	response, err := provider.health.Check(ctx, &healthpb.HealthCheckRequest{Service: authorization.Authorization_ServiceDesc.ServiceName})
+	if err != nil {
+		return err
+	}
+	if response.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("%s: %s", variables.ServiceNotReadyError, response.GetStatus())
+	}
+	return nil
+}
+
+func (provider *GrpcIdentityProvider) Close() error {
+	return provider.conn.Close()
+}
@@ -0,0 +1,126 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"filmoteka/modules/authorization/proto/authorization"
+	"io"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// scriptedClient answers each WatchInvalidations call with the next script:
+// the script's events and then a broken stream. The stream of the last
+// script stays open until ctx is done.
+type scriptedClient struct {
+	authorization.AuthorizationClient
+	mutex   sync.Mutex
+	scripts [][]*authorization.InvalidationEvent
+}
+
+func (client *scriptedClient) WatchInvalidations(ctx context.Context, in *authorization.WatchInvalidationsRequest, opts ...grpc.CallOption) (authorization.Authorization_WatchInvalidationsClient, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	events := client.scripts[0]
+	if len(client.scripts) == 1 {
+		return &scriptedStream{ctx: ctx, events: events, open: true}, nil
+	}
+	client.scripts = client.scripts[1:]
+	return &scriptedStream{ctx: ctx, events: events}, nil
+}
+
+type scriptedStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	events []*authorization.InvalidationEvent
+	open   bool
+}
+
+func (stream *scriptedStream) Recv() (*authorization.InvalidationEvent, error) {
+	if len(stream.events) > 0 {
+		event := stream.events[0]
+		stream.events = stream.events[1:]
+		return event, nil
+	}
+	if stream.open {
+		<-stream.ctx.Done()
+		return nil, stream.ctx.Err()
+	}
+	return nil, errors.New("stream broken")
+}
+
+// recordingInvalidator keeps the calls it receives in order
+type recordingInvalidator struct {
+	mutex sync.Mutex
+	calls []string
+}
+
+func (recorder *recordingInvalidator) record(call string) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.calls = append(recorder.calls, call)
+}
+
+func (recorder *recordingInvalidator) recorded() []string {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return append([]string(nil), recorder.calls...)
+}
+
+func (recorder *recordingInvalidator) InvalidateSession(sid string) {
+	recorder.record("session " + sid)
+}
+
+func (recorder *recordingInvalidator) InvalidateRole(id int64) {
+	recorder.record("role " + strconv.FormatInt(id, 10))
+}
+
+func (recorder *recordingInvalidator) Purge() {
+	recorder.record("purge")
+}
+
+func TestWatchInvalidationsPurgesOnReconnect(t *testing.T) {
+	client := &scriptedClient{scripts: [][]*authorization.InvalidationEvent{
+		{{Sid: "first"}, {Id: 7}},
+		{{Sid: "second"}},
+	}}
+	provider := &GrpcIdentityProvider{
+		client: client,
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	recorder := &recordingInvalidator{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		provider.WatchInvalidations(ctx, recorder, time.Millisecond)
+	}()
+
+	connected := []string{"session first", "role 7", "purge", "session second", "purge"}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(recorder.recorded()) < len(connected)-1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("calls = %v, want %v", recorder.recorded(), connected)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("WatchInvalidations did not return after ctx was done")
+	}
+
+	if calls := recorder.recorded(); !reflect.DeepEqual(calls, connected) {
+		t.Errorf("calls = %v, want %v", calls, connected)
+	}
+}
@@ -0,0 +1,63 @@
+package identity
+
+import (
+	"context"
+	"filmoteka/pkg/variables"
+	"fmt"
+	"sync"
+)
+
+// MemoryIdentityProvider keeps sessions and roles in memory. It is meant for tests
+// and local runs without the authorization service.
+type MemoryIdentityProvider struct {
+	mutex    sync.RWMutex
+	sessions map[string]int64
+	roles    map[int64]string
+}
+
+func GetMemoryIdentityProvider() *MemoryIdentityProvider {
+	return &MemoryIdentityProvider{
+		sessions: make(map[string]int64),
+		roles:    make(map[int64]string),
+	}
+}
+
+func (provider *MemoryIdentityProvider) SetSession(sid string, id int64) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+	provider.sessions[sid] = id
+}
+
+func (provider *MemoryIdentityProvider) DeleteSession(sid string) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+	delete(provider.sessions, sid)
+}
+
+func (provider *MemoryIdentityProvider) SetRole(id int64, role string) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+	provider.roles[id] = role
+}
+
+func (provider *MemoryIdentityProvider) GetUserId(ctx context.Context, sid string) (int64, error) {
+	provider.mutex.RLock()
+	defer provider.mutex.RUnlock()
+
+	id, found := provider.sessions[sid]
+	if !found {
+		return 0, fmt.Errorf("%s: %s", variables.SessionNotFoundError, sid)
+	}
+	return id, nil
+}
+
+func (provider *MemoryIdentityProvider) GetUserRole(ctx context.Context, id int64) (string, error) {
+	provider.mutex.RLock()
+	defer provider.mutex.RUnlock()
+
+	role, found := provider.roles[id]
+	if !found {
+		return "", fmt.Errorf("%s: %d", variables.ProfileRoleNotFoundByLoginError, id)
+	}
+	return role, nil
+}
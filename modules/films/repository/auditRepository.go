@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/pkg/audit"
+	"filmoteka/pkg/models"
+	communication "filmoteka/pkg/requests"
+	"filmoteka/pkg/variables"
+)
+
+// States recorded in the audit log, a film's crew is kept in billing order
+// and an actor's acting credits as sorted film ids. Whether the row is
+// trashed is read along, but not recorded.
+type (
+	filmState struct {
+		Title       string              `json:"title"`
+		Description string              `json:"description"`
+		Rating      float64             `json:"rating"`
+		ReleaseDate string              `json:"release_date"`
+		Crew        []models.CrewMember `json:"crew"`
+		models.FilmMetadata
+		trashed bool
+	}
+
+	actorState struct {
+		Name      string  `json:"name"`
+		Gender    string  `json:"gender"`
+		BirthDate string  `json:"birth_date"`
+		Films     []int64 `json:"films"`
+		trashed   bool
+	}
+
+	genreState struct {
+		Slug string `json:"slug"`
+		Name string `json:"name"`
+	}
+)
+
+func (repository *FilmRepository) GetAudit(ctx context.Context, filter models.AuditFilter, offset uint64, limit uint64) (communication.AuditListResponse, error) {
+	entries, err := audit.List(ctx, repository.db, filter, offset, limit)
+	if err != nil {
+		return communication.AuditListResponse{}, err
+	}
+	return communication.AuditListResponse{Entries: entries}, nil
+}
+
+// readFilmState locks the film row for the rest of the transaction
+func readFilmState(ctx context.Context, tx *sql.Tx, id int64) (*filmState, error) {
+	var state filmState
+	err := tx.QueryRowContext(ctx, `
+    SELECT film.name, COALESCE(film.description, ''), COALESCE(film.rating, 0), COALESCE(film.releaseDate::text, ''),
+           `+filmMetadataColumns("film")+`, film.deleted_at IS NOT NULL
+    FROM film WHERE film.id = $1 FOR UPDATE`, id).
+		Scan(append([]any{&state.Title, &state.Description, &state.Rating, &state.ReleaseDate},
+			append(filmMetadataTargets(&state.FilmMetadata), &state.trashed)...)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, variables.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state.Crew, err = readCrew(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// readActorState locks the actor row for the rest of the transaction
+func readActorState(ctx context.Context, tx *sql.Tx, id int64) (*actorState, error) {
+	var state actorState
+	err := tx.QueryRowContext(ctx, `
+    SELECT name, COALESCE(gender, ''), COALESCE(birthdate::text, ''), deleted_at IS NOT NULL
+    FROM actor WHERE id = $1 FOR UPDATE`, id).
+		Scan(&state.Name, &state.Gender, &state.BirthDate, &state.trashed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, variables.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state.Films, err = readLinks(ctx, tx, `SELECT film_id FROM film_actor WHERE actor_id = $1 AND role = $2 ORDER BY film_id`, id, variables.CrewRoleActor)
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func readCrew(ctx context.Context, tx *sql.Tx, id int64) ([]models.CrewMember, error) {
+	rows, err := tx.QueryContext(ctx, `
+    SELECT actor_id, role, character_name, billing_order FROM film_actor
+    WHERE film_id = $1 ORDER BY billing_order, actor_id, role`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	crew := []models.CrewMember{}
+	for rows.Next() {
+		var member models.CrewMember
+		err := rows.Scan(&member.ActorId, &member.Role, &member.CharacterName, &member.BillingOrder)
+		if err != nil {
+			return nil, err
+		}
+		crew = append(crew, member)
+	}
+	return crew, rows.Err()
+}
+
+func readLinks(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []int64{}
+	for rows.Next() {
+		var link int64
+		err := rows.Scan(&link)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// auditFilm records a film change, a nil state means the film is absent
+// on that side of the change.
+func auditFilm(ctx context.Context, tx *sql.Tx, action string, id int64, before *filmState, after *filmState) error {
+	entry := audit.Entry{Action: action, Entity: variables.AuditEntityFilm, EntityId: id}
+	if before != nil {
+		entry.Before = before
+	}
+	if after != nil {
+		entry.After = after
+	}
+	return audit.Record(ctx, tx, entry)
+}
+
+func auditActor(ctx context.Context, tx *sql.Tx, action string, id int64, before *actorState, after *actorState) error {
+	entry := audit.Entry{Action: action, Entity: variables.AuditEntityActor, EntityId: id}
+	if before != nil {
+		entry.Before = before
+	}
+	if after != nil {
+		entry.After = after
+	}
+	return audit.Record(ctx, tx, entry)
+}
+
+// readGenreState locks the genre row for the rest of the transaction
+func readGenreState(ctx context.Context, tx *sql.Tx, id int64) (*genreState, error) {
+	var state genreState
+	err := tx.QueryRowContext(ctx, `SELECT slug, name FROM genre WHERE id = $1 FOR UPDATE`, id).Scan(&state.Slug, &state.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, variables.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func auditGenre(ctx context.Context, tx *sql.Tx, action string, id int64, before *genreState, after *genreState) error {
+	entry := audit.Entry{Action: action, Entity: variables.AuditEntityGenre, EntityId: id}
+	if before != nil {
+		entry.Before = before
+	}
+	if after != nil {
+		entry.After = after
+	}
+	return audit.Record(ctx, tx, entry)
+}
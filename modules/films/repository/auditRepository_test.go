@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/variables"
+	"testing"
+)
+
+func TestAuditRecordsChangesInTheirTransaction(t *testing.T) {
+	repository := getTestRepository(t)
+	ctx := context.WithValue(context.Background(), variables.UserIDKey, int64(5))
+	ctx = context.WithValue(ctx, variables.RequestIDKey, "request-1")
+
+	filmId, err := repository.AddFilm(ctx, "Audit probe", "", 6, "2001-01-01", nil, models.FilmMetadata{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rating := 8.0
+	_, err = repository.EditFilm(ctx, filmId, 0, models.FilmPatch{Rating: &rating})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	audit, err := repository.GetAudit(ctx, models.AuditFilter{Entity: variables.AuditEntityFilm, EntityId: filmId}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(audit.Entries) != 2 {
+		t.Fatalf("entries = %+v, want create and update", audit.Entries)
+	}
+
+	update, create := audit.Entries[0], audit.Entries[1]
+	if create.Action != variables.AuditActionCreate || update.Action != variables.AuditActionUpdate {
+		t.Errorf("actions = %s, %s, want update, create", update.Action, create.Action)
+	}
+	for _, entry := range audit.Entries {
+		if entry.ActorId == nil || *entry.ActorId != 5 || entry.RequestId != "request-1" {
+			t.Errorf("entry %d has actor %v and request %q, want 5 and request-1", entry.Id, entry.ActorId, entry.RequestId)
+		}
+	}
+	if len(update.Changes) != 1 || update.Changes["rating"].Before != 6.0 || update.Changes["rating"].After != 8.0 {
+		t.Errorf("update changes = %v, want only rating 6 to 8", update.Changes)
+	}
+}
+
+func TestAuditLogIsAppendOnly(t *testing.T) {
+	repository := getTestRepository(t)
+	ctx := context.Background()
+	addTestActor(t, repository, "Audit probe")
+
+	statements := []string{
+		`UPDATE audit_log SET action = 'forged'`,
+		`DELETE FROM audit_log`,
+		`TRUNCATE audit_log`,
+	}
+	for _, statement := range statements {
+		_, err := repository.db.ExecContext(ctx, statement)
+		if err == nil {
+			t.Errorf("%s was allowed", statement)
+		}
+	}
+
+	audit, err := repository.GetAudit(ctx, models.AuditFilter{}, 0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(audit.Entries) == 0 {
+		t.Fatal("the audit log lost its entries")
+	}
+	for _, entry := range audit.Entries {
+		if entry.Action == "forged" {
+			t.Errorf("entry %d was changed", entry.Id)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/pkg/variables"
+	"fmt"
+
+	"github.com/jackc/pgx"
+)
+
+// Postgres error codes of the integrity constraint violation class
+const (
+	notNullViolation    = "23502"
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+	checkViolation      = "23514"
+)
+
+// mapConstraintError turns constraint violations into the errors the
+// delivery layer knows how to answer, keeping the constraint name.
+func mapConstraintError(err error) error {
+	var pgErr pgx.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case uniqueViolation:
+		return fmt.Errorf("%w: %s", variables.ErrAlreadyExists, pgErr.ConstraintName)
+	case foreignKeyViolation:
+		return fmt.Errorf("%w: %s", variables.ErrReferenceNotFound, pgErr.ConstraintName)
+	case checkViolation, notNullViolation:
+		return fmt.Errorf("%w: %s", variables.ErrConstraintViolated, pgErr.ConstraintName+pgErr.ColumnName)
+	}
+	return err
+}
+
+func (repository *FilmRepository) inTransaction(ctx context.Context, run func(tx *sql.Tx) error) error {
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = run(tx)
+	if err != nil {
+		return errors.Join(mapConstraintError(err), tx.Rollback())
+	}
+	return mapConstraintError(tx.Commit())
+}
+
+func expectAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return variables.ErrNotFound
+	}
+	return nil
+}
+
+// expectVersion is expectAffected for conditional updates of a row that
+// is known to exist
+func expectVersion(result sql.Result) error {
+	err := expectAffected(result)
+	if errors.Is(err, variables.ErrNotFound) {
+		return variables.ErrVersionMismatch
+	}
+	return err
+}
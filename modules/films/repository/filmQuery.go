@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"errors"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/variables"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// filmSortColumns whitelists what the films list can be ordered by, only
+// these columns are ever spliced into the query.
+var filmSortColumns = map[string]string{
+	variables.FilmSortTitle:       "f.name",
+	variables.FilmSortRating:      "f.rating",
+	variables.FilmSortReleaseDate: "f.releaseDate",
+	variables.FilmSortRuntime:     "f.runtime",
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// filmListQuery builds the films list query from a filter and sort keys.
+// Values always go in as arguments.
+type filmListQuery struct {
+	conditions []string
+	args       []any
+}
+
+// arg adds a query argument and returns its placeholder
+func (query *filmListQuery) arg(value any) string {
+	query.args = append(query.args, value)
+	return "$" + strconv.Itoa(len(query.args))
+}
+
+func (query *filmListQuery) where(condition string, values ...any) {
+	placeholders := make([]any, len(values))
+	for i, value := range values {
+		placeholders[i] = query.arg(value)
+	}
+	query.conditions = append(query.conditions, fmt.Sprintf(condition, placeholders...))
+}
+
+func (query *filmListQuery) filter(filter models.FilmFilter) {
+	query.conditions = append(query.conditions, "f.deleted_at IS NULL")
+
+	if filter.TitlePrefix != "" {
+		query.where(`f.name ILIKE %s::text || '%%'`, likeEscaper.Replace(filter.TitlePrefix))
+	}
+	if filter.MinRating != nil {
+		query.where("f.rating >= %s", *filter.MinRating)
+	}
+	if filter.MaxRating != nil {
+		query.where("f.rating <= %s", *filter.MaxRating)
+	}
+	if filter.ReleasedFrom != "" {
+		query.where("f.releaseDate >= %s::date", filter.ReleasedFrom)
+	}
+	if filter.ReleasedTo != "" {
+		query.where("f.releaseDate <= %s::date", filter.ReleasedTo)
+	}
+	if len(filter.CrewIds) > 0 {
+		placeholders := make([]string, len(filter.CrewIds))
+		for i, id := range filter.CrewIds {
+			placeholders[i] = query.arg(id)
+		}
+		crew := fmt.Sprintf(`
+        SELECT DISTINCT film_actor.actor_id FROM film_actor
+        JOIN actor ON actor.id = film_actor.actor_id AND actor.deleted_at IS NULL
+        WHERE film_actor.film_id = f.id AND film_actor.actor_id IN (%s)`, strings.Join(placeholders, ", "))
+
+		if filter.CrewMatchAll {
+			query.where("(SELECT COUNT(*) FROM ("+crew+") AS matched) = %s", len(filter.CrewIds))
+		} else {
+			query.conditions = append(query.conditions, "EXISTS ("+crew+")")
+		}
+	}
+	if filter.Genre != "" {
+		query.where("EXISTS (SELECT 1 FROM film_genre JOIN genre ON genre.id = film_genre.genre_id WHERE film_genre.film_id = f.id AND genre.slug = %s)", filter.Genre)
+	}
+	if filter.Tag != "" {
+		query.where("EXISTS (SELECT 1 FROM film_tag WHERE film_tag.film_id = f.id AND film_tag.tag = %s)", filter.Tag)
+	}
+	if filter.Country != "" {
+		query.where("EXISTS (SELECT 1 FROM film_country WHERE film_country.film_id = f.id AND film_country.country = %s)", filter.Country)
+	}
+	if filter.MinRuntime != 0 {
+		query.where("f.runtime >= %s", filter.MinRuntime)
+	}
+	if filter.MaxRuntime != 0 {
+		query.where("f.runtime <= %s", filter.MaxRuntime)
+	}
+	if filter.AgeRating != "" {
+		query.where("f.age_rating = %s", filter.AgeRating)
+	}
+	if filter.Language != "" {
+		query.where("f.original_language = %s", filter.Language)
+	}
+}
+
+// orderBy turns sort keys into an ORDER BY list, the film id always comes
+// last so pages are stable.
+func orderBy(sort []models.SortKey) (string, error) {
+	keys := make([]string, 0, len(sort)+1)
+	for _, key := range sort {
+		column, found := filmSortColumns[key.Field]
+		if !found {
+			return "", errors.New(variables.FilmSortError)
+		}
+		if key.Descending {
+			keys = append(keys, column+" DESC NULLS LAST")
+		} else {
+			keys = append(keys, column+" ASC NULLS LAST")
+		}
+	}
+	return strings.Join(append(keys, "f.id"), ", "), nil
+}
+
+// build pages over the matching films and joins the live crew of the page,
+// so a page always holds whole films. Films without crew come with none.
+func (query *filmListQuery) build(sort []models.SortKey, offset uint64, limit uint64) (string, error) {
+	order, err := orderBy(sort)
+	if err != nil {
+		return "", err
+	}
+
+	limitArg, offsetArg := query.arg(limit), query.arg(offset)
+	return fmt.Sprintf(`
+    WITH page AS (
+        SELECT f.* FROM film f
+        WHERE %s
+        ORDER BY %s
+        LIMIT %s OFFSET %s
+    )
+    SELECT f.id, f.name, COALESCE(f.description, ''), COALESCE(f.rating, 0), COALESCE(f.releaseDate::text, ''), %s, f.version,
+           COALESCE(a.id, 0), COALESCE(a.name, ''), COALESCE(a.gender, ''), COALESCE(a.birthdate::text, ''), COALESCE(a.version, 0),
+           COALESCE(fa.role, ''), COALESCE(fa.character_name, ''), COALESCE(fa.billing_order, 0)
+    FROM page f
+    LEFT JOIN (film_actor fa JOIN actor a ON a.id = fa.actor_id AND a.deleted_at IS NULL) ON fa.film_id = f.id
+    ORDER BY %s, fa.billing_order, a.id`,
+		strings.Join(query.conditions, " AND "), order, limitArg, offsetArg, filmMetadataColumns("f"), order), nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/variables"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOrderByRejectsUnknownColumn(t *testing.T) {
+	for _, field := range []string{"name", "f.id; DROP TABLE film", ""} {
+		var query filmListQuery
+		query.filter(models.FilmFilter{})
+
+		_, err := query.build([]models.SortKey{{Field: variables.FilmSortRating}, {Field: field}}, 0, 10)
+		if err == nil || err.Error() != variables.FilmSortError {
+			t.Errorf("sort by %q: err = %v, want %s", field, err, variables.FilmSortError)
+		}
+	}
+}
+
+func TestOrderByKeepsKeyOrderAndEndsWithId(t *testing.T) {
+	order, err := orderBy([]models.SortKey{
+		{Field: variables.FilmSortRating, Descending: true},
+		{Field: variables.FilmSortTitle},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "f.rating DESC NULLS LAST, f.name ASC NULLS LAST, f.id"; order != want {
+		t.Errorf("order = %s, want %s", order, want)
+	}
+
+	order, _ = orderBy(nil)
+	if order != "f.id" {
+		t.Errorf("default order = %s, want f.id", order)
+	}
+}
+
+func TestFilterCombination(t *testing.T) {
+	minRating := 7.5
+	var query filmListQuery
+	query.filter(models.FilmFilter{
+		TitlePrefix:  "50%_off",
+		MinRating:    &minRating,
+		ReleasedFrom: "1990-01-01",
+		CrewIds:      []int64{3, 4},
+		CrewMatchAll: true,
+		Genre:        "drama",
+	})
+
+	built, err := query.build([]models.SortKey{{Field: variables.FilmSortReleaseDate}}, 20, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantArgs := []any{`50\%\_off`, 7.5, "1990-01-01", int64(3), int64(4), 2, "drama", uint64(10), uint64(20)}
+	if !reflect.DeepEqual(query.args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", query.args, wantArgs)
+	}
+
+	wantConditions := []string{
+		"f.deleted_at IS NULL",
+		`f.name ILIKE $1::text || '%'`,
+		"f.rating >= $2",
+		"f.releaseDate >= $3::date",
+	}
+	if !slices.Equal(query.conditions[:4], wantConditions) {
+		t.Errorf("conditions = %q, want them to start with %q", query.conditions[:4], wantConditions)
+	}
+	if crew := query.conditions[4]; !strings.Contains(crew, "IN ($4, $5)") || !strings.HasSuffix(crew, ") = $6") {
+		t.Errorf("crew condition = %s, want all of $4 and $5 counted against $6", crew)
+	}
+	if genre := query.conditions[5]; !strings.Contains(genre, "genre.slug = $7") {
+		t.Errorf("genre condition = %s, want the slug in $7", genre)
+	}
+
+	for _, fragment := range []string{
+		"WHERE " + strings.Join(query.conditions, " AND "),
+		"ORDER BY f.releaseDate ASC NULLS LAST, f.id\n        LIMIT $8 OFFSET $9",
+	} {
+		if !strings.Contains(built, fragment) {
+			t.Errorf("query lacks %q:\n%s", fragment, built)
+		}
+	}
+}
+
+func TestGetFilmsPagesFilteredAndSortedFilms(t *testing.T) {
+	repository := getTestRepository(t)
+	ctx := context.Background()
+
+	ids := make(map[string]int64)
+	for _, film := range []struct {
+		title  string
+		rating float64
+	}{
+		{"Page probe A", 9},
+		{"Page probe B", 4},
+		{"Page probe C", 7},
+		{"Page probe D", 8},
+		{"Page probe E", 6},
+		{"Page probe F", 8},
+		{"Unrelated film", 10},
+	} {
+		ids[film.title] = addTestFilm(t, repository, film.title, film.rating, "2001-01-01")
+	}
+
+	minRating := 5.0
+	filter := models.FilmFilter{TitlePrefix: "page PROBE", MinRating: &minRating}
+	sort := []models.SortKey{{Field: variables.FilmSortRating, Descending: true}}
+
+	// A 9, D 8, F 8, C 7, E 6 with the id breaking the tie of D and F
+	pages := [][]int64{
+		{ids["Page probe A"], ids["Page probe D"]},
+		{ids["Page probe F"], ids["Page probe C"]},
+		{ids["Page probe E"]},
+		{},
+	}
+	for page, want := range pages {
+		films, err := repository.GetFilms(ctx, uint64(page*2), 2, sort, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := listedFilmIds(films.Films); !slices.Equal(got, want) {
+			t.Errorf("page %d = %v, want %v", page+1, got, want)
+		}
+	}
+}
@@ -1,19 +1,25 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"filmoteka/pkg/metrics"
 	"filmoteka/pkg/models"
 	communication "filmoteka/pkg/requests"
+	"filmoteka/pkg/tracing"
 	"filmoteka/pkg/variables"
 	"fmt"
 	"log/slog"
+	"reflect"
+	"strings"
 	"time"
 
 	_ "github.com/jackc/pgx/stdlib"
 )
 
 type FilmRepository struct {
-	db *sql.DB
+	db *tracing.DB
 }
 
 //go:generate mockgen -source=core.go -destination=../mocks/core_mock.go -package=mocks
@@ -24,19 +30,24 @@ func GetFilmRepository(configDatabase variables.RelationalDataBaseConfig, logger
 
 	db, err := sql.Open("pgx", dsn)
 	if err != nil {
-		logger.Error(variables.SqlOpenError, err.Error())
+		logger.Error(variables.SqlOpenError, "error", err)
 		return nil, err
 	}
 
 	err = db.Ping()
 	if err != nil {
-		logger.Error(variables.SqlPingError, err.Error())
+		logger.Error(variables.SqlPingError, "error", err)
 		return nil, err
 	}
 
 	db.SetMaxOpenConns(configDatabase.MaxOpenConns)
 
-	filmRepository := &FilmRepository{db: db}
+	err = metrics.RegisterDBStats(db, configDatabase.DbName)
+	if err != nil {
+		logger.Error(variables.MetricsRegisterError, "error", err)
+	}
+
+	filmRepository := &FilmRepository{db: tracing.WrapDB(db, configDatabase.DbName)}
 
 	errs := make(chan error)
 	go func() {
@@ -44,7 +55,7 @@ func GetFilmRepository(configDatabase variables.RelationalDataBaseConfig, logger
 	}()
 
 	if err := <-errs; err != nil {
-		logger.Error(err.Error())
+		logger.Error(variables.SqlMaxPingRetriesError, "error", err)
 		return nil, err
 	}
 
@@ -62,30 +73,24 @@ func (repository *FilmRepository) pingDb(timer uint32, logger *slog.Logger) erro
 		}
 
 		retries++
-		logger.Error(variables.SqlPingError, err.Error())
+		logger.Error(variables.SqlPingError, "error", err)
 		time.Sleep(time.Duration(timer) * time.Second)
 	}
 
-	logger.Error(variables.SqlMaxPingRetriesError, err)
-	return fmt.Errorf(variables.SqlMaxPingRetriesError, err.Error())
+	return fmt.Errorf("%s %w", variables.SqlMaxPingRetriesError, err)
 }
 
-func (repository *FilmRepository) GetFilms(begin uint64, end uint64, sortType string) (communication.FilmsListResponse, error) {
-	var films []models.FilmItem
+func (repository *FilmRepository) GetFilms(ctx context.Context, offset uint64, limit uint64, sort []models.SortKey, filter models.FilmFilter) (communication.FilmsListResponse, error) {
+	films := []models.FilmItem{}
 
-	var query string
-	switch sortType {
-	case "name":
-		query = "SELECT f.id, f.name, f.description, f.rating, f.releaseDate, a.id, a.name, a.gender, a.birthdate FROM film f JOIN film_actor fa ON f.id = fa.film_id JOIN actor a ON fa.actor_id = a.id ORDER BY f.name LIMIT $1 OFFSET $2"
-	case "rating":
-		query = "SELECT f.id, f.name, f.description, f.rating, f.releaseDate, a.id, a.name, a.gender, a.birthdate FROM film f JOIN film_actor fa ON f.id = fa.film_id JOIN actor a ON fa.actor_id = a.id ORDER BY f.rating DESC LIMIT $1 OFFSET $2"
-	case "release_date":
-		query = "SELECT f.id, f.name, f.description, f.rating, f.releaseDate, a.id, a.name, a.gender, a.birthdate FROM film f JOIN film_actor fa ON f.id = fa.film_id JOIN actor a ON fa.actor_id = a.id ORDER BY f.releaseDate LIMIT $1 OFFSET $2"
-	default:
-		query = "SELECT f.id, f.name, f.description, f.rating, f.releaseDate, a.id, a.name, a.gender, a.birthdate FROM film f JOIN film_actor fa ON f.id = fa.film_id JOIN actor a ON fa.actor_id = a.id ORDER BY f.rating DESC LIMIT $1 OFFSET $2"
+	var listQuery filmListQuery
+	listQuery.filter(filter)
+	query, err := listQuery.build(sort, offset, limit)
+	if err != nil {
+		return communication.FilmsListResponse{}, err
 	}
 
-	rows, err := repository.db.Query(query, end-begin, begin)
+	rows, err := repository.db.QueryContext(ctx, query, listQuery.args...)
 	if err != nil {
 		return communication.FilmsListResponse{}, err
 	}
@@ -93,26 +98,29 @@ func (repository *FilmRepository) GetFilms(begin uint64, end uint64, sortType st
 
 	for rows.Next() {
 		var film models.FilmItem
-		var actor models.ActorItem
-		err := rows.Scan(&film.Id, &film.Title, &film.Description, &film.Rating, &film.ReleaseDate, &actor.Id, &actor.Name, &actor.Gender, &actor.BirthDate)
+		var member models.CrewItem
+		var role string
+		err := rows.Scan(append(append([]any{&film.Id, &film.Title, &film.Description, &film.Rating, &film.ReleaseDate},
+			filmMetadataTargets(&film.FilmMetadata)...),
+			&film.Version, &member.Id, &member.Name, &member.Gender, &member.BirthDate, &member.Version, &role, &member.CharacterName, &member.BillingOrder)...)
 		if err != nil {
 			return communication.FilmsListResponse{}, err
 		}
 
-		var existingFilm *models.FilmItem
-		for i := range films {
-			if films[i].Id == film.Id {
-				existingFilm = &films[i]
-				break
-			}
-		}
-
-		if existingFilm == nil {
-			film.Crew = []models.ActorItem{actor}
+		// Rows come ordered by film, so a film's rows are adjacent
+		if len(films) == 0 || films[len(films)-1].Id != film.Id {
+			film.Crew = map[string][]models.CrewItem{}
 			films = append(films, film)
-		} else {
-			existingFilm.Crew = append(existingFilm.Crew, actor)
 		}
+		if member.Id != 0 {
+			crew := films[len(films)-1].Crew
+			crew[role] = append(crew[role], member)
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return communication.FilmsListResponse{}, err
 	}
 
 	response := communication.FilmsListResponse{
@@ -122,166 +130,249 @@ func (repository *FilmRepository) GetFilms(begin uint64, end uint64, sortType st
 	return response, nil
 }
 
-func (repository *FilmRepository) FindFilm(filmName string, actorName string) (communication.FindFilmResponse, error) {
-	var response communication.FindFilmResponse
-
-	query := `SELECT f.id, f.name AS title, f.description, f.rating, f.releaseDate, a.id AS actor_id, a.name AS actor_name, a.gender, a.birthdate
-              FROM film f
-              JOIN film_actor fa ON f.id = fa.film_id
-              JOIN actor a ON a.id = fa.actor_id
-              WHERE f.name ILIKE $1 || '%'
-                  OR f.name ILIKE '%' || $1 || '%'
-                  OR f.name ILIKE '%' || $1 || ''
-                  OR a.name ILIKE $2 || '%'
-                  OR a.name ILIKE '%' || $2 || '%'
-                  OR a.name ILIKE '%' || $2 || ''
-              ORDER BY 
-              (CASE 
-                  WHEN f.name ILIKE $1 || '%' THEN 1
-                  WHEN f.name ILIKE '%' || $1 || '%' THEN 2
-                  WHEN f.name ILIKE '%' || $1 || '' THEN 3
-                  WHEN a.name ILIKE $2 || '%' THEN 4
-                  WHEN a.name ILIKE '%' || $2 || '%' THEN 5
-                  WHEN a.name ILIKE '%' || $2 || '' THEN 6
-                  ELSE 7
-              END)`
-
-	rows, err := repository.db.Query(query, filmName, actorName)
+func (repository *FilmRepository) GetFilm(ctx context.Context, id int64) (models.FilmItem, error) {
+	var film models.FilmItem
+	err := repository.db.QueryRowContext(ctx, `
+    SELECT film.id, film.name, COALESCE(film.description, ''), COALESCE(film.rating, 0), COALESCE(film.releaseDate::text, ''),
+           `+filmMetadataColumns("film")+`, film.version
+    FROM film WHERE film.id = $1 AND film.deleted_at IS NULL`, id).
+		Scan(append([]any{&film.Id, &film.Title, &film.Description, &film.Rating, &film.ReleaseDate},
+			append(filmMetadataTargets(&film.FilmMetadata), &film.Version)...)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.FilmItem{}, variables.ErrNotFound
+	}
 	if err != nil {
-		return response, err
+		return models.FilmItem{}, err
+	}
+
+	rows, err := repository.db.QueryContext(ctx, `
+    SELECT actor.id, actor.name, COALESCE(actor.gender, ''), COALESCE(actor.birthdate::text, ''), actor.version,
+           film_actor.role, film_actor.character_name, film_actor.billing_order
+    FROM actor
+    JOIN film_actor ON film_actor.actor_id = actor.id
+    WHERE film_actor.film_id = $1 AND actor.deleted_at IS NULL
+    ORDER BY film_actor.billing_order, actor.id`, id)
+	if err != nil {
+		return models.FilmItem{}, err
 	}
 	defer rows.Close()
 
-	films := make(map[int]models.FilmShortItem)
+	film.Crew = map[string][]models.CrewItem{}
 	for rows.Next() {
-		var filmID int
-		var filmTitle string
-		var filmDescription string
-		var filmRating float64
-		var filmReleaseDate string
-		var actorID int
-		var actorName string
-		var actorGender string
-		var actorBirthDate string
-
-		err := rows.Scan(&filmID, &filmTitle, &filmDescription, &filmRating, &filmReleaseDate, &actorID, &actorName, &actorGender, &actorBirthDate)
+		var member models.CrewItem
+		var role string
+		err := rows.Scan(&member.Id, &member.Name, &member.Gender, &member.BirthDate, &member.Version, &role, &member.CharacterName, &member.BillingOrder)
 		if err != nil {
-			return response, err
+			return models.FilmItem{}, err
 		}
+		film.Crew[role] = append(film.Crew[role], member)
+	}
 
-		film, ok := films[filmID]
-		if !ok {
-			film = models.FilmShortItem{
-				Id:          filmID,
-				Title:       filmTitle,
-				Description: filmDescription,
-				Rating:      filmRating,
-				ReleaseDate: filmReleaseDate,
-			}
+	return film, rows.Err()
+}
+
+func (repository *FilmRepository) AddFilm(ctx context.Context, title string, description string, rating float64, releaseDate string, crew []models.CrewMember, metadata models.FilmMetadata) (int64, error) {
+	var filmId int64
+	err := repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		filmQuery := `
+    INSERT INTO film (name, description, rating, releaseDate, runtime, age_rating, original_language)
+    VALUES ($1, $2, $3 ,$4, NULLIF($5, 0), $6, $7) RETURNING id`
+		err := tx.QueryRowContext(ctx, filmQuery, title, description, rating, releaseDate,
+			metadata.Runtime, metadata.AgeRating, metadata.OriginalLanguage).Scan(&filmId)
+		if err != nil {
+			return err
 		}
 
-		actor := models.ActorShortItem{
-			Id:        actorID,
-			Name:      actorName,
-			Gender:    actorGender,
-			BirthDate: actorBirthDate,
+		err = replaceFilmGenres(ctx, tx, filmId, metadata.Genres)
+		if err != nil {
+			return err
 		}
 
-		film.Crew = append(film.Crew, actor)
-		films[filmID] = film
-	}
+		err = replaceFilmTags(ctx, tx, filmId, metadata.Tags)
+		if err != nil {
+			return err
+		}
 
-	for _, film := range films {
-		response.Films = append(response.Films, film)
-	}
+		err = replaceFilmCountries(ctx, tx, filmId, metadata.Countries)
+		if err != nil {
+			return err
+		}
 
-	return response, nil
+		for _, member := range crew {
+			err := linkFilmActor(ctx, tx, filmId, member)
+			if err != nil {
+				return err
+			}
+		}
+
+		after, err := readFilmState(ctx, tx, filmId)
+		if err != nil {
+			return err
+		}
+
+		err = saveFilmRevision(ctx, tx, filmId, after)
+		if err != nil {
+			return err
+		}
+		return auditFilm(ctx, tx, variables.AuditActionCreate, filmId, nil, after)
+	})
+	return filmId, err
 }
 
-func (repository *FilmRepository) AddFilm(title string, description string, rating float64, releaseDate string, crew []int64) error {
-	filmQuery := `INSERT INTO film (name, description, rating, releaseDate) VALUES ($1, $2, $3 ,$4) RETURNING id`
-	var filmId int
-	err := repository.db.QueryRow(filmQuery, title, description, rating, releaseDate).Scan(&filmId)
-	if err != nil {
-		return err
-	}
+// EditFilm applies a partial update, the crew, genres, tags and countries
+// are replaced only when the patch has them.
+func (repository *FilmRepository) EditFilm(ctx context.Context, id int64, version int64, patch models.FilmPatch) (int64, error) {
+	var updated int64
+	err := repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		before, err := readFilmState(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before.trashed {
+			return variables.ErrNotFound
+		}
 
-	for _, actorId := range crew {
-		_, err := repository.db.Exec(`INSERT INTO film_actor (film_id, actor_id) VALUES ($1, $2)`, filmId, actorId)
+		var update assignments
+		if patch.Title != nil {
+			update.add("name = $%d", *patch.Title)
+		}
+		if patch.Description != nil {
+			update.add("description = $%d", *patch.Description)
+		}
+		if patch.Rating != nil {
+			update.add("rating = $%d", *patch.Rating)
+		}
+		if patch.ReleaseDate != nil {
+			update.add("releaseDate = NULLIF($%d, '')::date", *patch.ReleaseDate)
+		}
+		if patch.Runtime != nil {
+			update.add("runtime = NULLIF($%d, 0)", *patch.Runtime)
+		}
+		if patch.AgeRating != nil {
+			update.add("age_rating = $%d", *patch.AgeRating)
+		}
+		if patch.OriginalLanguage != nil {
+			update.add("original_language = $%d", *patch.OriginalLanguage)
+		}
+
+		updated, err = update.exec(ctx, tx, "film", id, version)
 		if err != nil {
 			return err
 		}
-	}
 
-	return nil
-}
+		if patch.Crew != nil {
+			// Links to trashed actors are kept for a restore
+			_, err = tx.ExecContext(ctx, `
+    DELETE FROM film_actor
+    WHERE film_id = $1 AND actor_id IN (SELECT id FROM actor WHERE deleted_at IS NULL)`, id)
+			if err != nil {
+				return err
+			}
 
-func (repository *FilmRepository) EditFilm(id int64, title string, description string, rating float64, releaseDate string, crew []int64) error {
-	_, err := repository.db.Exec(`
-    UPDATE film
-    SET name = COALESCE($1, name),
-        description = COALESCE($2, description),
-        releaseDate = COALESCE($3, releaseDate)
-    WHERE id = $4`,
-		title, description, releaseDate, id)
-	if err != nil {
-		return err
-	}
+			// A crew read back from a revision still lists the kept links,
+			// they are left as they are
+			kept, err := readTrashedCrew(ctx, tx, id)
+			if err != nil {
+				return err
+			}
 
-	_, err = repository.db.Exec(`DELETE FROM film_actor WHERE film_id = $1`, id)
-	if err != nil {
-		return err
-	}
+			for _, member := range *patch.Crew {
+				if kept[crewLink{actorId: member.ActorId, role: member.Role}] {
+					continue
+				}
+				err := linkFilmActor(ctx, tx, id, member)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if patch.Genres != nil {
+			err = replaceFilmGenres(ctx, tx, id, *patch.Genres)
+			if err != nil {
+				return err
+			}
+		}
 
-	for _, actorId := range crew {
-		_, err := repository.db.Exec(`INSERT INTO film_actor (film_id, actor_id) VALUES ($1, $2)`, id, actorId)
+		if patch.Tags != nil {
+			err = replaceFilmTags(ctx, tx, id, *patch.Tags)
+			if err != nil {
+				return err
+			}
+		}
+
+		if patch.Countries != nil {
+			err = replaceFilmCountries(ctx, tx, id, *patch.Countries)
+			if err != nil {
+				return err
+			}
+		}
+
+		after, err := readFilmState(ctx, tx, id)
 		if err != nil {
 			return err
 		}
-	}
+		if reflect.DeepEqual(before, after) {
+			return nil
+		}
 
-	return nil
-}
+		updated, err = bumpVersion(ctx, tx, "film", id)
+		if err != nil {
+			return err
+		}
 
-func (repository *FilmRepository) GetActors(begin uint64, end uint64) (communication.ActorsListResponse, error) {
-	actorsMap := make(map[int]models.ActorItem)
-	filmsMap := make(map[int][]models.FilmShortItem)
+		err = saveFilmRevision(ctx, tx, id, after)
+		if err != nil {
+			return err
+		}
+		return auditFilm(ctx, tx, variables.AuditActionUpdate, id, before, after)
+	})
 
-	rows, err := repository.db.Query(`
-        SELECT actor.id, actor.name, actor.gender, actor.birthdate,
+	return updated, err
+}
+
+// GetActors pages over the live actors credited in live films and joins
+// their films, so a page always holds whole actors
+func (repository *FilmRepository) GetActors(ctx context.Context, offset uint64, limit uint64) (communication.ActorsListResponse, error) {
+	rows, err := repository.db.QueryContext(ctx, `
+        WITH page AS (
+            SELECT actor.* FROM actor
+            WHERE actor.deleted_at IS NULL AND EXISTS (
+                SELECT 1 FROM film_actor
+                JOIN film ON film.id = film_actor.film_id AND film.deleted_at IS NULL
+                WHERE film_actor.actor_id = actor.id)
+            ORDER BY actor.id
+            LIMIT $1 OFFSET $2
+        )
+        SELECT DISTINCT actor.id, actor.name, actor.gender, actor.birthdate, actor.version,
                film.id, film.name, film.description, film.rating, film.releaseDate
-        FROM actor
+        FROM page actor
         JOIN film_actor ON film_actor.actor_id = actor.id
         JOIN film ON film_actor.film_id = film.id
-        ORDER BY actor.id;
-    `)
+        WHERE film.deleted_at IS NULL
+        ORDER BY actor.id, film.id;
+    `, limit, offset)
 	if err != nil {
 		return communication.ActorsListResponse{}, err
 	}
 	defer rows.Close()
 
+	var actorsList []models.ActorItem
 	for rows.Next() {
 		var actor models.ActorItem
 		var film models.FilmShortItem
 
-		err := rows.Scan(&actor.Id, &actor.Name, &actor.Gender, &actor.BirthDate, &film.Id, &film.Title, &film.Description, &film.Rating, &film.ReleaseDate)
+		err := rows.Scan(&actor.Id, &actor.Name, &actor.Gender, &actor.BirthDate, &actor.Version, &film.Id, &film.Title, &film.Description, &film.Rating, &film.ReleaseDate)
 		if err != nil {
 			return communication.ActorsListResponse{}, err
 		}
 
-		actorsMap[actor.Id] = actor
-
-		filmsMap[actor.Id] = append(filmsMap[actor.Id], film)
-	}
-
-	for id, actor := range actorsMap {
-		actor.Films = filmsMap[id]
-		actorsMap[id] = actor
-	}
-
-	var actorsList []models.ActorItem
-	for _, actor := range actorsMap {
-		actorsList = append(actorsList, actor)
+		// Rows come ordered by actor, so an actor's rows are adjacent
+		if len(actorsList) == 0 || actorsList[len(actorsList)-1].Id != actor.Id {
+			actorsList = append(actorsList, actor)
+		}
+		last := &actorsList[len(actorsList)-1]
+		last.Films = append(last.Films, film)
 	}
 
 	err = rows.Err()
@@ -292,68 +383,305 @@ func (repository *FilmRepository) GetActors(begin uint64, end uint64) (communica
 	return communication.ActorsListResponse{Actors: actorsList}, nil
 }
 
-func (repository *FilmRepository) AddActor(name string, gender string, birthdate string) error {
-	actorQuery := `INSERT INTO actor (name, gender, birthdate) VALUES ($1, $2, $3)`
-	_, err := repository.db.Exec(actorQuery, name, gender, birthdate)
+func (repository *FilmRepository) GetActor(ctx context.Context, id int64) (models.ActorItem, error) {
+	var actor models.ActorItem
+	err := repository.db.QueryRowContext(ctx, `
+    SELECT id, name, COALESCE(gender, ''), COALESCE(birthdate::text, ''), version
+    FROM actor WHERE id = $1 AND deleted_at IS NULL`, id).
+		Scan(&actor.Id, &actor.Name, &actor.Gender, &actor.BirthDate, &actor.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ActorItem{}, variables.ErrNotFound
+	}
 	if err != nil {
-		return err
+		return models.ActorItem{}, err
 	}
 
-	return nil
+	rows, err := repository.db.QueryContext(ctx, `
+    SELECT DISTINCT film.id, film.name, COALESCE(film.description, ''), COALESCE(film.rating, 0), COALESCE(film.releaseDate::text, '')
+    FROM film
+    JOIN film_actor ON film_actor.film_id = film.id
+    WHERE film_actor.actor_id = $1 AND film.deleted_at IS NULL
+    ORDER BY film.id`, id)
+	if err != nil {
+		return models.ActorItem{}, err
+	}
+	defer rows.Close()
+
+	actor.Films = []models.FilmShortItem{}
+	for rows.Next() {
+		var film models.FilmShortItem
+		err := rows.Scan(&film.Id, &film.Title, &film.Description, &film.Rating, &film.ReleaseDate)
+		if err != nil {
+			return models.ActorItem{}, err
+		}
+		actor.Films = append(actor.Films, film)
+	}
+
+	return actor, rows.Err()
 }
 
-func (repository *FilmRepository) EditActor(id int64, name string, gender string, birthdate string, films []int64) error {
-	_, err := repository.db.Exec(`
-    UPDATE actor
-    SET name = COALESCE($1, name),
-        gender = COALESCE($2, gender),
-        birthdate = COALESCE($3, birthdate)
-    WHERE id = $4`,
-		name, gender, birthdate, id)
-	if err != nil {
-		return err
+func (repository *FilmRepository) AddActor(ctx context.Context, name string, gender string, birthdate string) (int64, error) {
+	var actorId int64
+	err := repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		actorQuery := `INSERT INTO actor (name, gender, birthdate) VALUES ($1, $2, $3) RETURNING id`
+		err := tx.QueryRowContext(ctx, actorQuery, name, gender, birthdate).Scan(&actorId)
+		if err != nil {
+			return err
+		}
+
+		after, err := readActorState(ctx, tx, actorId)
+		if err != nil {
+			return err
+		}
+		return auditActor(ctx, tx, variables.AuditActionCreate, actorId, nil, after)
+	})
+	return actorId, err
+}
+
+// EditActor applies a partial update, the films are replaced only when the
+// patch has them.
+func (repository *FilmRepository) EditActor(ctx context.Context, id int64, version int64, patch models.ActorPatch) (int64, error) {
+	var updated int64
+	err := repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		before, err := readActorState(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before.trashed {
+			return variables.ErrNotFound
+		}
+
+		var update assignments
+		if patch.Name != nil {
+			update.add("name = $%d", *patch.Name)
+		}
+		if patch.Gender != nil {
+			update.add("gender = $%d", *patch.Gender)
+		}
+		if patch.BirthDate != nil {
+			update.add("birthdate = NULLIF($%d, '')::date", *patch.BirthDate)
+		}
+
+		updated, err = update.exec(ctx, tx, "actor", id, version)
+		if err != nil {
+			return err
+		}
+
+		if patch.Films != nil {
+			err = replaceActingCredits(ctx, tx, id, *patch.Films)
+			if err != nil {
+				return err
+			}
+		}
+
+		after, err := readActorState(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if reflect.DeepEqual(before, after) {
+			return nil
+		}
+
+		updated, err = bumpVersion(ctx, tx, "actor", id)
+		if err != nil {
+			return err
+		}
+		return auditActor(ctx, tx, variables.AuditActionUpdate, id, before, after)
+	})
+
+	return updated, err
+}
+
+// DeleteActor moves the actor to the trash. Crew links stay, so a restore
+// brings them back.
+func (repository *FilmRepository) DeleteActor(ctx context.Context, id int64, version int64) error {
+	return repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		before, err := readActorState(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before.trashed {
+			return variables.ErrNotFound
+		}
+
+		result, err := tx.ExecContext(ctx, `
+    UPDATE actor SET deleted_at = now(), version = version + 1
+    WHERE id = $1 AND deleted_at IS NULL AND ($2::bigint = 0 OR version = $2)`, id, version)
+		if err != nil {
+			return err
+		}
+
+		err = expectVersion(result)
+		if err != nil {
+			return err
+		}
+		return auditActor(ctx, tx, variables.AuditActionDelete, id, before, nil)
+	})
+}
+
+func (repository *FilmRepository) DeleteFilm(ctx context.Context, id int64, version int64) error {
+	return repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		before, err := readFilmState(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before.trashed {
+			return variables.ErrNotFound
+		}
+
+		result, err := tx.ExecContext(ctx, `
+    UPDATE film SET deleted_at = now(), version = version + 1
+    WHERE id = $1 AND deleted_at IS NULL AND ($2::bigint = 0 OR version = $2)`, id, version)
+		if err != nil {
+			return err
+		}
+
+		err = expectVersion(result)
+		if err != nil {
+			return err
+		}
+		return auditFilm(ctx, tx, variables.AuditActionDelete, id, before, nil)
+	})
+}
+
+// assignments collects the SET list of a partial update
+type assignments struct {
+	columns []string
+	args    []any
+}
+
+func (update *assignments) add(assignment string, value any) {
+	update.args = append(update.args, value)
+	update.columns = append(update.columns, fmt.Sprintf(assignment, len(update.args)))
+}
+
+// exec updates a live row when it still has the expected version, a zero
+// version matches any, and returns the version. An empty patch still checks
+// it, the version is bumped by bumpVersion once the edit is known to change
+// anything.
+func (update *assignments) exec(ctx context.Context, tx *sql.Tx, table string, id int64, version int64) (int64, error) {
+	if len(update.columns) == 0 {
+		update.columns = append(update.columns, "version = version")
+	}
+	update.args = append(update.args, id, version)
+	query := fmt.Sprintf(`UPDATE %s SET %s WHERE id = $%d AND deleted_at IS NULL AND ($%d::bigint = 0 OR version = $%d) RETURNING version`,
+		table, strings.Join(update.columns, ", "), len(update.args)-1, len(update.args), len(update.args))
+
+	var current int64
+	err := tx.QueryRowContext(ctx, query, update.args...).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, variables.ErrVersionMismatch
 	}
+	return current, err
+}
 
-	_, err = repository.db.Exec(`DELETE FROM film_actor WHERE actor_id = $1`, id)
+// bumpVersion gives a row changed in the transaction its next version
+func bumpVersion(ctx context.Context, tx *sql.Tx, table string, id int64) (int64, error) {
+	var updated int64
+	err := tx.QueryRowContext(ctx, fmt.Sprintf(`UPDATE %s SET version = version + 1 WHERE id = $1 RETURNING version`, table), id).
+		Scan(&updated)
+	return updated, err
+}
+
+// replaceActingCredits sets the live films an actor plays in. Kept credits
+// hold on to their character and billing, other roles are left alone and
+// links to trashed films are kept for a restore.
+func replaceActingCredits(ctx context.Context, tx *sql.Tx, id int64, films []int64) error {
+	current, err := readLinks(ctx, tx, `
+    SELECT film_id FROM film_actor
+    WHERE actor_id = $1 AND role = $2 AND film_id IN (SELECT id FROM film WHERE deleted_at IS NULL)`,
+		id, variables.CrewRoleActor)
 	if err != nil {
 		return err
 	}
 
+	wanted := make(map[int64]bool, len(films))
 	for _, filmId := range films {
-		_, err := repository.db.Exec(`INSERT INTO film_actor (film_id, actor_id) VALUES ($1, $2)`, filmId, id)
+		wanted[filmId] = true
+	}
+
+	for _, filmId := range current {
+		if wanted[filmId] {
+			delete(wanted, filmId)
+			continue
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM film_actor WHERE film_id = $1 AND actor_id = $2 AND role = $3`,
+			filmId, id, variables.CrewRoleActor)
 		if err != nil {
 			return err
 		}
 	}
 
+	for _, filmId := range films {
+		if !wanted[filmId] {
+			continue
+		}
+		delete(wanted, filmId)
+		err := linkFilmActor(ctx, tx, filmId, models.CrewMember{ActorId: id, Role: variables.CrewRoleActor})
+		if err != nil {
+			return err
+		}
+	}
 	return nil
+}
 
+// crewLink is what tells the links of a film apart
+type crewLink struct {
+	actorId int64
+	role    string
 }
 
-func (repository *FilmRepository) DeleteActor(id int64) error {
-	_, err := repository.db.Exec(`DELETE FROM film_actor WHERE actor_id = $1`, id)
+// readTrashedCrew lists the links of a film to trashed actors
+func readTrashedCrew(ctx context.Context, tx *sql.Tx, filmId int64) (map[crewLink]bool, error) {
+	rows, err := tx.QueryContext(ctx, `
+    SELECT actor_id, role FROM film_actor
+    WHERE film_id = $1 AND actor_id IN (SELECT id FROM actor WHERE deleted_at IS NOT NULL)`, filmId)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	_, err = repository.db.Exec(`DELETE FROM actor WHERE id = $1`, id)
-	if err != nil {
-		return err
+	links := make(map[crewLink]bool)
+	for rows.Next() {
+		var link crewLink
+		err := rows.Scan(&link.actorId, &link.role)
+		if err != nil {
+			return nil, err
+		}
+		links[link] = true
 	}
-
-	return nil
+	return links, rows.Err()
 }
 
-func (repository *FilmRepository) DeleteFilm(id int64) error {
-	_, err := repository.db.Exec(`DELETE FROM film_actor WHERE film_id = $1`, id)
+func linkFilmActor(ctx context.Context, tx *sql.Tx, filmId int64, member models.CrewMember) error {
+	result, err := tx.ExecContext(ctx, `
+    INSERT INTO film_actor (film_id, actor_id, role, character_name, billing_order)
+    SELECT film.id, actor.id, $3, $4, $5 FROM film, actor
+    WHERE film.id = $1 AND actor.id = $2 AND film.deleted_at IS NULL AND actor.deleted_at IS NULL`,
+		filmId, member.ActorId, member.Role, member.CharacterName, member.BillingOrder)
 	if err != nil {
 		return err
 	}
 
-	_, err = repository.db.Exec(`DELETE FROM film WHERE id = $1`, id)
-	if err != nil {
-		return err
+	err = expectAffected(result)
+	if errors.Is(err, variables.ErrNotFound) {
+		return variables.ErrReferenceNotFound
 	}
+	return err
+}
 
+func (repository *FilmRepository) Close() error {
+	err := repository.db.Close()
+	if err != nil {
+		return fmt.Errorf("%s %w", variables.SqlCloseError, err)
+	}
 	return nil
 }
+
+func (repository *FilmRepository) Name() string {
+	return variables.PostgresHealthChecker
+}
+
+func (repository *FilmRepository) CheckHealth(ctx context.Context) error {
+	return repository.db.PingContext(ctx)
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"filmoteka/pkg/models"
+	communication "filmoteka/pkg/requests"
+	"filmoteka/pkg/variables"
+	"fmt"
+)
+
+// stringList scans the JSON arrays built by filmMetadataColumns
+type stringList []string
+
+func (list *stringList) Scan(src any) error {
+	var data []byte
+	switch value := src.(type) {
+	case []byte:
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		return fmt.Errorf("stringList: unsupported type %T", src)
+	}
+	*list = stringList{}
+	return json.Unmarshal(data, (*[]string)(list))
+}
+
+// filmMetadataColumns selects the metadata of the film row with the given
+// alias, in the order filmMetadataTargets scans it. Labels come as sorted
+// JSON arrays so films don't multiply in joins.
+func filmMetadataColumns(alias string) string {
+	return fmt.Sprintf(`
+           (SELECT COALESCE(jsonb_agg(genre.slug ORDER BY genre.slug), '[]')
+            FROM film_genre JOIN genre ON genre.id = film_genre.genre_id WHERE film_genre.film_id = %[1]s.id),
+           (SELECT COALESCE(jsonb_agg(film_tag.tag ORDER BY film_tag.tag), '[]') FROM film_tag WHERE film_tag.film_id = %[1]s.id),
+           (SELECT COALESCE(jsonb_agg(film_country.country ORDER BY film_country.country), '[]')
+            FROM film_country WHERE film_country.film_id = %[1]s.id),
+           COALESCE(%[1]s.runtime, 0), %[1]s.age_rating, %[1]s.original_language`, alias)
+}
+
+func filmMetadataTargets(metadata *models.FilmMetadata) []any {
+	return []any{
+		(*stringList)(&metadata.Genres),
+		(*stringList)(&metadata.Tags),
+		(*stringList)(&metadata.Countries),
+		&metadata.Runtime,
+		&metadata.AgeRating,
+		&metadata.OriginalLanguage,
+	}
+}
+
+// replaceFilmGenres links the film to the genres with the given slugs
+func replaceFilmGenres(ctx context.Context, tx *sql.Tx, id int64, genres []string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM film_genre WHERE film_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	for _, slug := range genres {
+		result, err := tx.ExecContext(ctx, `
+    INSERT INTO film_genre (film_id, genre_id)
+    SELECT $1, id FROM genre WHERE slug = $2`, id, slug)
+		if err != nil {
+			return err
+		}
+
+		err = expectAffected(result)
+		if errors.Is(err, variables.ErrNotFound) {
+			return variables.ErrReferenceNotFound
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func replaceFilmTags(ctx context.Context, tx *sql.Tx, id int64, tags []string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM film_tag WHERE film_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		_, err := tx.ExecContext(ctx, `INSERT INTO film_tag (film_id, tag) VALUES ($1, $2)`, id, tag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func replaceFilmCountries(ctx context.Context, tx *sql.Tx, id int64, countries []string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM film_country WHERE film_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	for _, country := range countries {
+		_, err := tx.ExecContext(ctx, `INSERT INTO film_country (film_id, country) VALUES ($1, $2)`, id, country)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repository *FilmRepository) GetGenres(ctx context.Context) (communication.GenresListResponse, error) {
+	rows, err := repository.db.QueryContext(ctx, `SELECT id, slug, name FROM genre ORDER BY slug`)
+	if err != nil {
+		return communication.GenresListResponse{}, err
+	}
+	defer rows.Close()
+
+	genres := []models.Genre{}
+	for rows.Next() {
+		var genre models.Genre
+		err := rows.Scan(&genre.Id, &genre.Slug, &genre.Name)
+		if err != nil {
+			return communication.GenresListResponse{}, err
+		}
+		genres = append(genres, genre)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return communication.GenresListResponse{}, err
+	}
+
+	return communication.GenresListResponse{Genres: genres}, nil
+}
+
+func (repository *FilmRepository) AddGenre(ctx context.Context, slug string, name string) (int64, error) {
+	var id int64
+	err := repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `INSERT INTO genre (slug, name) VALUES ($1, $2) RETURNING id`, slug, name).Scan(&id)
+		if err != nil {
+			return err
+		}
+		return auditGenre(ctx, tx, variables.AuditActionCreate, id, nil, &genreState{Slug: slug, Name: name})
+	})
+	return id, err
+}
+
+// EditGenre renames a genre, films follow it as they link by id
+func (repository *FilmRepository) EditGenre(ctx context.Context, id int64, slug string, name string) error {
+	return repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		before, err := readGenreState(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE genre SET slug = $2, name = $3 WHERE id = $1`, id, slug, name)
+		if err != nil {
+			return err
+		}
+
+		after := &genreState{Slug: slug, Name: name}
+		if *before == *after {
+			return nil
+		}
+		return auditGenre(ctx, tx, variables.AuditActionUpdate, id, before, after)
+	})
+}
+
+// DeleteGenre removes a genre no film is linked to, trashed ones included
+func (repository *FilmRepository) DeleteGenre(ctx context.Context, id int64) error {
+	return repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		before, err := readGenreState(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		var used bool
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM film_genre WHERE genre_id = $1)`, id).Scan(&used)
+		if err != nil {
+			return err
+		}
+		if used {
+			return variables.ErrStillReferenced
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM genre WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+		return auditGenre(ctx, tx, variables.AuditActionDelete, id, before, nil)
+	})
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"filmoteka/pkg/models"
+	communication "filmoteka/pkg/requests"
+	"filmoteka/pkg/variables"
+)
+
+// saveFilmRevision stores the state a film was left in by an add or edit.
+// The film row is locked by readFilmState, so revision numbers can't race.
+func saveFilmRevision(ctx context.Context, tx *sql.Tx, id int64, state *filmState) error {
+	crew, err := json.Marshal(state.Crew)
+	if err != nil {
+		return err
+	}
+
+	labels := make([]string, 0, 3)
+	for _, list := range [][]string{state.Genres, state.Tags, state.Countries} {
+		encoded, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		labels = append(labels, string(encoded))
+	}
+
+	authorId, _ := ctx.Value(variables.UserIDKey).(int64)
+	_, err = tx.ExecContext(ctx, `
+    INSERT INTO film_revision (film_id, revision, name, description, rating, releaseDate, crew, author_id,
+                               genres, tags, countries, runtime, age_rating, original_language)
+    SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, NULLIF($5, '')::date, $6::jsonb, NULLIF($7::bigint, 0),
+           $8::jsonb, $9::jsonb, $10::jsonb, NULLIF($11, 0), $12, $13
+    FROM film_revision WHERE film_id = $1`,
+		id, state.Title, state.Description, state.Rating, state.ReleaseDate, string(crew), authorId,
+		labels[0], labels[1], labels[2], state.Runtime, state.AgeRating, state.OriginalLanguage)
+	return err
+}
+
+func (repository *FilmRepository) GetFilmRevisions(ctx context.Context, id int64, offset uint64, limit uint64) (communication.FilmRevisionsResponse, error) {
+	var exists bool
+	err := repository.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM film WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
+	if err != nil {
+		return communication.FilmRevisionsResponse{}, err
+	}
+	if !exists {
+		return communication.FilmRevisionsResponse{}, variables.ErrNotFound
+	}
+
+	rows, err := repository.db.QueryContext(ctx, `
+    SELECT revision, name, description, rating, COALESCE(releaseDate::text, ''), crew, author_id, created_at,
+           genres, tags, countries, COALESCE(runtime, 0), age_rating, original_language
+    FROM film_revision
+    WHERE film_id = $1
+    ORDER BY revision DESC
+    LIMIT $2 OFFSET $3`,
+		id, limit, offset)
+	if err != nil {
+		return communication.FilmRevisionsResponse{}, err
+	}
+	defer rows.Close()
+
+	revisions := []models.FilmRevision{}
+	for rows.Next() {
+		revision, err := scanFilmRevision(rows)
+		if err != nil {
+			return communication.FilmRevisionsResponse{}, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return communication.FilmRevisionsResponse{}, err
+	}
+
+	return communication.FilmRevisionsResponse{Revisions: revisions}, nil
+}
+
+func (repository *FilmRepository) GetFilmRevision(ctx context.Context, id int64, revision int64) (models.FilmRevision, error) {
+	row := repository.db.QueryRowContext(ctx, `
+    SELECT film_revision.revision, film_revision.name, film_revision.description, film_revision.rating,
+           COALESCE(film_revision.releaseDate::text, ''), film_revision.crew, film_revision.author_id, film_revision.created_at,
+           film_revision.genres, film_revision.tags, film_revision.countries, COALESCE(film_revision.runtime, 0),
+           film_revision.age_rating, film_revision.original_language
+    FROM film_revision
+    JOIN film ON film.id = film_revision.film_id
+    WHERE film_revision.film_id = $1 AND film_revision.revision = $2 AND film.deleted_at IS NULL`,
+		id, revision)
+
+	filmRevision, err := scanFilmRevision(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.FilmRevision{}, variables.ErrNotFound
+	}
+	return filmRevision, err
+}
+
+func scanFilmRevision(row interface{ Scan(dest ...any) error }) (models.FilmRevision, error) {
+	var revision models.FilmRevision
+	var crew []byte
+	var authorId sql.NullInt64
+
+	err := row.Scan(append([]any{&revision.Revision, &revision.Title, &revision.Description, &revision.Rating, &revision.ReleaseDate, &crew, &authorId, &revision.CreatedAt},
+		filmMetadataTargets(&revision.FilmMetadata)...)...)
+	if err != nil {
+		return models.FilmRevision{}, err
+	}
+
+	if authorId.Valid {
+		revision.AuthorId = &authorId.Int64
+	}
+	err = json.Unmarshal(crew, &revision.Crew)
+	return revision, err
+}
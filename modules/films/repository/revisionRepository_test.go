@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/variables"
+	"testing"
+)
+
+func TestEditFilmRecordsRevisions(t *testing.T) {
+	repository := getTestRepository(t)
+	ctx := context.WithValue(context.Background(), variables.UserIDKey, int64(5))
+	actorId := addTestActor(t, repository, "Revision probe actor")
+	filmId := addTestFilm(t, repository, "Revision probe", 6, "2001-01-01", actorId)
+
+	rating := 8.0
+	title := "Revision probe, director's cut"
+	_, err := repository.EditFilm(ctx, filmId, 0, models.FilmPatch{Rating: &rating, Title: &title})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := repository.GetFilmRevisions(ctx, filmId, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions.Revisions) != 2 {
+		t.Fatalf("revisions = %+v, want the add and the edit", revisions.Revisions)
+	}
+
+	edited, added := revisions.Revisions[0], revisions.Revisions[1]
+	if added.Revision != 1 || added.Rating != 6 || added.Title != "Revision probe" || added.AuthorId != nil {
+		t.Errorf("first revision = %+v, want the film as added without an author", added)
+	}
+	if edited.Revision != 2 || edited.Rating != 8 || edited.Title != title || edited.AuthorId == nil || *edited.AuthorId != 5 {
+		t.Errorf("second revision = %+v, want the edit by user 5", edited)
+	}
+	if len(edited.Crew) != 1 || edited.Crew[0].ActorId != actorId {
+		t.Errorf("second revision crew = %+v, want the unchanged crew", edited.Crew)
+	}
+
+	first, err := repository.GetFilmRevision(ctx, filmId, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Rating != 6 {
+		t.Errorf("revision 1 rating = %v, want 6", first.Rating)
+	}
+	if _, err := repository.GetFilmRevision(ctx, filmId, 3); !errors.Is(err, variables.ErrNotFound) {
+		t.Errorf("missing revision = %v, want %v", err, variables.ErrNotFound)
+	}
+}
+
+func TestNoOpEditKeepsVersion(t *testing.T) {
+	repository := getTestRepository(t)
+	ctx := context.Background()
+	actorId := addTestActor(t, repository, "Version probe actor")
+	filmId := addTestFilm(t, repository, "Version probe", 6, "2001-01-01", actorId)
+
+	rating := 6.0
+	crew := []models.CrewMember{{ActorId: actorId, Role: variables.CrewRoleActor}}
+	version, err := repository.EditFilm(ctx, filmId, 1, models.FilmPatch{Rating: &rating, Crew: &crew})
+	if err != nil || version != 1 {
+		t.Fatalf("resent film version = %d, %v, want 1", version, err)
+	}
+	version, err = repository.EditFilm(ctx, filmId, 1, models.FilmPatch{})
+	if err != nil || version != 1 {
+		t.Fatalf("empty film patch version = %d, %v, want 1", version, err)
+	}
+
+	rating = 7
+	version, err = repository.EditFilm(ctx, filmId, 1, models.FilmPatch{Rating: &rating})
+	if err != nil || version != 2 {
+		t.Fatalf("edited film version = %d, %v, want 2", version, err)
+	}
+	revisions, err := repository.GetFilmRevisions(ctx, filmId, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions.Revisions) != 2 {
+		t.Errorf("revisions = %+v, want the add and the one real edit", revisions.Revisions)
+	}
+
+	gender := "female"
+	version, err = repository.EditActor(ctx, actorId, 1, models.ActorPatch{Gender: &gender, Films: &[]int64{filmId}})
+	if err != nil || version != 1 {
+		t.Fatalf("resent actor version = %d, %v, want 1", version, err)
+	}
+	if _, err := repository.EditFilm(ctx, filmId, 1, models.FilmPatch{}); !errors.Is(err, variables.ErrVersionMismatch) {
+		t.Errorf("stale film version = %v, want %v", err, variables.ErrVersionMismatch)
+	}
+}
+
+func TestRevertedCrewKeepsTrashedActors(t *testing.T) {
+	repository := getTestRepository(t)
+	ctx := context.Background()
+	trashedId := addTestActor(t, repository, "Trashed crew probe")
+	liveId := addTestActor(t, repository, "Live crew probe")
+	filmId := addTestFilm(t, repository, "Crew revert probe", 6, "2001-01-01", trashedId, liveId)
+
+	rating := 8.0
+	_, err := repository.EditFilm(ctx, filmId, 0, models.FilmPatch{Rating: &rating})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repository.DeleteActor(ctx, trashedId, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// RevertFilm replays the whole revision as an edit
+	for _, revision := range []int64{2, 1} {
+		snapshot, err := repository.GetFilmRevision(ctx, filmId, revision)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = repository.EditFilm(ctx, filmId, 0, models.FilmPatch{Rating: &snapshot.Rating, Crew: &snapshot.Crew})
+		if err != nil {
+			t.Fatalf("revert to revision %d: %v", revision, err)
+		}
+	}
+
+	reverted, err := repository.GetFilmRevisions(ctx, filmId, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crew := reverted.Revisions[0].Crew; len(crew) != 2 || crew[0].ActorId != trashedId || crew[1].ActorId != liveId {
+		t.Errorf("reverted crew = %+v, want the trashed and the live actor", crew)
+	}
+
+	_, err = repository.EditFilm(ctx, filmId, 0, models.FilmPatch{Crew: &[]models.CrewMember{{ActorId: trashedId, Role: variables.CrewRoleDirector}}})
+	if !errors.Is(err, variables.ErrReferenceNotFound) {
+		t.Errorf("new link to a trashed actor = %v, want %v", err, variables.ErrReferenceNotFound)
+	}
+}
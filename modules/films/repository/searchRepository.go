@@ -0,0 +1,436 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/pkg/models"
+	communication "filmoteka/pkg/requests"
+	"filmoteka/pkg/tracing"
+	"filmoteka/pkg/variables"
+	"strconv"
+	"strings"
+)
+
+// filmHits matches films by their own text or by the names of their live
+// crew, misspelt names still match by trigrams. It takes the text search
+// config, the query text and the crew and fuzzy weights as $1 to $4; the
+// query takes web search syntax, so it can't fail to parse.
+const filmHits = `
+    WITH search AS (SELECT websearch_to_tsquery($1::regconfig, $2) AS query, $2::text AS text),
+    hits AS (
+        SELECT f.id AS film_id, ts_rank(f.search_vector, search.query) AS score
+        FROM search
+        JOIN film f ON f.search_vector @@ search.query
+        WHERE f.deleted_at IS NULL
+        UNION ALL
+        SELECT fa.film_id, ts_rank(a.search_vector, search.query) * $3::real
+        FROM search
+        JOIN actor a ON a.search_vector @@ search.query AND a.deleted_at IS NULL
+        JOIN film_actor fa ON fa.actor_id = a.id
+        JOIN film f ON f.id = fa.film_id AND f.deleted_at IS NULL
+        UNION ALL
+        SELECT f.id, word_similarity(search.text, f.name) * $4::real
+        FROM search
+        JOIN film f ON search.text <% f.name
+        WHERE f.deleted_at IS NULL
+        UNION ALL
+        SELECT fa.film_id, word_similarity(search.text, a.name) * $4::real * $3::real
+        FROM search
+        JOIN actor a ON search.text <% a.name AND a.deleted_at IS NULL
+        JOIN film_actor fa ON fa.actor_id = a.id
+        JOIN film f ON f.id = fa.film_id AND f.deleted_at IS NULL
+    ),
+    matched AS (SELECT film_id, MAX(score) AS score FROM hits GROUP BY film_id)`
+
+// actorHits matches live actors by name, with the text search config, the
+// query text and the fuzzy weight as $1 to $3.
+const actorHits = `
+    WITH search AS (SELECT websearch_to_tsquery($1::regconfig, $2) AS query, $2::text AS text),
+    hits AS (
+        SELECT a.id AS actor_id, ts_rank(a.search_vector, search.query) AS score
+        FROM search
+        JOIN actor a ON a.search_vector @@ search.query
+        WHERE a.deleted_at IS NULL
+        UNION ALL
+        SELECT a.id, word_similarity(search.text, a.name) * $3::real
+        FROM search
+        JOIN actor a ON search.text <% a.name
+        WHERE a.deleted_at IS NULL
+    ),
+    matched AS (SELECT actor_id, MAX(score) AS score FROM hits GROUP BY actor_id)`
+
+// SqlSearchIndex searches the catalogue tables themselves. Their search
+// vectors are generated columns, so every write keeps them in sync and
+// there is nothing to index, delete or rebuild.
+type SqlSearchIndex struct {
+	db *tracing.DB
+}
+
+func GetSqlSearchIndex(films *FilmRepository) *SqlSearchIndex {
+	return &SqlSearchIndex{db: films.db}
+}
+
+func (index *SqlSearchIndex) Index(ctx context.Context, document models.SearchDocument) error {
+	return nil
+}
+
+func (index *SqlSearchIndex) Delete(ctx context.Context, kind string, id int64) error {
+	return nil
+}
+
+func (index *SqlSearchIndex) Rebuild(ctx context.Context, documents []models.SearchDocument) error {
+	return nil
+}
+
+func (index *SqlSearchIndex) Query(ctx context.Context, query models.SearchQuery) (models.SearchResult, error) {
+	switch query.Kind {
+	case variables.SearchFilmKind:
+		return index.queryFilms(ctx, query)
+	case variables.SearchActorKind:
+		return index.queryActors(ctx, query)
+	default:
+		return models.SearchResult{}, errors.New(variables.SearchKindError)
+	}
+}
+
+func (index *SqlSearchIndex) queryFilms(ctx context.Context, query models.SearchQuery) (models.SearchResult, error) {
+	hits, err := index.hits(ctx, filmHits+`
+    SELECT f.id, matched.score, ts_headline($1::regconfig, f.name || '. ' || COALESCE(f.description, ''), search.query, $7)
+    FROM matched
+    CROSS JOIN search
+    JOIN film f ON f.id = matched.film_id
+    ORDER BY matched.score DESC, f.id
+    LIMIT $5 OFFSET $6`,
+		variables.SearchTextConfig, query.Text, variables.SearchCrewRankWeight, variables.SearchFuzzyRankWeight,
+		query.Limit, query.Offset, variables.SearchHeadlineOptions)
+	if err != nil {
+		return models.SearchResult{}, err
+	}
+
+	result := models.SearchResult{Hits: hits}
+	err = index.db.QueryRowContext(ctx, filmHits+`
+    SELECT COUNT(*) FROM matched`,
+		variables.SearchTextConfig, query.Text, variables.SearchCrewRankWeight, variables.SearchFuzzyRankWeight).Scan(&result.Total)
+	if err != nil {
+		return models.SearchResult{}, err
+	}
+
+	if query.Facets {
+		result.Facets, err = index.filmFacets(ctx, query.Text)
+		if err != nil {
+			return models.SearchResult{}, err
+		}
+	}
+	return result, nil
+}
+
+func (index *SqlSearchIndex) queryActors(ctx context.Context, query models.SearchQuery) (models.SearchResult, error) {
+	hits, err := index.hits(ctx, actorHits+`
+    SELECT a.id, matched.score, ts_headline($1::regconfig, a.name, search.query, $6)
+    FROM matched
+    CROSS JOIN search
+    JOIN actor a ON a.id = matched.actor_id
+    ORDER BY matched.score DESC, a.id
+    LIMIT $4 OFFSET $5`,
+		variables.SearchTextConfig, query.Text, variables.SearchFuzzyRankWeight, query.Limit, query.Offset,
+		variables.SearchHeadlineOptions)
+	if err != nil {
+		return models.SearchResult{}, err
+	}
+
+	result := models.SearchResult{Hits: hits}
+	err = index.db.QueryRowContext(ctx, actorHits+`
+    SELECT COUNT(*) FROM matched`,
+		variables.SearchTextConfig, query.Text, variables.SearchFuzzyRankWeight).Scan(&result.Total)
+	if err != nil {
+		return models.SearchResult{}, err
+	}
+	return result, nil
+}
+
+func (index *SqlSearchIndex) hits(ctx context.Context, query string, args ...any) ([]models.SearchHit, error) {
+	rows, err := index.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []models.SearchHit{}
+	for rows.Next() {
+		var hit models.SearchHit
+		err := rows.Scan(&hit.Id, &hit.Score, &hit.Snippet)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// filmFacets counts the films matching the query by genre, release decade
+// and rating bucket. Genres go by count, the rest in their order.
+func (index *SqlSearchIndex) filmFacets(ctx context.Context, query string) (models.SearchFacets, error) {
+	rows, err := index.db.QueryContext(ctx, filmHits+`
+    SELECT $5::text AS facet, genre.slug AS value, COUNT(*) AS count, 0 AS position
+    FROM matched
+    JOIN film_genre ON film_genre.film_id = matched.film_id
+    JOIN genre ON genre.id = film_genre.genre_id
+    GROUP BY genre.slug
+    UNION ALL
+    SELECT $6::text, decade::text || 's', COUNT(*), decade
+    FROM matched
+    JOIN film f ON f.id = matched.film_id
+    CROSS JOIN LATERAL (SELECT (EXTRACT(DECADE FROM f.releaseDate) * 10)::int AS decade) decades
+    WHERE f.releaseDate IS NOT NULL
+    GROUP BY decade
+    UNION ALL
+    SELECT $7::text, bucket::text || '-' || (bucket + $8::int)::text, COUNT(*), bucket
+    FROM matched
+    JOIN film f ON f.id = matched.film_id
+    CROSS JOIN LATERAL (SELECT LEAST(FLOOR(f.rating / $8::int)::int, $9::int / $8::int - 1) * $8::int AS bucket) buckets
+    WHERE f.rating IS NOT NULL
+    GROUP BY bucket
+    ORDER BY facet, position, count DESC, value`,
+		variables.SearchTextConfig, query, variables.SearchCrewRankWeight, variables.SearchFuzzyRankWeight,
+		variables.SearchFacetGenre, variables.SearchFacetDecade, variables.SearchFacetRating,
+		variables.SearchRatingBucketWidth, variables.FilmRatingEnd)
+	if err != nil {
+		return models.SearchFacets{}, err
+	}
+	defer rows.Close()
+
+	facets := models.SearchFacets{
+		Genres:  []models.FacetCount{},
+		Decades: []models.FacetCount{},
+		Ratings: []models.FacetCount{},
+	}
+	for rows.Next() {
+		var facet string
+		var position int
+		var count models.FacetCount
+		err := rows.Scan(&facet, &count.Value, &count.Count, &position)
+		if err != nil {
+			return models.SearchFacets{}, err
+		}
+
+		switch facet {
+		case variables.SearchFacetGenre:
+			facets.Genres = append(facets.Genres, count)
+		case variables.SearchFacetDecade:
+			facets.Decades = append(facets.Decades, count)
+		case variables.SearchFacetRating:
+			facets.Ratings = append(facets.Ratings, count)
+		}
+	}
+	return facets, rows.Err()
+}
+
+// Suggest picks the films and actors whose names are closest to the typed
+// text, a few of each, and mixes them by similarity and popularity. Films
+// are as popular as they are rated, actors by the films they are in.
+func (repository *FilmRepository) Suggest(ctx context.Context, query string, limit uint64) (communication.SuggestResponse, error) {
+	rows, err := repository.db.QueryContext(ctx, `
+    SELECT type, id, title, similarity * (1 - $5::float8) + popularity * $5::float8 AS score
+    FROM (
+        (SELECT $3::text AS type, f.id, f.name AS title, word_similarity($1, f.name)::float8 AS similarity,
+                COALESCE(f.rating, 0)::float8 / 10 AS popularity
+         FROM film f
+         WHERE $1 <% f.name AND f.deleted_at IS NULL
+         ORDER BY similarity DESC, f.id
+         LIMIT $2)
+        UNION ALL
+        (SELECT $4::text, a.id, a.name, word_similarity($1, a.name)::float8 AS similarity,
+                1 - 1::float8 / (1 + (SELECT COUNT(DISTINCT fa.film_id) FROM film_actor fa
+                                JOIN film f ON f.id = fa.film_id AND f.deleted_at IS NULL
+                                WHERE fa.actor_id = a.id))
+         FROM actor a
+         WHERE $1 <% a.name AND a.deleted_at IS NULL
+         ORDER BY similarity DESC, a.id
+         LIMIT $2)
+    ) suggestion
+    ORDER BY score DESC, type, id
+    LIMIT $2`,
+		query, limit, variables.SuggestFilmType, variables.SuggestActorType, variables.SuggestPopularityWeight)
+	if err != nil {
+		return communication.SuggestResponse{}, err
+	}
+	defer rows.Close()
+
+	suggestions := []models.Suggestion{}
+	for rows.Next() {
+		var suggestion models.Suggestion
+		err := rows.Scan(&suggestion.Type, &suggestion.Id, &suggestion.Title, &suggestion.Score)
+		if err != nil {
+			return communication.SuggestResponse{}, err
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return communication.SuggestResponse{}, err
+	}
+
+	return communication.SuggestResponse{Suggestions: suggestions}, nil
+}
+
+// idArray passes ids as a bigint[] literal, nil stands for all rows
+func idArray(ids []int64) any {
+	if ids == nil {
+		return nil
+	}
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.FormatInt(id, 10)
+	}
+	return "{" + strings.Join(values, ",") + "}"
+}
+
+// GetSearchDocuments reads the live films or actors with the given ids, or
+// all of them for nil ids, as a search index keeps them. Trashed ones are
+// missing from the result.
+func (repository *FilmRepository) GetSearchDocuments(ctx context.Context, kind string, ids []int64) ([]models.SearchDocument, error) {
+	var query string
+	switch kind {
+	case variables.SearchFilmKind:
+		query = `
+    SELECT f.id, f.name, COALESCE(f.description, ''),
+           (SELECT COALESCE(jsonb_agg(DISTINCT a.name), '[]') FROM film_actor fa
+            JOIN actor a ON a.id = fa.actor_id AND a.deleted_at IS NULL
+            WHERE fa.film_id = f.id),
+           (SELECT COALESCE(jsonb_agg(genre.slug ORDER BY genre.slug), '[]')
+            FROM film_genre JOIN genre ON genre.id = film_genre.genre_id WHERE film_genre.film_id = f.id),
+           COALESCE(EXTRACT(YEAR FROM f.releaseDate)::int, 0), f.rating
+    FROM film f
+    WHERE f.deleted_at IS NULL AND ($1::bigint[] IS NULL OR f.id = ANY($1::bigint[]))
+    ORDER BY f.id`
+	case variables.SearchActorKind:
+		query = `
+    SELECT a.id, a.name, '', '[]'::jsonb, '[]'::jsonb, 0, NULL::float8
+    FROM actor a
+    WHERE a.deleted_at IS NULL AND ($1::bigint[] IS NULL OR a.id = ANY($1::bigint[]))
+    ORDER BY a.id`
+	default:
+		return nil, errors.New(variables.SearchKindError)
+	}
+
+	rows, err := repository.db.QueryContext(ctx, query, idArray(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	documents := []models.SearchDocument{}
+	for rows.Next() {
+		document := models.SearchDocument{Kind: kind}
+		var rating sql.NullFloat64
+		err := rows.Scan(&document.Id, &document.Title, &document.Text, (*stringList)(&document.Crew),
+			(*stringList)(&document.Genres), &document.ReleaseYear, &rating)
+		if err != nil {
+			return nil, err
+		}
+		if rating.Valid {
+			document.Rating = &rating.Float64
+		}
+		documents = append(documents, document)
+	}
+	return documents, rows.Err()
+}
+
+// GetActorFilmIds lists the films the actor is credited in, trashed ones too
+func (repository *FilmRepository) GetActorFilmIds(ctx context.Context, id int64) ([]int64, error) {
+	return repository.filmIds(ctx, `SELECT DISTINCT film_id FROM film_actor WHERE actor_id = $1 ORDER BY film_id`, id)
+}
+
+// GetGenreFilmIds lists the films of the genre, trashed ones too
+func (repository *FilmRepository) GetGenreFilmIds(ctx context.Context, id int64) ([]int64, error) {
+	return repository.filmIds(ctx, `SELECT film_id FROM film_genre WHERE genre_id = $1 ORDER BY film_id`, id)
+}
+
+func (repository *FilmRepository) filmIds(ctx context.Context, query string, id int64) ([]int64, error) {
+	rows, err := repository.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var filmId int64
+		err := rows.Scan(&filmId)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, filmId)
+	}
+	return ids, rows.Err()
+}
+
+// GetFilmShortItems reads the live films with the given ids along with
+// their live crew, for search results
+func (repository *FilmRepository) GetFilmShortItems(ctx context.Context, ids []int64) (map[int64]models.FilmShortItem, error) {
+	rows, err := repository.db.QueryContext(ctx, `
+    SELECT f.id, f.name, COALESCE(f.description, ''), COALESCE(f.rating, 0), COALESCE(f.releaseDate::text, ''),
+           COALESCE(crew.id, 0), COALESCE(crew.name, ''), COALESCE(crew.gender, ''), COALESCE(crew.birthdate, '')
+    FROM film f
+    LEFT JOIN LATERAL (
+        SELECT a.id, a.name, a.gender, a.birthdate::text AS birthdate, MIN(fa.billing_order) AS billing_order
+        FROM film_actor fa
+        JOIN actor a ON a.id = fa.actor_id AND a.deleted_at IS NULL
+        WHERE fa.film_id = f.id
+        GROUP BY a.id
+    ) crew ON true
+    WHERE f.deleted_at IS NULL AND f.id = ANY($1::bigint[])
+    ORDER BY f.id, crew.billing_order, crew.id`, idArray(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	films := make(map[int64]models.FilmShortItem, len(ids))
+	for rows.Next() {
+		var film models.FilmShortItem
+		var actor models.ActorShortItem
+		err := rows.Scan(&film.Id, &film.Title, &film.Description, &film.Rating, &film.ReleaseDate,
+			&actor.Id, &actor.Name, &actor.Gender, &actor.BirthDate)
+		if err != nil {
+			return nil, err
+		}
+
+		existing, found := films[int64(film.Id)]
+		if found {
+			film = existing
+		} else {
+			film.Crew = []models.ActorShortItem{}
+		}
+		if actor.Id != 0 {
+			film.Crew = append(film.Crew, actor)
+		}
+		films[int64(film.Id)] = film
+	}
+	return films, rows.Err()
+}
+
+// GetActorShortItems reads the live actors with the given ids
+func (repository *FilmRepository) GetActorShortItems(ctx context.Context, ids []int64) (map[int64]models.ActorShortItem, error) {
+	rows, err := repository.db.QueryContext(ctx, `
+    SELECT a.id, a.name, COALESCE(a.gender, ''), COALESCE(a.birthdate::text, '')
+    FROM actor a
+    WHERE a.deleted_at IS NULL AND a.id = ANY($1::bigint[])`, idArray(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actors := make(map[int64]models.ActorShortItem, len(ids))
+	for rows.Next() {
+		var actor models.ActorShortItem
+		err := rows.Scan(&actor.Id, &actor.Name, &actor.Gender, &actor.BirthDate)
+		if err != nil {
+			return nil, err
+		}
+		actors[int64(actor.Id)] = actor
+	}
+	return actors, rows.Err()
+}
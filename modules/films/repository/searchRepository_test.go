@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/variables"
+	"slices"
+	"testing"
+)
+
+// addSearchProbes adds films found by their title, their description and
+// their crew, one unrelated film and one trashed film
+func addSearchProbes(t *testing.T, repository *FilmRepository) map[string]int64 {
+	t.Helper()
+	ctx := context.Background()
+	actorId := addTestActor(t, repository, "Quokka Ivanova")
+
+	films := []struct {
+		key         string
+		title       string
+		description string
+		crew        []models.CrewMember
+	}{
+		{"title", "Quokka island", "A quiet film", nil},
+		{"description", "Plain story", "A quokka lives on the island", nil},
+		{"crew", "Crew story", "Nothing here", []models.CrewMember{{ActorId: actorId, Role: variables.CrewRoleActor}}},
+		{"unrelated", "Wombat tale", "A wombat digs", nil},
+		{"trashed", "Trashed quokka", "A quokka in the trash", nil},
+	}
+
+	ids := make(map[string]int64)
+	for _, film := range films {
+		id, err := repository.AddFilm(ctx, film.title, film.description, 5, "2001-01-01", film.crew, models.FilmMetadata{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[film.key] = id
+	}
+
+	err := repository.DeleteFilm(ctx, ids["trashed"], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func searchFilms(t *testing.T, index *SqlSearchIndex, text string, offset uint64, limit uint64) ([]int64, int64) {
+	t.Helper()
+	result, err := index.Query(context.Background(), models.SearchQuery{Kind: variables.SearchFilmKind, Text: text, Offset: offset, Limit: limit})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []int64{}
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.Id)
+	}
+	return ids, result.Total
+}
+
+func TestSearchFilmsRanksTitlesFirst(t *testing.T) {
+	repository := getTestRepository(t)
+	ids := addSearchProbes(t, repository)
+	index := GetSqlSearchIndex(repository)
+
+	found, total := searchFilms(t, index, "quokka", 0, 10)
+	if total != 3 || len(found) != 3 {
+		t.Fatalf("found %v of %d, want the title, description and crew matches", found, total)
+	}
+	if found[0] != ids["title"] {
+		t.Errorf("first hit = %d, want the title match %d", found[0], ids["title"])
+	}
+	for _, key := range []string{"description", "crew"} {
+		if !slices.Contains(found, ids[key]) {
+			t.Errorf("the %s match %d is missing from %v", key, ids[key], found)
+		}
+	}
+	if slices.Contains(found, ids["trashed"]) {
+		t.Error("a trashed film was found")
+	}
+
+	second, total := searchFilms(t, index, "quokka", 1, 1)
+	if total != 3 || len(second) != 1 || second[0] != found[1] {
+		t.Errorf("second page = %v of %d, want [%d] of 3", second, total, found[1])
+	}
+}
+
+func TestSearchFilmsQuerySyntax(t *testing.T) {
+	repository := getTestRepository(t)
+	ids := addSearchProbes(t, repository)
+	index := GetSqlSearchIndex(repository)
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{`"quokka island"`, []string{"title"}},
+		{"quokka -lives", []string{"title", "crew"}},
+		{"wombat or island", []string{"title", "description", "unrelated"}},
+		{"platypus", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			found, _ := searchFilms(t, index, test.text, 0, 10)
+			slices.Sort(found)
+
+			want := []int64{}
+			for _, key := range test.want {
+				want = append(want, ids[key])
+			}
+			slices.Sort(want)
+			if !slices.Equal(found, want) {
+				t.Errorf("found %v, want %v", found, want)
+			}
+		})
+	}
+}
+
+func TestSearchActorsMatchesNames(t *testing.T) {
+	repository := getTestRepository(t)
+	addSearchProbes(t, repository)
+	index := GetSqlSearchIndex(repository)
+
+	result, err := index.Query(context.Background(), models.SearchQuery{Kind: variables.SearchActorKind, Text: "ivanova", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || len(result.Hits) != 1 {
+		t.Fatalf("hits = %+v, want the one probe actor", result.Hits)
+	}
+
+	_, err = index.Query(context.Background(), models.SearchQuery{Kind: "genre", Text: "drama", Limit: 10})
+	if err == nil {
+		t.Error("an unknown kind was searched")
+	}
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"filmoteka/pkg/models"
+	communication "filmoteka/pkg/requests"
+	"filmoteka/pkg/variables"
+	"time"
+)
+
+func (repository *FilmRepository) GetTrash(ctx context.Context, offset uint64, limit uint64) (communication.TrashListResponse, error) {
+	rows, err := repository.db.QueryContext(ctx, `
+    SELECT id, $3::text, name, deleted_at FROM film WHERE deleted_at IS NOT NULL
+    UNION ALL
+    SELECT id, $4::text, name, deleted_at FROM actor WHERE deleted_at IS NOT NULL
+    ORDER BY 4 DESC, 2, 1
+    LIMIT $1 OFFSET $2`,
+		limit, offset, variables.TrashFilmType, variables.TrashActorType)
+	if err != nil {
+		return communication.TrashListResponse{}, err
+	}
+	defer rows.Close()
+
+	items := []models.TrashItem{}
+	for rows.Next() {
+		var item models.TrashItem
+		err := rows.Scan(&item.Id, &item.Type, &item.Name, &item.DeletedAt)
+		if err != nil {
+			return communication.TrashListResponse{}, err
+		}
+		items = append(items, item)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return communication.TrashListResponse{}, err
+	}
+
+	return communication.TrashListResponse{Items: items}, nil
+}
+
+func (repository *FilmRepository) RestoreFilm(ctx context.Context, id int64) error {
+	return repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		state, err := readFilmState(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, `UPDATE film SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+		if err != nil {
+			return err
+		}
+
+		err = expectAffected(result)
+		if err != nil {
+			return err
+		}
+		return auditFilm(ctx, tx, variables.AuditActionRestore, id, nil, state)
+	})
+}
+
+func (repository *FilmRepository) RestoreActor(ctx context.Context, id int64) error {
+	return repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		state, err := readActorState(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, `UPDATE actor SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+		if err != nil {
+			return err
+		}
+
+		err = expectAffected(result)
+		if err != nil {
+			return err
+		}
+		return auditActor(ctx, tx, variables.AuditActionRestore, id, nil, state)
+	})
+}
+
+// Only trashed rows can be purged, crew links go with them by ON DELETE CASCADE
+func (repository *FilmRepository) PurgeFilm(ctx context.Context, id int64) error {
+	return repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		return purgeFilm(ctx, tx, id)
+	})
+}
+
+func (repository *FilmRepository) PurgeActor(ctx context.Context, id int64) error {
+	return repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		return purgeActor(ctx, tx, id)
+	})
+}
+
+// PurgeTrash removes everything trashed before the given time and returns
+// the number of purged films and actors.
+func (repository *FilmRepository) PurgeTrash(ctx context.Context, before time.Time) (int64, int64, error) {
+	var films, actors int64
+
+	err := repository.inTransaction(ctx, func(tx *sql.Tx) error {
+		filmIds, err := expiredIds(ctx, tx, `SELECT id FROM film WHERE deleted_at < $1`, before)
+		if err != nil {
+			return err
+		}
+		for _, id := range filmIds {
+			err := purgeFilm(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		actorIds, err := expiredIds(ctx, tx, `SELECT id FROM actor WHERE deleted_at < $1`, before)
+		if err != nil {
+			return err
+		}
+		for _, id := range actorIds {
+			err := purgeActor(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		films, actors = int64(len(filmIds)), int64(len(actorIds))
+		return nil
+	})
+
+	return films, actors, err
+}
+
+func purgeFilm(ctx context.Context, tx *sql.Tx, id int64) error {
+	state, err := readFilmState(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM film WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	err = expectAffected(result)
+	if err != nil {
+		return err
+	}
+	return auditFilm(ctx, tx, variables.AuditActionPurge, id, state, nil)
+}
+
+func purgeActor(ctx context.Context, tx *sql.Tx, id int64) error {
+	state, err := readActorState(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM actor WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	err = expectAffected(result)
+	if err != nil {
+		return err
+	}
+	return auditActor(ctx, tx, variables.AuditActionPurge, id, state, nil)
+}
+
+func expiredIds(ctx context.Context, tx *sql.Tx, query string, before time.Time) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, query, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
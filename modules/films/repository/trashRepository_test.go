@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/variables"
+	"slices"
+	"testing"
+	"time"
+)
+
+func listedActorIds(t *testing.T, repository *FilmRepository) []int64 {
+	t.Helper()
+	actors, err := repository.GetActors(context.Background(), 0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []int64{}
+	for _, actor := range actors.Actors {
+		ids = append(ids, int64(actor.Id))
+	}
+	return ids
+}
+
+func listedProbeFilmIds(t *testing.T, repository *FilmRepository) []int64 {
+	t.Helper()
+	films, err := repository.GetFilms(context.Background(), 0, 1000, nil, models.FilmFilter{TitlePrefix: "Trash probe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return listedFilmIds(films.Films)
+}
+
+func TestSoftDeletedRowsAreHiddenUntilRestored(t *testing.T) {
+	repository := getTestRepository(t)
+	ctx := context.Background()
+	actorId := addTestActor(t, repository, "Trash probe actor")
+	filmId := addTestFilm(t, repository, "Trash probe film", 7, "2001-01-01", actorId)
+
+	err := repository.DeleteFilm(ctx, filmId, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := listedProbeFilmIds(t, repository); len(ids) != 0 {
+		t.Errorf("films list = %v after delete, want none", ids)
+	}
+	if ids := listedActorIds(t, repository); slices.Contains(ids, actorId) {
+		t.Error("actors list kept an actor credited only in a trashed film")
+	}
+	if _, err := repository.GetFilm(ctx, filmId); !errors.Is(err, variables.ErrNotFound) {
+		t.Errorf("GetFilm of a trashed film = %v, want %v", err, variables.ErrNotFound)
+	}
+
+	trash, err := repository.GetTrash(ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash.Items) != 1 || trash.Items[0].Id != filmId || trash.Items[0].Type != variables.TrashFilmType {
+		t.Errorf("trash = %+v, want the deleted film", trash.Items)
+	}
+
+	err = repository.RestoreFilm(ctx, filmId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := listedProbeFilmIds(t, repository); !slices.Equal(ids, []int64{filmId}) {
+		t.Errorf("films list = %v after restore, want [%d]", ids, filmId)
+	}
+	if ids := listedActorIds(t, repository); !slices.Contains(ids, actorId) {
+		t.Error("actors list misses the actor of a restored film")
+	}
+
+	err = repository.DeleteActor(ctx, actorId, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := listedActorIds(t, repository); slices.Contains(ids, actorId) {
+		t.Error("actors list kept a trashed actor")
+	}
+	if err := repository.RestoreFilm(ctx, filmId); !errors.Is(err, variables.ErrNotFound) {
+		t.Errorf("restoring a live film = %v, want %v", err, variables.ErrNotFound)
+	}
+}
+
+func TestPurgeTrashRemovesOnlyExpiredItems(t *testing.T) {
+	repository := getTestRepository(t)
+	ctx := context.Background()
+	filmId := addTestFilm(t, repository, "Trash probe film", 7, "2001-01-01")
+	actorId := addTestActor(t, repository, "Trash probe actor")
+
+	err := repository.DeleteFilm(ctx, filmId, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repository.DeleteActor(ctx, actorId, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	films, actors, err := repository.PurgeTrash(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if films != 0 || actors != 0 {
+		t.Errorf("purged %d films and %d actors trashed just now, want none", films, actors)
+	}
+
+	films, actors, err = repository.PurgeTrash(ctx, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if films != 1 || actors != 1 {
+		t.Errorf("purged %d films and %d actors, want 1 and 1", films, actors)
+	}
+
+	trash, err := repository.GetTrash(ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash.Items) != 0 {
+		t.Errorf("trash = %+v after purge, want empty", trash.Items)
+	}
+	if err := repository.RestoreFilm(ctx, filmId); !errors.Is(err, variables.ErrNotFound) {
+		t.Errorf("restoring a purged film = %v, want %v", err, variables.ErrNotFound)
+	}
+}
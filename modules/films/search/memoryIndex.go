@@ -0,0 +1,404 @@
+package search
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/variables"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// documentKey tells a film and an actor with the same id apart
+type documentKey struct {
+	kind string
+	id   int64
+}
+
+// indexedDocument keeps the weighted frequency of every term of a document,
+// titles counting more than descriptions and crew names
+type indexedDocument struct {
+	document models.SearchDocument
+	terms    map[string]float64
+	length   float64
+}
+
+// memoryState is one generation of the index, a rebuild makes a new one
+// and swaps it in whole
+type memoryState struct {
+	documents map[documentKey]*indexedDocument
+	postings  map[string]map[documentKey]float64
+	lengths   map[string]float64
+	counts    map[string]int
+}
+
+func newMemoryState() *memoryState {
+	return &memoryState{
+		documents: make(map[documentKey]*indexedDocument),
+		postings:  make(map[string]map[documentKey]float64),
+		lengths:   make(map[string]float64),
+		counts:    make(map[string]int),
+	}
+}
+
+// MemoryIndex is an inverted index held in memory, every term points to
+// the documents it occurs in. Queries take the syntax of the postgres
+// backend and rank the matching documents by BM25.
+type MemoryIndex struct {
+	mutex sync.RWMutex
+	state *memoryState
+}
+
+func GetMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{state: newMemoryState()}
+}
+
+func (index *MemoryIndex) Index(ctx context.Context, document models.SearchDocument) error {
+	err := checkKind(document.Kind)
+	if err != nil {
+		return err
+	}
+
+	indexed := analyze(document)
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	key := documentKey{kind: document.Kind, id: document.Id}
+	index.state.remove(key)
+	index.state.add(key, indexed)
+	return nil
+}
+
+func (index *MemoryIndex) Delete(ctx context.Context, kind string, id int64) error {
+	err := checkKind(kind)
+	if err != nil {
+		return err
+	}
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	index.state.remove(documentKey{kind: kind, id: id})
+	return nil
+}
+
+// Rebuild replaces the whole index with the given documents, queries see
+// the old contents until it is done
+func (index *MemoryIndex) Rebuild(ctx context.Context, documents []models.SearchDocument) error {
+	state := newMemoryState()
+	for _, document := range documents {
+		err := checkKind(document.Kind)
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		state.add(documentKey{kind: document.Kind, id: document.Id}, analyze(document))
+	}
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	index.state = state
+	return nil
+}
+
+func (index *MemoryIndex) Query(ctx context.Context, query models.SearchQuery) (models.SearchResult, error) {
+	err := checkKind(query.Kind)
+	if err != nil {
+		return models.SearchResult{}, err
+	}
+
+	alternatives := parseQuery(query.Text)
+	terms := queryTerms(alternatives)
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	scores := index.state.match(query.Kind, alternatives, terms)
+	hits := make([]models.SearchHit, 0, len(scores))
+	for key, score := range scores {
+		hits = append(hits, models.SearchHit{Id: key.id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Id < hits[j].Id
+	})
+
+	result := models.SearchResult{Total: int64(len(hits))}
+	if query.Facets && query.Kind == variables.SearchFilmKind {
+		result.Facets = index.state.facets(hits)
+	}
+
+	begin := min(query.Offset, uint64(len(hits)))
+	result.Hits = hits[begin:min(begin+query.Limit, uint64(len(hits)))]
+	for i := range result.Hits {
+		document := index.state.documents[documentKey{kind: query.Kind, id: result.Hits[i].Id}].document
+		result.Hits[i].Snippet = snippet(document, terms)
+	}
+	return result, nil
+}
+
+func checkKind(kind string) error {
+	if kind != variables.SearchFilmKind && kind != variables.SearchActorKind {
+		return fmt.Errorf("%s: %q", variables.SearchKindError, kind)
+	}
+	return nil
+}
+
+func analyze(document models.SearchDocument) *indexedDocument {
+	indexed := &indexedDocument{document: document, terms: make(map[string]float64)}
+	addTerms := func(text string, weight float64) {
+		for _, term := range tokenize(text) {
+			indexed.terms[term] += weight
+			indexed.length += weight
+		}
+	}
+
+	addTerms(document.Title, variables.SearchTitleWeight)
+	addTerms(document.Text, variables.SearchTextWeight)
+	for _, name := range document.Crew {
+		addTerms(name, variables.SearchCrewRankWeight)
+	}
+	return indexed
+}
+
+func (state *memoryState) add(key documentKey, indexed *indexedDocument) {
+	state.documents[key] = indexed
+	state.lengths[key.kind] += indexed.length
+	state.counts[key.kind]++
+	for term, frequency := range indexed.terms {
+		postings, found := state.postings[term]
+		if !found {
+			postings = make(map[documentKey]float64)
+			state.postings[term] = postings
+		}
+		postings[key] = frequency
+	}
+}
+
+func (state *memoryState) remove(key documentKey) {
+	indexed, found := state.documents[key]
+	if !found {
+		return
+	}
+
+	delete(state.documents, key)
+	state.lengths[key.kind] -= indexed.length
+	state.counts[key.kind]--
+	for term := range indexed.terms {
+		postings := state.postings[term]
+		delete(postings, key)
+		if len(postings) == 0 {
+			delete(state.postings, term)
+		}
+	}
+}
+
+// match scores the documents of the kind matching any alternative of the
+// query by the terms they hold
+func (state *memoryState) match(kind string, alternatives [][]clause, terms []string) map[documentKey]float64 {
+	count := state.counts[kind]
+	if count == 0 {
+		return nil
+	}
+
+	scores := make(map[documentKey]float64)
+	for _, alternative := range alternatives {
+		for _, key := range state.candidates(kind, alternative) {
+			if _, found := scores[key]; !found && state.holdsAll(key, alternative) {
+				scores[key] = 0
+			}
+		}
+	}
+
+	averageLength := state.lengths[kind] / float64(count)
+	for _, term := range terms {
+		postings := state.postings[term]
+		frequency := 0
+		for key := range postings {
+			if key.kind == kind {
+				frequency++
+			}
+		}
+		if frequency == 0 {
+			continue
+		}
+		idf := math.Log(1 + (float64(count)-float64(frequency)+0.5)/(float64(frequency)+0.5))
+
+		for key := range scores {
+			weight, found := postings[key]
+			if !found {
+				continue
+			}
+			length := state.documents[key].length
+			norm := variables.SearchBm25K1 * (1 - variables.SearchBm25B + variables.SearchBm25B*length/averageLength)
+			scores[key] += idf * weight * (variables.SearchBm25K1 + 1) / (weight + norm)
+		}
+	}
+	return scores
+}
+
+// candidates lists the documents holding the rarest term of the alternative,
+// every document of the kind when it only excludes terms
+func (state *memoryState) candidates(kind string, alternative []clause) []documentKey {
+	var rarest map[documentKey]float64
+	negatedOnly := true
+	for _, clause := range alternative {
+		if clause.negated {
+			continue
+		}
+		for _, term := range clause.terms {
+			postings := state.postings[term]
+			if negatedOnly || len(postings) < len(rarest) {
+				negatedOnly = false
+				rarest = postings
+			}
+		}
+	}
+
+	var keys []documentKey
+	if negatedOnly {
+		for key := range state.documents {
+			if key.kind == kind {
+				keys = append(keys, key)
+			}
+		}
+		return keys
+	}
+	for key := range rarest {
+		if key.kind == kind {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (state *memoryState) holdsAll(key documentKey, alternative []clause) bool {
+	for _, clause := range alternative {
+		if state.holds(key, clause) == clause.negated {
+			return false
+		}
+	}
+	return true
+}
+
+// holds tells whether the document has the terms of the clause, a phrase
+// has to be found whole within the title, the description or a crew name
+func (state *memoryState) holds(key documentKey, clause clause) bool {
+	for _, term := range clause.terms {
+		if _, found := state.postings[term][key]; !found {
+			return false
+		}
+	}
+	if len(clause.terms) == 1 {
+		return true
+	}
+
+	document := state.documents[key].document
+	if containsPhrase(document.Title, clause.terms) || containsPhrase(document.Text, clause.terms) {
+		return true
+	}
+	for _, name := range document.Crew {
+		if containsPhrase(name, clause.terms) {
+			return true
+		}
+	}
+	return false
+}
+
+// facets counts the matching films the way the postgres backend does
+func (state *memoryState) facets(hits []models.SearchHit) models.SearchFacets {
+	genres := make(map[string]int64)
+	decades := make(map[int]int64)
+	ratings := make(map[int]int64)
+	lastBucket := variables.FilmRatingEnd/variables.SearchRatingBucketWidth - 1
+
+	for _, hit := range hits {
+		document := state.documents[documentKey{kind: variables.SearchFilmKind, id: hit.Id}].document
+		for _, genre := range document.Genres {
+			genres[genre]++
+		}
+		if document.ReleaseYear != 0 {
+			decades[document.ReleaseYear/10*10]++
+		}
+		if document.Rating != nil {
+			bucket := min(int(math.Floor(*document.Rating/variables.SearchRatingBucketWidth)), lastBucket)
+			ratings[bucket*variables.SearchRatingBucketWidth]++
+		}
+	}
+
+	facets := models.SearchFacets{
+		Genres:  []models.FacetCount{},
+		Decades: []models.FacetCount{},
+		Ratings: []models.FacetCount{},
+	}
+	for genre, count := range genres {
+		facets.Genres = append(facets.Genres, models.FacetCount{Value: genre, Count: count})
+	}
+	sort.Slice(facets.Genres, func(i, j int) bool {
+		if facets.Genres[i].Count != facets.Genres[j].Count {
+			return facets.Genres[i].Count > facets.Genres[j].Count
+		}
+		return facets.Genres[i].Value < facets.Genres[j].Value
+	})
+
+	for _, decade := range sortedKeys(decades) {
+		facets.Decades = append(facets.Decades, models.FacetCount{Value: strconv.Itoa(decade) + "s", Count: decades[decade]})
+	}
+	for _, bucket := range sortedKeys(ratings) {
+		label := fmt.Sprintf("%d-%d", bucket, bucket+variables.SearchRatingBucketWidth)
+		facets.Ratings = append(facets.Ratings, models.FacetCount{Value: label, Count: ratings[bucket]})
+	}
+	return facets
+}
+
+func sortedKeys(counts map[int]int64) []int {
+	keys := make([]int, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// snippet marks the words of the title and the description that hold query
+// terms, starting a little before the first of them
+func snippet(document models.SearchDocument, terms []string) string {
+	text := document.Title
+	if document.Text != "" {
+		text += ". " + document.Text
+	}
+
+	words := strings.Fields(text)
+	first := -1
+	for i, word := range words {
+		for _, term := range tokenize(word) {
+			if containsTerm(terms, term) {
+				words[i] = variables.SearchHighlightStart + word + variables.SearchHighlightStop
+				if first < 0 {
+					first = i
+				}
+				break
+			}
+		}
+	}
+
+	begin := max(first-variables.SearchSnippetWords/4, 0)
+	end := min(begin+variables.SearchSnippetWords, len(words))
+	return strings.Join(words[begin:end], " ")
+}
+
+func containsTerm(terms []string, term string) bool {
+	for _, candidate := range terms {
+		if candidate == term {
+			return true
+		}
+	}
+	return false
+}
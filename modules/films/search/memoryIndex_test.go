@@ -0,0 +1,101 @@
+package search
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/variables"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		want [][]clause
+	}{
+		{"Quokka island", [][]clause{{{terms: []string{"quokka"}}, {terms: []string{"island"}}}}},
+		{`"quokka island" -wombat`, [][]clause{{{terms: []string{"quokka", "island"}}, {terms: []string{"wombat"}, negated: true}}}},
+		{"wombat OR island", [][]clause{{{terms: []string{"wombat"}}}, {{terms: []string{"island"}}}}},
+		{`-"quokka island" spider-man`, [][]clause{{{terms: []string{"quokka", "island"}, negated: true}, {terms: []string{"spider", "man"}}}}},
+		{"or - wombat or", [][]clause{{{terms: []string{"wombat"}}}}},
+		{`"`, nil},
+	}
+	for _, test := range tests {
+		if got := parseQuery(test.text); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseQuery(%q) = %v, want %v", test.text, got, test.want)
+		}
+	}
+}
+
+func getTestIndex(t *testing.T) *MemoryIndex {
+	t.Helper()
+	index := GetMemoryIndex()
+	err := index.Rebuild(context.Background(), []models.SearchDocument{
+		{Kind: variables.SearchFilmKind, Id: 1, Title: "Quokka island", Text: "A quiet film"},
+		{Kind: variables.SearchFilmKind, Id: 2, Title: "Plain story", Text: "A quokka lives on the island"},
+		{Kind: variables.SearchFilmKind, Id: 3, Title: "Crew story", Text: "Nothing here", Crew: []string{"Quokka Ivanova"}},
+		{Kind: variables.SearchFilmKind, Id: 4, Title: "Wombat tale", Text: "A wombat digs"},
+		{Kind: variables.SearchActorKind, Id: 1, Title: "Quokka Ivanova"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return index
+}
+
+func TestQuerySyntax(t *testing.T) {
+	index := getTestIndex(t)
+
+	tests := []struct {
+		text string
+		want []int64
+	}{
+		{"quokka", []int64{1, 2, 3}},
+		{"quokka island", []int64{1, 2}},
+		{`"quokka island"`, []int64{1}},
+		{`"island quokka"`, []int64{}},
+		{"quokka -lives", []int64{1, 3}},
+		{`quokka -"quiet film"`, []int64{2, 3}},
+		{"wombat or island", []int64{1, 2, 4}},
+		{"wombat or", []int64{4}},
+		{"-quokka", []int64{4}},
+		{"platypus", []int64{}},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			result, err := index.Query(context.Background(), models.SearchQuery{Kind: variables.SearchFilmKind, Text: test.text, Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			found := []int64{}
+			for _, hit := range result.Hits {
+				found = append(found, hit.Id)
+			}
+			slices.Sort(found)
+			if !slices.Equal(found, test.want) || result.Total != int64(len(test.want)) {
+				t.Errorf("found %v of %d, want %v", found, result.Total, test.want)
+			}
+		})
+	}
+}
+
+func TestQueryRanksTitlesFirst(t *testing.T) {
+	index := getTestIndex(t)
+
+	result, err := index.Query(context.Background(), models.SearchQuery{Kind: variables.SearchFilmKind, Text: "quokka -wombat", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Hits) != 3 || result.Hits[0].Id != 1 {
+		t.Fatalf("hits = %+v, want the title match first", result.Hits)
+	}
+
+	highlighted := variables.SearchHighlightStart + "quokka" + variables.SearchHighlightStop
+	for _, hit := range result.Hits {
+		if hit.Id == 2 && hit.Snippet != "Plain story. A "+highlighted+" lives on the island" {
+			t.Errorf("snippet = %s, want only the quokka highlighted", hit.Snippet)
+		}
+	}
+}
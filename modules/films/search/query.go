@@ -0,0 +1,102 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// clause is a word or a quoted phrase of a query, a word joined by hyphens
+// is a phrase too. A negated clause excludes the documents holding it.
+type clause struct {
+	terms   []string
+	negated bool
+}
+
+// parseQuery reads the syntax of websearch_to_tsquery used by the postgres
+// backend: all the words and "quoted phrases" must match, or starts an
+// alternative and a leading minus negates a word or a phrase
+func parseQuery(text string) [][]clause {
+	var alternatives [][]clause
+	var current []clause
+	negated := false
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		switch {
+		case unicode.IsSpace(runes[i]):
+			negated = false
+			i++
+		case runes[i] == '-':
+			negated = true
+			i++
+		case runes[i] == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if terms := tokenize(string(runes[i+1 : end])); len(terms) != 0 {
+				current = append(current, clause{terms: terms, negated: negated})
+			}
+			negated = false
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+				end++
+			}
+			word := string(runes[i:end])
+			if strings.EqualFold(word, "or") && !negated {
+				if len(current) != 0 {
+					alternatives = append(alternatives, current)
+					current = nil
+				}
+			} else if terms := tokenize(word); len(terms) != 0 {
+				current = append(current, clause{terms: terms, negated: negated})
+			}
+			negated = false
+			i = end
+		}
+	}
+
+	if len(current) != 0 {
+		alternatives = append(alternatives, current)
+	}
+	return alternatives
+}
+
+// queryTerms lists the terms a matching document may hold, they are ranked
+// and highlighted
+func queryTerms(alternatives [][]clause) []string {
+	var terms []string
+	for _, alternative := range alternatives {
+		for _, clause := range alternative {
+			if clause.negated {
+				continue
+			}
+			for _, term := range clause.terms {
+				if !containsTerm(terms, term) {
+					terms = append(terms, term)
+				}
+			}
+		}
+	}
+	return terms
+}
+
+// containsPhrase tells whether the terms follow each other in the text
+func containsPhrase(text string, phrase []string) bool {
+	words := tokenize(text)
+	for begin := 0; begin+len(phrase) <= len(words); begin++ {
+		found := true
+		for i, term := range phrase {
+			if words[begin+i] != term {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,17 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+var termNormalizer = strings.NewReplacer("ё", "е")
+
+// tokenize splits text into lower case terms of letters and digits, the
+// same way for documents and queries
+func tokenize(text string) []string {
+	text = termNormalizer.Replace(strings.ToLower(text))
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
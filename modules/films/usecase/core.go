@@ -2,198 +2,394 @@ package usecase
 
 import (
 	"context"
-	"filmoteka/modules/authorization/proto/authorization"
+	"filmoteka/pkg/metrics"
+	"filmoteka/pkg/models"
 	communication "filmoteka/pkg/requests"
 	"filmoteka/pkg/util"
 	"filmoteka/pkg/variables"
-	"fmt"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 	"log/slog"
+	"sync"
+	"time"
 )
 
 //go:generate mockgen -source=core.go -destination=../mocks/film_repository_mock.go -package=mocks
 
 type IFilmRepository interface {
-	GetFilms(begin uint64, end uint64, sortType string) (communication.FilmsListResponse, error)
-	FindFilm(filmName string, actorName string) (communication.FindFilmResponse, error)
-	AddFilm(title string, description string, rating float64, releaseDate string, crew []int64) error
-	EditFilm(id int64, title string, description string, rating float64, releaseDate string, crew []int64) error
-	GetActors(begin uint64, end uint64) (communication.ActorsListResponse, error)
-	AddActor(name string, gender string, birthdate string) error
-	EditActor(id int64, name string, gender string, birthdate string, films []int64) error
-	DeleteActor(id int64) error
-	DeleteFilm(id int64) error
+	GetFilms(ctx context.Context, offset uint64, limit uint64, sort []models.SortKey, filter models.FilmFilter) (communication.FilmsListResponse, error)
+	GetSearchDocuments(ctx context.Context, kind string, ids []int64) ([]models.SearchDocument, error)
+	GetActorFilmIds(ctx context.Context, id int64) ([]int64, error)
+	GetGenreFilmIds(ctx context.Context, id int64) ([]int64, error)
+	GetFilmShortItems(ctx context.Context, ids []int64) (map[int64]models.FilmShortItem, error)
+	GetActorShortItems(ctx context.Context, ids []int64) (map[int64]models.ActorShortItem, error)
+	Suggest(ctx context.Context, query string, limit uint64) (communication.SuggestResponse, error)
+	AddFilm(ctx context.Context, title string, description string, rating float64, releaseDate string, crew []models.CrewMember, metadata models.FilmMetadata) (int64, error)
+	GetFilm(ctx context.Context, id int64) (models.FilmItem, error)
+	EditFilm(ctx context.Context, id int64, version int64, patch models.FilmPatch) (int64, error)
+	GetActors(ctx context.Context, offset uint64, limit uint64) (communication.ActorsListResponse, error)
+	AddActor(ctx context.Context, name string, gender string, birthdate string) (int64, error)
+	GetActor(ctx context.Context, id int64) (models.ActorItem, error)
+	EditActor(ctx context.Context, id int64, version int64, patch models.ActorPatch) (int64, error)
+	DeleteActor(ctx context.Context, id int64, version int64) error
+	DeleteFilm(ctx context.Context, id int64, version int64) error
+	GetTrash(ctx context.Context, offset uint64, limit uint64) (communication.TrashListResponse, error)
+	RestoreFilm(ctx context.Context, id int64) error
+	RestoreActor(ctx context.Context, id int64) error
+	PurgeFilm(ctx context.Context, id int64) error
+	PurgeActor(ctx context.Context, id int64) error
+	PurgeTrash(ctx context.Context, before time.Time) (int64, int64, error)
+	GetAudit(ctx context.Context, filter models.AuditFilter, offset uint64, limit uint64) (communication.AuditListResponse, error)
+	GetFilmRevisions(ctx context.Context, id int64, offset uint64, limit uint64) (communication.FilmRevisionsResponse, error)
+	GetFilmRevision(ctx context.Context, id int64, revision int64) (models.FilmRevision, error)
+	GetGenres(ctx context.Context) (communication.GenresListResponse, error)
+	AddGenre(ctx context.Context, slug string, name string) (int64, error)
+	EditGenre(ctx context.Context, id int64, slug string, name string) error
+	DeleteGenre(ctx context.Context, id int64) error
 }
 
-type Core struct {
-	filmRepository IFilmRepository
-	client         authorization.AuthorizationClient
-	logger         *slog.Logger
+// Identity provider interface
+type IdentityProvider interface {
+	GetUserId(ctx context.Context, sid string) (int64, error)
+	GetUserRole(ctx context.Context, id int64) (string, error)
 }
 
-func GetGrpcClient(port string) (authorization.AuthorizationClient, error) {
-	conn, err := grpc.Dial(port, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		return nil, fmt.Errorf(variables.GrpcConnectError, ": %w", err)
-	}
-	client := authorization.NewAuthorizationClient(conn)
+// SearchIndex ranks films and actors for search. The catalogue stays the
+// source of truth, the core feeds the index on every write and can rebuild
+// it from scratch.
+type SearchIndex interface {
+	Index(ctx context.Context, document models.SearchDocument) error
+	Delete(ctx context.Context, kind string, id int64) error
+	Rebuild(ctx context.Context, documents []models.SearchDocument) error
+	Query(ctx context.Context, query models.SearchQuery) (models.SearchResult, error)
+}
 
-	return client, nil
+type Core struct {
+	filmRepository IFilmRepository
+	identities     IdentityProvider
+	searchIndex    SearchIndex
+	searchMutex    sync.Mutex
+	logger         *slog.Logger
 }
 
-func GetCore(configGrpc variables.GrpcConfig, films IFilmRepository, logger *slog.Logger) *Core {
-	client, err := GetGrpcClient(configGrpc.Port)
-	if err != nil {
-		logger.Error(variables.GrpcConnectError, ": %w", err)
-		return nil
-	}
+func GetCore(films IFilmRepository, identities IdentityProvider, searchIndex SearchIndex, logger *slog.Logger) *Core {
 	return &Core{
 		filmRepository: films,
-		client:         client,
-		logger:         logger,
+		identities:     identities,
+		searchIndex:    searchIndex,
+		logger:         logger.With(variables.ModuleLogger, variables.CoreModuleLogger),
 	}
 }
 
-func (core *Core) GetFilms(begin uint64, end uint64, sortType string) (communication.FilmsListResponse, error) {
-	filmsList, err := core.filmRepository.GetFilms(begin, end, sortType)
+func (core *Core) GetFilms(ctx context.Context, offset uint64, limit uint64, sort []models.SortKey, filter models.FilmFilter) (communication.FilmsListResponse, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	filmsList, err := core.filmRepository.GetFilms(ctx, offset, limit, sort, filter)
 	if err != nil {
-		core.logger.Error(variables.FilmsListNotFoundError, err)
+		logger.Error(variables.FilmsListNotFoundError, "error", err)
 		return communication.FilmsListResponse{}, err
 	}
 	return filmsList, nil
 }
 
-func (core *Core) FindFilm(filmName string, actorName string) (communication.FindFilmResponse, error) {
-	film, err := core.filmRepository.FindFilm(filmName, actorName)
+func (core *Core) FindFilm(ctx context.Context, query string, offset uint64, limit uint64) (communication.FindFilmResponse, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	films, _, err := core.searchFilms(ctx, models.SearchQuery{Text: query, Offset: offset, Limit: limit})
 	if err != nil {
-		core.logger.Error(variables.FilmNotFoundError, err)
+		logger.Error(variables.FilmNotFoundError, "query", query, "error", err)
 		return communication.FindFilmResponse{}, err
 	}
-	return film, nil
+	return communication.FindFilmResponse{Films: films}, nil
+}
+
+// Search pages films and actors separately, the facets cover every
+// matching film
+func (core *Core) Search(ctx context.Context, query string, filmsPage uint64, actorsPage uint64, pageSize uint64) (communication.SearchResponse, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	films, filmsResult, err := core.searchFilms(ctx, models.SearchQuery{
+		Text:   query,
+		Offset: (filmsPage - 1) * pageSize,
+		Limit:  pageSize,
+		Facets: true,
+	})
+	if err != nil {
+		logger.Error(variables.SearchError, "query", query, "error", err)
+		return communication.SearchResponse{}, err
+	}
+
+	actors, actorsResult, err := core.searchActors(ctx, models.SearchQuery{
+		Text:   query,
+		Offset: (actorsPage - 1) * pageSize,
+		Limit:  pageSize,
+	})
+	if err != nil {
+		logger.Error(variables.SearchError, "query", query, "error", err)
+		return communication.SearchResponse{}, err
+	}
+
+	return communication.SearchResponse{
+		Films: communication.SearchFilmsSection{
+			Items:    films,
+			Total:    filmsResult.Total,
+			Page:     filmsPage,
+			PageSize: pageSize,
+		},
+		Actors: communication.SearchActorsSection{
+			Items:    actors,
+			Total:    actorsResult.Total,
+			Page:     actorsPage,
+			PageSize: pageSize,
+		},
+		Facets: filmsResult.Facets,
+	}, nil
 }
 
-func (core *Core) AddFilm(title string, description string, rating float64, releaseDate string, crew []int64) error {
+func (core *Core) Suggest(ctx context.Context, query string, limit uint64) (communication.SuggestResponse, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	suggestions, err := core.filmRepository.Suggest(ctx, query, limit)
+	if err != nil {
+		logger.Error(variables.SuggestionsNotFoundError, "query", query, "error", err)
+		return communication.SuggestResponse{}, err
+	}
+	return suggestions, nil
+}
+
+func (core *Core) AddFilm(ctx context.Context, title string, description string, rating float64, releaseDate string, crew []models.CrewMember, metadata models.FilmMetadata) (int64, error) {
+	logger := util.ContextLogger(ctx, core.logger)
 	if rating < variables.FilmRatingBegin || rating > variables.FilmRatingEnd {
-		core.logger.Error(variables.RatingSizeError)
-		return fmt.Errorf(variables.RatingSizeError)
+		logger.Warn(variables.RatingSizeError)
+		return 0, util.InvalidInputError(variables.RatingSizeError)
 	}
 
-	err := util.ValidateStringSize(title, variables.FilmTitleBegin, variables.FilmTitleEnd, variables.TitleSizeError, core.logger)
+	err := util.ValidateStringSize(title, variables.FilmTitleBegin, variables.FilmTitleEnd, variables.TitleSizeError, logger)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	err = util.ValidateStringSize(description, variables.FilmDescriptionBegin, variables.FilmDescriptionEnd, variables.DescriptionSizeError, core.logger)
+	err = util.ValidateStringSize(description, variables.FilmDescriptionBegin, variables.FilmDescriptionEnd, variables.DescriptionSizeError, logger)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	err = core.filmRepository.AddFilm(title, description, rating, releaseDate, crew)
+	crew, err = normalizeCrew(crew, logger)
 	if err != nil {
-		core.logger.Error(variables.FilmNotAddedError, err)
-		return err
+		return 0, err
 	}
-	return nil
+
+	metadata, err = normalizeMetadata(metadata, logger)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := core.filmRepository.AddFilm(ctx, title, description, rating, releaseDate, crew, metadata)
+	if err != nil {
+		logger.Error(variables.FilmNotAddedError, "error", err)
+		return 0, err
+	}
+	metrics.FilmsAddedTotal.Inc()
+	core.syncSearch(ctx, variables.SearchFilmKind, id)
+	return id, nil
 }
 
-func (core *Core) EditFilm(id int64, title string, description string, rating float64, releaseDate string, crew []int64) error {
-	if rating < variables.FilmRatingBegin || rating > variables.FilmRatingEnd {
-		core.logger.Error(variables.RatingSizeError)
-		return fmt.Errorf(variables.RatingSizeError)
+func (core *Core) GetFilm(ctx context.Context, id int64) (models.FilmItem, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	film, err := core.filmRepository.GetFilm(ctx, id)
+	if err != nil {
+		logger.Error(variables.FilmNotFoundError, "id", id, "error", err)
+		return models.FilmItem{}, err
+	}
+	return film, nil
+}
+
+// EditFilm validates only the fields the patch sets. The edit applies only
+// to the given version, zero matches any, and the new version is returned.
+func (core *Core) EditFilm(ctx context.Context, id int64, version int64, patch models.FilmPatch) (int64, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	if patch.Rating != nil && (*patch.Rating < variables.FilmRatingBegin || *patch.Rating > variables.FilmRatingEnd) {
+		logger.Warn(variables.RatingSizeError)
+		return 0, util.InvalidInputError(variables.RatingSizeError)
+	}
+
+	if patch.Title != nil {
+		err := util.ValidateStringSize(*patch.Title, variables.FilmTitleBegin, variables.FilmTitleEnd, variables.TitleSizeError, logger)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if patch.Description != nil {
+		err := util.ValidateStringSize(*patch.Description, variables.FilmDescriptionBegin, variables.FilmDescriptionEnd, variables.DescriptionSizeError, logger)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if patch.Crew != nil {
+		crew, err := normalizeCrew(*patch.Crew, logger)
+		if err != nil {
+			return 0, err
+		}
+		patch.Crew = &crew
 	}
 
-	err := util.ValidateStringSize(title, variables.FilmTitleBegin, variables.FilmTitleEnd, variables.TitleSizeError, core.logger)
+	err := normalizeMetadataPatch(&patch, logger)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	err = util.ValidateStringSize(description, variables.FilmDescriptionBegin, variables.FilmDescriptionEnd, variables.DescriptionSizeError, core.logger)
+	updated, err := core.filmRepository.EditFilm(ctx, id, version, patch)
 	if err != nil {
-		return err
+		logger.Error(variables.FilmNotEditedError, "error", err)
+		return 0, err
 	}
+	metrics.FilmsEditedTotal.Inc()
+	core.syncSearch(ctx, variables.SearchFilmKind, id)
+	return updated, nil
+}
 
-	err = core.filmRepository.EditFilm(id, title, description, rating, releaseDate, crew)
+func (core *Core) GetFilmRevisions(ctx context.Context, id int64, offset uint64, limit uint64) (communication.FilmRevisionsResponse, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	revisions, err := core.filmRepository.GetFilmRevisions(ctx, id, offset, limit)
 	if err != nil {
-		core.logger.Error(variables.FilmNotEditedError, err)
-		return err
+		logger.Error(variables.FilmRevisionsError, "id", id, "error", err)
+		return communication.FilmRevisionsResponse{}, err
 	}
-	return nil
+	return revisions, nil
+}
+
+// RevertFilm makes a new revision out of an earlier one, so a revert is
+// validated like any edit and can be reverted in turn.
+func (core *Core) RevertFilm(ctx context.Context, id int64, version int64, revision int64) (int64, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	filmRevision, err := core.filmRepository.GetFilmRevision(ctx, id, revision)
+	if err != nil {
+		logger.Error(variables.FilmRevertError, "id", id, "revision", revision, "error", err)
+		return 0, err
+	}
+
+	return core.EditFilm(ctx, id, version, models.FilmPatch{
+		Title:       &filmRevision.Title,
+		Description: &filmRevision.Description,
+		Rating:      &filmRevision.Rating,
+		ReleaseDate: &filmRevision.ReleaseDate,
+		Crew:        &filmRevision.Crew,
+
+		Genres:           &filmRevision.Genres,
+		Tags:             &filmRevision.Tags,
+		Countries:        &filmRevision.Countries,
+		Runtime:          &filmRevision.Runtime,
+		AgeRating:        &filmRevision.AgeRating,
+		OriginalLanguage: &filmRevision.OriginalLanguage,
+	})
 }
 
-func (core *Core) GetActors(begin uint64, end uint64) (communication.ActorsListResponse, error) {
-	actorsList, err := core.filmRepository.GetActors(begin, end)
+func (core *Core) GetActors(ctx context.Context, offset uint64, limit uint64) (communication.ActorsListResponse, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	actorsList, err := core.filmRepository.GetActors(ctx, offset, limit)
 	if err != nil {
-		core.logger.Error(variables.ActorsNotFoundError, err)
+		logger.Error(variables.ActorsNotFoundError, "error", err)
 		return communication.ActorsListResponse{}, err
 	}
 	return actorsList, nil
 }
 
-func (core *Core) AddActor(name string, gender string, birthdate string) error {
-	err := util.ValidateStringSize(name, variables.ActorNameBegin, variables.ActorNameEnd, variables.ActorNameSizeError, core.logger)
+func (core *Core) AddActor(ctx context.Context, name string, gender string, birthdate string) (int64, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	err := util.ValidateStringSize(name, variables.ActorNameBegin, variables.ActorNameEnd, variables.ActorNameSizeError, logger)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	err = core.filmRepository.AddActor(name, gender, birthdate)
+	id, err := core.filmRepository.AddActor(ctx, name, gender, birthdate)
 	if err != nil {
-		core.logger.Error(variables.ActorNotAddedError, err)
-		return err
+		logger.Error(variables.ActorNotAddedError, "error", err)
+		return 0, err
 	}
-	return nil
+	metrics.ActorsAddedTotal.Inc()
+	core.syncSearch(ctx, variables.SearchActorKind, id)
+	return id, nil
 }
 
-func (core *Core) EditActor(id int64, name string, gender string, birthdate string, films []int64) error {
-	err := util.ValidateStringSize(name, variables.ActorNameBegin, variables.ActorNameEnd, variables.ActorNameSizeError, core.logger)
+func (core *Core) GetActor(ctx context.Context, id int64) (models.ActorItem, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	actor, err := core.filmRepository.GetActor(ctx, id)
 	if err != nil {
-		return err
+		logger.Error(variables.ActorNotFoundError, "id", id, "error", err)
+		return models.ActorItem{}, err
+	}
+	return actor, nil
+}
+
+// EditActor validates only the fields the patch sets and, like EditFilm,
+// applies to the given version.
+func (core *Core) EditActor(ctx context.Context, id int64, version int64, patch models.ActorPatch) (int64, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	if patch.Name != nil {
+		err := util.ValidateStringSize(*patch.Name, variables.ActorNameBegin, variables.ActorNameEnd, variables.ActorNameSizeError, logger)
+		if err != nil {
+			return 0, err
+		}
 	}
 
-	err = core.filmRepository.EditActor(id, name, gender, birthdate, films)
+	filmIds := core.actorFilmIds(ctx, id)
+	updated, err := core.filmRepository.EditActor(ctx, id, version, patch)
 	if err != nil {
-		core.logger.Error(variables.ActorNotEditedError, err)
-		return err
+		logger.Error(variables.ActorNotEditedError, "error", err)
+		return 0, err
 	}
-	return nil
+	metrics.ActorsEditedTotal.Inc()
+	core.syncSearchActor(ctx, id, filmIds)
+	return updated, nil
 }
 
-func (core *Core) DeleteActor(id int64) error {
-	err := core.filmRepository.DeleteActor(id)
+func (core *Core) DeleteActor(ctx context.Context, id int64, version int64) error {
+	logger := util.ContextLogger(ctx, core.logger)
+	filmIds := core.actorFilmIds(ctx, id)
+	err := core.filmRepository.DeleteActor(ctx, id, version)
 	if err != nil {
-		core.logger.Error(variables.ActorNotDeletedError, err)
+		logger.Error(variables.ActorNotDeletedError, "error", err)
 		return err
 	}
+	metrics.ActorsDeletedTotal.Inc()
+	core.syncSearchActor(ctx, id, filmIds)
 	return nil
 }
 
-func (core *Core) DeleteFilm(id int64) error {
-	err := core.filmRepository.DeleteFilm(id)
+func (core *Core) DeleteFilm(ctx context.Context, id int64, version int64) error {
+	logger := util.ContextLogger(ctx, core.logger)
+	err := core.filmRepository.DeleteFilm(ctx, id, version)
 	if err != nil {
-		core.logger.Error(variables.FilmNotDeletedError, err)
+		logger.Error(variables.FilmNotDeletedError, "error", err)
 		return err
 	}
+	metrics.FilmsDeletedTotal.Inc()
+	core.syncSearch(ctx, variables.SearchFilmKind, id)
 	return nil
 }
 
-func (core *Core) GetUserRole(ctx context.Context, id int64) (string, error) {
-	grpcRequest := authorization.RoleRequest{Id: id}
+func (core *Core) GetAudit(ctx context.Context, filter models.AuditFilter, offset uint64, limit uint64) (communication.AuditListResponse, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	entries, err := core.filmRepository.GetAudit(ctx, filter, offset, limit)
+	if err != nil {
+		logger.Error(variables.AuditListError, "error", err)
+		return communication.AuditListResponse{}, err
+	}
+	return entries, nil
+}
 
-	grpcResponse, err := core.client.GetRole(ctx, &grpcRequest)
+func (core *Core) GetUserRole(ctx context.Context, id int64) (string, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	role, err := core.identities.GetUserRole(ctx, id)
 	if err != nil {
-		core.logger.Error(variables.GrpcRecievError, err)
-		return "", fmt.Errorf(variables.GrpcRecievError, err)
+		logger.Error(variables.GetProfileRoleError, "error", err)
+		return "", err
 	}
-	return grpcResponse.GetRole(), nil
+	return role, nil
 }
 
 func (core *Core) GetUserId(ctx context.Context, sid string) (int64, error) {
-	grpcRequest := authorization.FindIdRequest{Sid: sid}
-
-	grpcResponse, err := core.client.GetId(ctx, &grpcRequest)
+	logger := util.ContextLogger(ctx, core.logger)
+	id, err := core.identities.GetUserId(ctx, sid)
 	if err != nil {
-		core.logger.Error(variables.GrpcRecievError, err)
-		return 0, fmt.Errorf(variables.GrpcRecievError, err)
+		logger.Error(variables.GetProfileError, "error", err)
+		return 0, err
 	}
-	return grpcResponse.Value, nil
+	return id, nil
 }
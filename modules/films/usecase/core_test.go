@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"filmoteka/modules/films/identity"
+	"filmoteka/modules/films/search"
+	"filmoteka/pkg/middleware"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/variables"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeRepository serves the calls a test makes, any other call panics on
+// the nil embedded interface
+type fakeRepository struct {
+	IFilmRepository
+}
+
+func getTestCore(repository IFilmRepository, identities IdentityProvider) *Core {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return GetCore(repository, identities, search.GetMemoryIndex(), logger)
+}
+
+func getTestIdentities() *identity.MemoryIdentityProvider {
+	identities := identity.GetMemoryIdentityProvider()
+	identities.SetSession("admin-session", 1)
+	identities.SetRole(1, variables.AdminRole)
+	identities.SetSession("user-session", 2)
+	identities.SetRole(2, variables.UserRole)
+	identities.SetSession("roleless-session", 3)
+	return identities
+}
+
+func TestCorePermissions(t *testing.T) {
+	identities := getTestIdentities()
+	core := getTestCore(&fakeRepository{}, identities)
+	permissions := func() map[string]string {
+		return map[string]string{variables.PermissionAuditRead: variables.UserRole}
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	guarded := func(permission string) http.Handler {
+		ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+		return middleware.AuthorizationMiddleware(
+			middleware.PermissionsMiddleware(ok, core, permission, permissions, logger), core, logger)
+	}
+
+	tests := []struct {
+		name       string
+		session    string
+		permission string
+		status     int
+	}{
+		{"admin passes an unmapped permission", "admin-session", variables.PermissionCatalogWrite, http.StatusOK},
+		{"user is refused an unmapped permission", "user-session", variables.PermissionCatalogWrite, http.StatusForbidden},
+		{"user passes a permission mapped to users", "user-session", variables.PermissionAuditRead, http.StatusOK},
+		{"admin passes a permission mapped to users", "admin-session", variables.PermissionAuditRead, http.StatusOK},
+		{"unknown session", "gone-session", variables.PermissionAuditRead, http.StatusUnauthorized},
+		{"session without a role", "roleless-session", variables.PermissionAuditRead, http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.AddCookie(&http.Cookie{Name: variables.SessionCookieName, Value: test.session})
+			recorder := httptest.NewRecorder()
+
+			guarded(test.permission).ServeHTTP(recorder, request)
+
+			if recorder.Code != test.status {
+				t.Errorf("status = %d, want %d", recorder.Code, test.status)
+			}
+		})
+	}
+}
+
+func TestCoreFollowsIdentityChanges(t *testing.T) {
+	identities := getTestIdentities()
+	core := getTestCore(&fakeRepository{}, identities)
+	ctx := context.Background()
+
+	identities.SetRole(2, variables.AdminRole)
+	role, err := core.GetUserRole(ctx, 2)
+	if err != nil || role != variables.AdminRole {
+		t.Errorf("GetUserRole = %q, %v, want %q", role, err, variables.AdminRole)
+	}
+
+	identities.DeleteSession("user-session")
+	_, err = core.GetUserId(ctx, "user-session")
+	if err == nil {
+		t.Error("GetUserId found a deleted session")
+	}
+}
+
+type revertRepository struct {
+	fakeRepository
+	revision models.FilmRevision
+	edits    []models.FilmPatch
+	versions []int64
+}
+
+func (repository *revertRepository) GetFilmRevision(ctx context.Context, id int64, revision int64) (models.FilmRevision, error) {
+	if revision != repository.revision.Revision {
+		return models.FilmRevision{}, variables.ErrNotFound
+	}
+	return repository.revision, nil
+}
+
+func (repository *revertRepository) EditFilm(ctx context.Context, id int64, version int64, patch models.FilmPatch) (int64, error) {
+	repository.edits = append(repository.edits, patch)
+	repository.versions = append(repository.versions, version)
+	return version + 1, nil
+}
+
+func (repository *revertRepository) GetSearchDocuments(ctx context.Context, kind string, ids []int64) ([]models.SearchDocument, error) {
+	return nil, nil
+}
+
+func getTestRevision() models.FilmRevision {
+	return models.FilmRevision{
+		Revision:    2,
+		Title:       "Solaris",
+		Description: "A psychologist is sent to a station orbiting a distant planet",
+		Rating:      8,
+		ReleaseDate: "1972-03-20",
+		Crew:        []models.CrewMember{{ActorId: 3, Role: variables.CrewRoleDirector}},
+		FilmMetadata: models.FilmMetadata{
+			Genres:    []string{"drama"},
+			Tags:      []string{},
+			Countries: []string{},
+			Runtime:   167,
+		},
+	}
+}
+
+func TestRevertFilmEditsToTheRevision(t *testing.T) {
+	revision := getTestRevision()
+	repository := &revertRepository{revision: revision}
+	core := getTestCore(repository, getTestIdentities())
+
+	version, err := core.RevertFilm(context.Background(), 1, 5, revision.Revision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 6 || len(repository.versions) != 1 || repository.versions[0] != 5 {
+		t.Errorf("reverted version 5 to %d with edits at %v, want one edit making 6", version, repository.versions)
+	}
+
+	patch := repository.edits[0]
+	reverted := models.FilmRevision{
+		Revision:    revision.Revision,
+		Title:       *patch.Title,
+		Description: *patch.Description,
+		Rating:      *patch.Rating,
+		ReleaseDate: *patch.ReleaseDate,
+		Crew:        *patch.Crew,
+		FilmMetadata: models.FilmMetadata{
+			Genres:           *patch.Genres,
+			Tags:             *patch.Tags,
+			Countries:        *patch.Countries,
+			Runtime:          *patch.Runtime,
+			AgeRating:        *patch.AgeRating,
+			OriginalLanguage: *patch.OriginalLanguage,
+		},
+	}
+	if !reflect.DeepEqual(reverted, revision) {
+		t.Errorf("edit = %+v, want the revision %+v", reverted, revision)
+	}
+}
+
+func TestRevertFilmIsValidatedLikeEditFilm(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(revision *models.FilmRevision)
+		err    string
+	}{
+		{"rating out of range", func(revision *models.FilmRevision) { revision.Rating = 11 }, variables.RatingSizeError},
+		{"empty title", func(revision *models.FilmRevision) { revision.Title = "" }, variables.TitleSizeError},
+		{"unknown crew role", func(revision *models.FilmRevision) { revision.Crew[0].Role = "caterer" }, variables.CrewRoleError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			revision := getTestRevision()
+			test.change(&revision)
+			repository := &revertRepository{revision: revision}
+			core := getTestCore(repository, getTestIdentities())
+
+			_, err := core.RevertFilm(context.Background(), 1, 0, revision.Revision)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("err = %v, want %s", err, test.err)
+			}
+			if len(repository.edits) != 0 {
+				t.Error("an invalid revision reached the repository")
+			}
+		})
+	}
+}
+
+func TestRevertFilmToMissingRevision(t *testing.T) {
+	repository := &revertRepository{revision: getTestRevision()}
+	core := getTestCore(repository, getTestIdentities())
+
+	_, err := core.RevertFilm(context.Background(), 1, 0, 9)
+	if !errors.Is(err, variables.ErrNotFound) {
+		t.Errorf("err = %v, want %v", err, variables.ErrNotFound)
+	}
+	if len(repository.edits) != 0 {
+		t.Error("a missing revision was applied")
+	}
+}
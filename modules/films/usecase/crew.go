@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/util"
+	"filmoteka/pkg/variables"
+	"log/slog"
+	"slices"
+)
+
+// normalizeCrew checks the crew members and returns them with the actor
+// role filled in where it was left out.
+func normalizeCrew(crew []models.CrewMember, logger *slog.Logger) ([]models.CrewMember, error) {
+	type credit struct {
+		actorId int64
+		role    string
+	}
+
+	normalized := make([]models.CrewMember, 0, len(crew))
+	seen := make(map[credit]bool, len(crew))
+	for _, member := range crew {
+		if member.Role == "" {
+			member.Role = variables.CrewRoleActor
+		}
+		if !slices.Contains(variables.CrewRoles, member.Role) {
+			logger.Warn(variables.CrewRoleError, "role", member.Role)
+			return nil, util.InvalidInputError(variables.CrewRoleError)
+		}
+
+		err := util.ValidateStringSize(member.CharacterName, variables.CharacterNameBegin, variables.CharacterNameEnd, variables.CharacterNameSizeError, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		if member.BillingOrder < 0 {
+			logger.Warn(variables.BillingOrderError)
+			return nil, util.InvalidInputError(variables.BillingOrderError)
+		}
+
+		key := credit{actorId: member.ActorId, role: member.Role}
+		if seen[key] {
+			logger.Warn(variables.CrewDuplicateError, "actor_id", member.ActorId, "role", member.Role)
+			return nil, util.InvalidInputError(variables.CrewDuplicateError)
+		}
+		seen[key] = true
+
+		normalized = append(normalized, member)
+	}
+	return normalized, nil
+}
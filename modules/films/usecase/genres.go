@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	communication "filmoteka/pkg/requests"
+	"filmoteka/pkg/util"
+	"filmoteka/pkg/variables"
+	"log/slog"
+	"strings"
+)
+
+func (core *Core) GetGenres(ctx context.Context) (communication.GenresListResponse, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	genres, err := core.filmRepository.GetGenres(ctx)
+	if err != nil {
+		logger.Error(variables.GenresListError, "error", err)
+		return communication.GenresListResponse{}, err
+	}
+	return genres, nil
+}
+
+func (core *Core) AddGenre(ctx context.Context, slug string, name string) (int64, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	slug, err := validateGenre(slug, name, logger)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := core.filmRepository.AddGenre(ctx, slug, name)
+	if err != nil {
+		logger.Error(variables.GenreNotAddedError, "slug", slug, "error", err)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (core *Core) EditGenre(ctx context.Context, id int64, slug string, name string) error {
+	logger := util.ContextLogger(ctx, core.logger)
+	slug, err := validateGenre(slug, name, logger)
+	if err != nil {
+		return err
+	}
+
+	err = core.filmRepository.EditGenre(ctx, id, slug, name)
+	if err != nil {
+		logger.Error(variables.GenreNotEditedError, "id", id, "error", err)
+		return err
+	}
+
+	// Films are indexed with genre slugs
+	filmIds, err := core.filmRepository.GetGenreFilmIds(ctx, id)
+	if err != nil {
+		logger.Error(variables.SearchIndexSyncError, "genre", id, "error", err)
+		return nil
+	}
+	core.syncSearch(ctx, variables.SearchFilmKind, filmIds...)
+	return nil
+}
+
+func (core *Core) DeleteGenre(ctx context.Context, id int64) error {
+	logger := util.ContextLogger(ctx, core.logger)
+	err := core.filmRepository.DeleteGenre(ctx, id)
+	if err != nil {
+		logger.Error(variables.GenreNotDeletedError, "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+// validateGenre returns the slug lowercased, as films refer to it
+func validateGenre(slug string, name string, logger *slog.Logger) (string, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if !validGenreSlug(slug) {
+		logger.Warn(variables.GenreSlugError, "slug", slug)
+		return "", util.InvalidInputError(variables.GenreSlugError)
+	}
+
+	err := util.ValidateStringSize(name, variables.GenreNameBegin, variables.GenreNameEnd, variables.GenreNameSizeError, logger)
+	if err != nil {
+		return "", err
+	}
+	return slug, nil
+}
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/util"
+	"filmoteka/pkg/variables"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	genreSlugPattern    = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	countryCodePattern  = regexp.MustCompile(`^[A-Z]{2}$`)
+	languageCodePattern = regexp.MustCompile(`^[a-z]{2}$`)
+)
+
+func validGenreSlug(slug string) bool {
+	return len(slug) <= variables.GenreSlugEnd && genreSlugPattern.MatchString(slug)
+}
+
+func validTag(tag string) bool {
+	length := utf8.RuneCountInString(tag)
+	return length >= variables.TagBegin && length <= variables.TagEnd
+}
+
+// normalizeLabels brings labels to their stored form and returns them
+// sorted and without duplicates, so that states compare equal.
+func normalizeLabels(labels []string, normalize func(string) string, valid func(string) bool, message string, logger *slog.Logger) ([]string, error) {
+	normalized := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label = normalize(strings.TrimSpace(label))
+		if !valid(label) {
+			logger.Warn(message, "value", label)
+			return nil, util.InvalidInputError(message)
+		}
+		normalized = append(normalized, label)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
+
+func normalizeGenres(genres []string, logger *slog.Logger) ([]string, error) {
+	return normalizeLabels(genres, strings.ToLower, validGenreSlug, variables.GenreSlugError, logger)
+}
+
+func normalizeTags(tags []string, logger *slog.Logger) ([]string, error) {
+	return normalizeLabels(tags, strings.ToLower, validTag, variables.TagSizeError, logger)
+}
+
+func normalizeCountries(countries []string, logger *slog.Logger) ([]string, error) {
+	return normalizeLabels(countries, strings.ToUpper, countryCodePattern.MatchString, variables.CountryCodeError, logger)
+}
+
+func validateRuntime(runtime int, logger *slog.Logger) error {
+	if runtime < 0 {
+		logger.Warn(variables.RuntimeError)
+		return util.InvalidInputError(variables.RuntimeError)
+	}
+	return nil
+}
+
+func normalizeAgeRating(ageRating string, logger *slog.Logger) (string, error) {
+	ageRating = strings.TrimSpace(ageRating)
+	err := util.ValidateStringSize(ageRating, variables.AgeRatingBegin, variables.AgeRatingEnd, variables.AgeRatingSizeError, logger)
+	return ageRating, err
+}
+
+// normalizeLanguage accepts an empty language for films where it is unknown
+func normalizeLanguage(language string, logger *slog.Logger) (string, error) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language != "" && !languageCodePattern.MatchString(language) {
+		logger.Warn(variables.LanguageCodeError, "value", language)
+		return "", util.InvalidInputError(variables.LanguageCodeError)
+	}
+	return language, nil
+}
+
+func normalizeMetadata(metadata models.FilmMetadata, logger *slog.Logger) (models.FilmMetadata, error) {
+	var err error
+	metadata.Genres, err = normalizeGenres(metadata.Genres, logger)
+	if err != nil {
+		return models.FilmMetadata{}, err
+	}
+
+	metadata.Tags, err = normalizeTags(metadata.Tags, logger)
+	if err != nil {
+		return models.FilmMetadata{}, err
+	}
+
+	metadata.Countries, err = normalizeCountries(metadata.Countries, logger)
+	if err != nil {
+		return models.FilmMetadata{}, err
+	}
+
+	err = validateRuntime(metadata.Runtime, logger)
+	if err != nil {
+		return models.FilmMetadata{}, err
+	}
+
+	metadata.AgeRating, err = normalizeAgeRating(metadata.AgeRating, logger)
+	if err != nil {
+		return models.FilmMetadata{}, err
+	}
+
+	metadata.OriginalLanguage, err = normalizeLanguage(metadata.OriginalLanguage, logger)
+	if err != nil {
+		return models.FilmMetadata{}, err
+	}
+	return metadata, nil
+}
+
+// normalizeMetadataPatch is normalizeMetadata for the fields a patch sets
+func normalizeMetadataPatch(patch *models.FilmPatch, logger *slog.Logger) error {
+	if patch.Genres != nil {
+		genres, err := normalizeGenres(*patch.Genres, logger)
+		if err != nil {
+			return err
+		}
+		patch.Genres = &genres
+	}
+
+	if patch.Tags != nil {
+		tags, err := normalizeTags(*patch.Tags, logger)
+		if err != nil {
+			return err
+		}
+		patch.Tags = &tags
+	}
+
+	if patch.Countries != nil {
+		countries, err := normalizeCountries(*patch.Countries, logger)
+		if err != nil {
+			return err
+		}
+		patch.Countries = &countries
+	}
+
+	if patch.Runtime != nil {
+		err := validateRuntime(*patch.Runtime, logger)
+		if err != nil {
+			return err
+		}
+	}
+
+	if patch.AgeRating != nil {
+		ageRating, err := normalizeAgeRating(*patch.AgeRating, logger)
+		if err != nil {
+			return err
+		}
+		patch.AgeRating = &ageRating
+	}
+
+	if patch.OriginalLanguage != nil {
+		language, err := normalizeLanguage(*patch.OriginalLanguage, logger)
+		if err != nil {
+			return err
+		}
+		patch.OriginalLanguage = &language
+	}
+	return nil
+}
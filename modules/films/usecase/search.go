@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/util"
+	"filmoteka/pkg/variables"
+	"slices"
+)
+
+// searchFilms queries the index and loads the films it hits, skipping the
+// ones gone from the catalogue since they were indexed
+func (core *Core) searchFilms(ctx context.Context, query models.SearchQuery) ([]models.FilmSearchItem, models.SearchResult, error) {
+	query.Kind = variables.SearchFilmKind
+	result, err := core.searchIndex.Query(ctx, query)
+	if err != nil {
+		return nil, models.SearchResult{}, err
+	}
+
+	films, err := core.filmRepository.GetFilmShortItems(ctx, hitIds(result.Hits))
+	if err != nil {
+		return nil, models.SearchResult{}, err
+	}
+
+	items := make([]models.FilmSearchItem, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		film, found := films[hit.Id]
+		if found {
+			items = append(items, models.FilmSearchItem{FilmShortItem: film, Score: hit.Score, Snippet: hit.Snippet})
+		}
+	}
+	return items, result, nil
+}
+
+func (core *Core) searchActors(ctx context.Context, query models.SearchQuery) ([]models.ActorSearchItem, models.SearchResult, error) {
+	query.Kind = variables.SearchActorKind
+	result, err := core.searchIndex.Query(ctx, query)
+	if err != nil {
+		return nil, models.SearchResult{}, err
+	}
+
+	actors, err := core.filmRepository.GetActorShortItems(ctx, hitIds(result.Hits))
+	if err != nil {
+		return nil, models.SearchResult{}, err
+	}
+
+	items := make([]models.ActorSearchItem, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		actor, found := actors[hit.Id]
+		if found {
+			items = append(items, models.ActorSearchItem{ActorShortItem: actor, Score: hit.Score, Snippet: hit.Snippet})
+		}
+	}
+	return items, result, nil
+}
+
+func hitIds(hits []models.SearchHit) []int64 {
+	ids := make([]int64, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.Id
+	}
+	return ids
+}
+
+// syncSearch indexes the live documents with the given ids again and drops
+// the ones that left the catalogue. A failed sync doesn't fail the write,
+// a rebuild catches the index up.
+func (core *Core) syncSearch(ctx context.Context, kind string, ids ...int64) {
+	if len(ids) == 0 {
+		return
+	}
+
+	core.searchMutex.Lock()
+	defer core.searchMutex.Unlock()
+
+	logger := util.ContextLogger(ctx, core.logger)
+	documents, err := core.filmRepository.GetSearchDocuments(ctx, kind, ids)
+	if err != nil {
+		logger.Error(variables.SearchIndexSyncError, "kind", kind, "ids", ids, "error", err)
+		return
+	}
+
+	live := make(map[int64]bool, len(documents))
+	for _, document := range documents {
+		live[document.Id] = true
+		err := core.searchIndex.Index(ctx, document)
+		if err != nil {
+			logger.Error(variables.SearchIndexSyncError, "kind", kind, "id", document.Id, "error", err)
+		}
+	}
+
+	for _, id := range ids {
+		if live[id] {
+			continue
+		}
+		err := core.searchIndex.Delete(ctx, kind, id)
+		if err != nil {
+			logger.Error(variables.SearchIndexSyncError, "kind", kind, "id", id, "error", err)
+		}
+	}
+}
+
+// syncSearchActor indexes the actor again along with the films they were
+// and are credited in, as films are found by their crew names
+func (core *Core) syncSearchActor(ctx context.Context, id int64, filmIds []int64) {
+	core.syncSearch(ctx, variables.SearchActorKind, id)
+
+	filmIds = append(filmIds, core.actorFilmIds(ctx, id)...)
+	slices.Sort(filmIds)
+	core.syncSearch(ctx, variables.SearchFilmKind, slices.Compact(filmIds)...)
+}
+
+func (core *Core) actorFilmIds(ctx context.Context, id int64) []int64 {
+	filmIds, err := core.filmRepository.GetActorFilmIds(ctx, id)
+	if err != nil {
+		util.ContextLogger(ctx, core.logger).Error(variables.SearchIndexSyncError, "actor", id, "error", err)
+	}
+	return filmIds
+}
+
+// RebuildSearchIndex indexes the whole catalogue anew and returns the
+// number of indexed films and actors
+func (core *Core) RebuildSearchIndex(ctx context.Context) (int, int, error) {
+	core.searchMutex.Lock()
+	defer core.searchMutex.Unlock()
+
+	logger := util.ContextLogger(ctx, core.logger)
+	films, err := core.filmRepository.GetSearchDocuments(ctx, variables.SearchFilmKind, nil)
+	if err != nil {
+		logger.Error(variables.SearchIndexRebuildError, "error", err)
+		return 0, 0, err
+	}
+
+	actors, err := core.filmRepository.GetSearchDocuments(ctx, variables.SearchActorKind, nil)
+	if err != nil {
+		logger.Error(variables.SearchIndexRebuildError, "error", err)
+		return 0, 0, err
+	}
+
+	err = core.searchIndex.Rebuild(ctx, append(films, actors...))
+	if err != nil {
+		logger.Error(variables.SearchIndexRebuildError, "error", err)
+		return 0, 0, err
+	}
+
+	logger.Info(variables.SearchIndexRebuiltMessage, "films", len(films), "actors", len(actors))
+	return len(films), len(actors), nil
+}
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"context"
+	"filmoteka/pkg/metrics"
+	communication "filmoteka/pkg/requests"
+	"filmoteka/pkg/util"
+	"filmoteka/pkg/variables"
+	"time"
+)
+
+func (core *Core) GetTrash(ctx context.Context, offset uint64, limit uint64) (communication.TrashListResponse, error) {
+	logger := util.ContextLogger(ctx, core.logger)
+	trash, err := core.filmRepository.GetTrash(ctx, offset, limit)
+	if err != nil {
+		logger.Error(variables.TrashListError, "error", err)
+		return communication.TrashListResponse{}, err
+	}
+	return trash, nil
+}
+
+func (core *Core) RestoreFromTrash(ctx context.Context, itemType string, id int64) error {
+	logger := util.ContextLogger(ctx, core.logger)
+	var err error
+	switch itemType {
+	case variables.TrashFilmType:
+		err = core.filmRepository.RestoreFilm(ctx, id)
+	case variables.TrashActorType:
+		err = core.filmRepository.RestoreActor(ctx, id)
+	default:
+		logger.Warn(variables.TrashItemTypeError, "type", itemType)
+		return util.InvalidInputError(variables.TrashItemTypeError)
+	}
+
+	if err != nil {
+		logger.Error(variables.TrashRestoreError, "type", itemType, "id", id, "error", err)
+		return err
+	}
+
+	if itemType == variables.TrashActorType {
+		core.syncSearchActor(ctx, id, nil)
+	} else {
+		core.syncSearch(ctx, variables.SearchFilmKind, id)
+	}
+	return nil
+}
+
+func (core *Core) PurgeFromTrash(ctx context.Context, itemType string, id int64) error {
+	logger := util.ContextLogger(ctx, core.logger)
+	var err error
+	switch itemType {
+	case variables.TrashFilmType:
+		err = core.filmRepository.PurgeFilm(ctx, id)
+	case variables.TrashActorType:
+		err = core.filmRepository.PurgeActor(ctx, id)
+	default:
+		logger.Warn(variables.TrashItemTypeError, "type", itemType)
+		return util.InvalidInputError(variables.TrashItemTypeError)
+	}
+
+	if err != nil {
+		logger.Error(variables.TrashPurgeError, "type", itemType, "id", id, "error", err)
+		return err
+	}
+	metrics.TrashPurgedTotal.WithLabelValues(itemType).Inc()
+	return nil
+}
+
+// RunTrashRetention purges expired trash every interval until ctx is done.
+// The retention is read on each run, so a reloaded value applies at once.
+// A zero retention keeps the trash forever. Trashed items already left the
+// search index, so purging them leaves it as it is.
+func (core *Core) RunTrashRetention(ctx context.Context, interval time.Duration, retention func() time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			keep := retention()
+			if keep <= 0 {
+				continue
+			}
+
+			films, actors, err := core.filmRepository.PurgeTrash(ctx, time.Now().Add(-keep))
+			if err != nil {
+				core.logger.Error(variables.TrashRetentionError, "error", err)
+				continue
+			}
+
+			metrics.TrashPurgedTotal.WithLabelValues(variables.TrashFilmType).Add(float64(films))
+			metrics.TrashPurgedTotal.WithLabelValues(variables.TrashActorType).Add(float64(actors))
+			if films > 0 || actors > 0 {
+				core.logger.Info(variables.TrashPurgedMessage, "films", films, "actors", actors)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/variables"
+	"testing"
+	"time"
+)
+
+type trashRepository struct {
+	fakeRepository
+	restored  []int64
+	documents []models.SearchDocument
+	purges    chan time.Time
+}
+
+func (repository *trashRepository) RestoreFilm(ctx context.Context, id int64) error {
+	repository.restored = append(repository.restored, id)
+	return nil
+}
+
+func (repository *trashRepository) GetSearchDocuments(ctx context.Context, kind string, ids []int64) ([]models.SearchDocument, error) {
+	return repository.documents, nil
+}
+
+func (repository *trashRepository) PurgeTrash(ctx context.Context, before time.Time) (int64, int64, error) {
+	select {
+	case repository.purges <- before:
+	case <-ctx.Done():
+	}
+	return 1, 0, nil
+}
+
+func TestRestoreFromTrashReindexesFilm(t *testing.T) {
+	repository := &trashRepository{documents: []models.SearchDocument{
+		{Kind: variables.SearchFilmKind, Id: 4, Title: "Stalker"},
+	}}
+	core := getTestCore(repository, getTestIdentities())
+	ctx := context.Background()
+
+	err := core.RestoreFromTrash(ctx, variables.TrashFilmType, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repository.restored) != 1 || repository.restored[0] != 4 {
+		t.Errorf("restored = %v, want [4]", repository.restored)
+	}
+
+	result, err := core.searchIndex.Query(ctx, models.SearchQuery{Kind: variables.SearchFilmKind, Text: "stalker", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Hits) != 1 || result.Hits[0].Id != 4 {
+		t.Errorf("search hits = %+v, want the restored film", result.Hits)
+	}
+}
+
+func TestRestoreFromTrashRejectsUnknownType(t *testing.T) {
+	repository := &trashRepository{}
+	core := getTestCore(repository, getTestIdentities())
+
+	err := core.RestoreFromTrash(context.Background(), "genre", 4)
+	if err == nil || err.Error() != variables.TrashItemTypeError {
+		t.Errorf("err = %v, want %s", err, variables.TrashItemTypeError)
+	}
+	if len(repository.restored) != 0 {
+		t.Errorf("restored = %v, want nothing", repository.restored)
+	}
+}
+
+func TestRunTrashRetention(t *testing.T) {
+	repository := &trashRepository{purges: make(chan time.Time)}
+	core := getTestCore(repository, getTestIdentities())
+	ctx, cancel := context.WithCancel(context.Background())
+
+	retentions := make(chan time.Duration, 1)
+	retentions <- 0
+	retention := func() time.Duration {
+		select {
+		case keep := <-retentions:
+			return keep
+		default:
+			return time.Hour
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		core.RunTrashRetention(ctx, time.Millisecond, retention)
+	}()
+
+	// the first run reads a zero retention and keeps everything, a purge
+	// made by it would ask for trash older than now
+	select {
+	case before := <-repository.purges:
+		if age := time.Since(before); age < time.Hour || age > time.Hour+time.Minute {
+			t.Errorf("purged trash older than %v, want an hour", age)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunTrashRetention never purged")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunTrashRetention did not return after ctx was done")
+	}
+}
@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/variables"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// IExecer is implemented by *sql.Tx and tracing.DB, so entries can be
+// recorded in the transaction of the change they describe.
+type IExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type IQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+type Entry struct {
+	Action   string
+	Entity   string
+	EntityId int64
+	// ActorId falls back to the authorized user of the request
+	ActorId int64
+	Before  any
+	After   any
+}
+
+// Record appends entry to the audit log with the request id and the
+// fields that differ between its before and after states.
+func Record(ctx context.Context, exec IExecer, entry Entry) error {
+	changes, err := diff(entry.Before, entry.After)
+	if err != nil {
+		return fmt.Errorf("%s: %w", variables.AuditRecordError, err)
+	}
+
+	actorId := sql.NullInt64{Int64: entry.ActorId, Valid: entry.ActorId != 0}
+	if !actorId.Valid {
+		actorId.Int64, actorId.Valid = ctx.Value(variables.UserIDKey).(int64)
+	}
+	requestId, _ := ctx.Value(variables.RequestIDKey).(string)
+
+	_, err = exec.ExecContext(ctx, `
+    INSERT INTO audit_log (actor_id, action, entity, entity_id, changes, request_id)
+    VALUES ($1, $2, $3, $4, $5::jsonb, NULLIF($6, ''))`,
+		actorId, entry.Action, entry.Entity, entry.EntityId, string(changes), requestId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", variables.AuditRecordError, err)
+	}
+	return nil
+}
+
+// diff compares the JSON forms of the two states field by field. A nil
+// state stands for an entity that does not exist on that side.
+func diff(before any, after any) ([]byte, error) {
+	beforeFields, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]models.AuditChange)
+	for name, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[name]) {
+			changes[name] = models.AuditChange{Before: value, After: afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = models.AuditChange{After: value}
+		}
+	}
+	return json.Marshal(changes)
+}
+
+func fields(state any) (map[string]any, error) {
+	fields := make(map[string]any)
+	if state == nil {
+		return fields, nil
+	}
+
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(encoded, &fields)
+	return fields, err
+}
+
+// List returns the entries matching filter, newest first.
+func List(ctx context.Context, db IQuerier, filter models.AuditFilter, offset uint64, limit uint64) ([]models.AuditEntry, error) {
+	var conditions []string
+	var args []any
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ActorId != 0 {
+		where("actor_id = $%d", filter.ActorId)
+	}
+	if filter.Action != "" {
+		where("action = $%d", filter.Action)
+	}
+	if filter.Entity != "" {
+		where("entity = $%d", filter.Entity)
+	}
+	if filter.EntityId != 0 {
+		where("entity_id = $%d", filter.EntityId)
+	}
+	if filter.RequestId != "" {
+		where("request_id = $%d", filter.RequestId)
+	}
+	if !filter.From.IsZero() {
+		where("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where("created_at < $%d", filter.To)
+	}
+
+	query := `SELECT id, actor_id, action, entity, entity_id, changes, COALESCE(request_id, ''), created_at FROM audit_log`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit, offset)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var actorId sql.NullInt64
+		var changes []byte
+
+		err := rows.Scan(&entry.Id, &actorId, &entry.Action, &entry.Entity, &entry.EntityId, &changes, &entry.RequestId, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if actorId.Valid {
+			entry.ActorId = &actorId.Int64
+		}
+		err = json.Unmarshal(changes, &entry.Changes)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/sqltest"
+	"filmoteka/pkg/variables"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testState struct {
+	Title  string   `json:"title"`
+	Rating float64  `json:"rating"`
+	Genres []string `json:"genres"`
+}
+
+func recordedEntry(t *testing.T, ctx context.Context, entry Entry) (sqltest.Statement, map[string]models.AuditChange) {
+	t.Helper()
+	db := sqltest.Open(nil)
+	defer db.Close()
+
+	err := Record(ctx, db, entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inserts := db.Find("INSERT INTO audit_log")
+	if len(inserts) != 1 {
+		t.Fatalf("recorded %d inserts, want 1", len(inserts))
+	}
+
+	var changes map[string]models.AuditChange
+	err = json.Unmarshal([]byte(inserts[0].Args[4].(string)), &changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inserts[0], changes
+}
+
+func TestRecordKeepsActorRequestAndChangedFields(t *testing.T) {
+	ctx := context.WithValue(context.Background(), variables.UserIDKey, int64(5))
+	ctx = context.WithValue(ctx, variables.RequestIDKey, "request-1")
+
+	insert, changes := recordedEntry(t, ctx, Entry{
+		Action:   variables.AuditActionUpdate,
+		Entity:   variables.AuditEntityFilm,
+		EntityId: 9,
+		Before:   testState{Title: "Solaris", Rating: 7, Genres: []string{"drama"}},
+		After:    testState{Title: "Solaris", Rating: 8, Genres: []string{"drama", "sci-fi"}},
+	})
+
+	want := []any{sql.NullInt64{Int64: 5, Valid: true}, variables.AuditActionUpdate, variables.AuditEntityFilm, int64(9)}
+	if !reflect.DeepEqual(insert.Args[:4], want) {
+		t.Errorf("args = %v, want %v", insert.Args[:4], want)
+	}
+	if insert.Args[5] != "request-1" {
+		t.Errorf("request id = %v, want request-1", insert.Args[5])
+	}
+
+	wantChanges := map[string]models.AuditChange{
+		"rating": {Before: 7.0, After: 8.0},
+		"genres": {Before: []any{"drama"}, After: []any{"drama", "sci-fi"}},
+	}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("changes = %v, want %v", changes, wantChanges)
+	}
+}
+
+func TestRecordCreationAndRemoval(t *testing.T) {
+	state := testState{Title: "Solaris", Rating: 7}
+
+	_, created := recordedEntry(t, context.Background(), Entry{After: state})
+	wantCreated := map[string]models.AuditChange{
+		"title":  {After: "Solaris"},
+		"rating": {After: 7.0},
+		"genres": {},
+	}
+	if !reflect.DeepEqual(created, wantCreated) {
+		t.Errorf("creation changes = %v, want %v", created, wantCreated)
+	}
+
+	_, removed := recordedEntry(t, context.Background(), Entry{Before: state})
+	wantRemoved := map[string]models.AuditChange{
+		"title":  {Before: "Solaris"},
+		"rating": {Before: 7.0},
+	}
+	if !reflect.DeepEqual(removed, wantRemoved) {
+		t.Errorf("removal changes = %v, want %v", removed, wantRemoved)
+	}
+}
+
+func TestRecordActorFallsBackToRequestUser(t *testing.T) {
+	ctx := context.WithValue(context.Background(), variables.UserIDKey, int64(5))
+
+	insert, _ := recordedEntry(t, ctx, Entry{ActorId: 3})
+	if insert.Args[0] != (sql.NullInt64{Int64: 3, Valid: true}) {
+		t.Errorf("explicit actor = %v, want 3", insert.Args[0])
+	}
+
+	insert, _ = recordedEntry(t, context.Background(), Entry{})
+	if insert.Args[0] != (sql.NullInt64{}) {
+		t.Errorf("actor without a user = %v, want NULL", insert.Args[0])
+	}
+	if insert.Args[5] != "" {
+		t.Errorf("request id without a request = %v, want empty", insert.Args[5])
+	}
+}
+
+func TestListFiltersAndPages(t *testing.T) {
+	db := sqltest.Open(nil)
+	defer db.Close()
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := List(context.Background(), db, models.AuditFilter{ActorId: 5, Entity: variables.AuditEntityFilm, From: from}, 20, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries := db.Find("FROM audit_log")
+	if len(queries) != 1 {
+		t.Fatalf("ran %d queries, want 1", len(queries))
+	}
+	wantQuery := `SELECT id, actor_id, action, entity, entity_id, changes, COALESCE(request_id, ''), created_at FROM audit_log` +
+		` WHERE actor_id = $1 AND entity = $2 AND created_at >= $3 ORDER BY id DESC LIMIT $4 OFFSET $5`
+	if queries[0].Query != wantQuery {
+		t.Errorf("query = %s, want %s", queries[0].Query, wantQuery)
+	}
+	wantArgs := []any{int64(5), variables.AuditEntityFilm, from, uint64(10), uint64(20)}
+	if !reflect.DeepEqual(queries[0].Args, wantArgs) {
+		t.Errorf("args = %v, want %v", queries[0].Args, wantArgs)
+	}
+}
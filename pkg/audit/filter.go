@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"errors"
+	"filmoteka/pkg/models"
+	"filmoteka/pkg/variables"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ParseFilter reads the audit filter from the query string. Times are
+// RFC 3339, from is inclusive and to is exclusive.
+func ParseFilter(r *http.Request) (models.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		Action:    query.Get(variables.AuditActionParam),
+		Entity:    query.Get(variables.AuditEntityParam),
+		RequestId: query.Get(variables.AuditRequestIdParam),
+	}
+
+	var errs []error
+	parseId := func(param string) int64 {
+		value := query.Get(param)
+		if value == "" {
+			return 0
+		}
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			errs = append(errs, fmt.Errorf("%s: %q", param, value))
+		}
+		return id
+	}
+	parseTime := func(param string) time.Time {
+		value := query.Get(param)
+		if value == "" {
+			return time.Time{}
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", param, err))
+		}
+		return parsed
+	}
+
+	filter.ActorId = parseId(variables.AuditActorParam)
+	filter.EntityId = parseId(variables.AuditEntityIdParam)
+	filter.From = parseTime(variables.AuditFromParam)
+	filter.To = parseTime(variables.AuditToParam)
+
+	return filter, errors.Join(errs...)
+}
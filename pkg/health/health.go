@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	communication "filmoteka/pkg/requests"
+	"filmoteka/pkg/util"
+	"filmoteka/pkg/variables"
+	"log/slog"
+	"net/http"
+)
+
+// Dependency status interface
+type IHealthChecker interface {
+	Name() string
+	CheckHealth(ctx context.Context) error
+}
+
+func LivenessHandler(logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		util.SendResponse(w, r, http.StatusOK, communication.HealthResponse{Status: variables.HealthStatusUp}, variables.StatusOkMessage, nil, logger)
+	})
+}
+
+func ReadinessHandler(checkers []IHealthChecker, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), variables.HealthCheckTimeout)
+		defer cancel()
+
+		response := communication.HealthResponse{
+			Status: variables.HealthStatusUp,
+			Checks: make(map[string]string, len(checkers)),
+		}
+
+		var checkErr error
+		for _, checker := range checkers {
+			err := checker.CheckHealth(ctx)
+			if err != nil {
+				response.Status = variables.HealthStatusDown
+				response.Checks[checker.Name()] = err.Error()
+				checkErr = err
+				continue
+			}
+			response.Checks[checker.Name()] = variables.HealthStatusUp
+		}
+
+		if checkErr != nil {
+			util.SendResponse(w, r, http.StatusServiceUnavailable, response, variables.ServiceNotReadyError, checkErr, logger)
+			return
+		}
+		util.SendResponse(w, r, http.StatusOK, response, variables.StatusOkMessage, nil, logger)
+	})
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	communication "filmoteka/pkg/requests"
+	"filmoteka/pkg/util"
+	"filmoteka/pkg/variables"
+	"log/slog"
+	"net/http"
+)
+
+// LevelsHandler changes the global or a module level at runtime. An empty
+// level with a module drops the override for that module.
+func LevelsHandler(levels *Levels, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var levelRequest communication.LogLevelRequest
+
+		err := util.GetRequestBody(w, r, &levelRequest, logger)
+		if err != nil {
+			return
+		}
+
+		switch {
+		case levelRequest.Level == "" && levelRequest.Module != "":
+			levels.ResetLevel(levelRequest.Module)
+		case levelRequest.Level == "":
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.LogLevelError, nil, logger)
+			return
+		default:
+			level, err := parseLevel(levelRequest.Level)
+			if err != nil {
+				util.SendResponse(w, r, http.StatusBadRequest, nil, variables.LogLevelError, err, logger)
+				return
+			}
+			levels.SetLevel(levelRequest.Module, level)
+		}
+
+		util.ContextLogger(r.Context(), logger).Info(variables.LogLevelChangedMessage, "module", levelRequest.Module, "level", levelRequest.Level)
+		util.SendResponse(w, r, http.StatusOK, levelsResponse(levels), variables.StatusOkMessage, nil, logger)
+	})
+}
+
+func levelsResponse(levels *Levels) communication.LogLevelsResponse {
+	global, modules := levels.Snapshot()
+	response := communication.LogLevelsResponse{
+		Level:   global.String(),
+		Modules: make(map[string]string, len(modules)),
+	}
+	for module, level := range modules {
+		response.Modules[module] = level.String()
+	}
+	return response
+}
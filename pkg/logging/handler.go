@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"context"
+	"filmoteka/pkg/variables"
+	"log/slog"
+)
+
+// moduleHandler filters records by the level of the module the logger was
+// tagged with through variables.ModuleLogger.
+type moduleHandler struct {
+	next   slog.Handler
+	levels *Levels
+	module string
+}
+
+func (handler *moduleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= handler.levels.Level(handler.module)
+}
+
+func (handler *moduleHandler) Handle(ctx context.Context, record slog.Record) error {
+	return handler.next.Handle(ctx, record)
+}
+
+func (handler *moduleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	module := handler.module
+	for _, attr := range attrs {
+		if attr.Key == variables.ModuleLogger {
+			module = attr.Value.String()
+		}
+	}
+	return &moduleHandler{next: handler.next.WithAttrs(attrs), levels: handler.levels, module: module}
+}
+
+func (handler *moduleHandler) WithGroup(name string) slog.Handler {
+	return &moduleHandler{next: handler.next.WithGroup(name), levels: handler.levels, module: handler.module}
+}
@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+type levelsState struct {
+	global  slog.Level
+	modules map[string]slog.Level
+}
+
+// Levels keeps the global level and per-module overrides. Reads happen on
+// every log call, so changes swap in a fresh copy instead of taking a lock.
+type Levels struct {
+	state atomic.Pointer[levelsState]
+}
+
+func GetLevels(global slog.Level, modules map[string]slog.Level) *Levels {
+	levels := &Levels{}
+	state := &levelsState{global: global, modules: make(map[string]slog.Level, len(modules))}
+	for module, level := range modules {
+		state.modules[module] = level
+	}
+	levels.state.Store(state)
+	return levels
+}
+
+func (levels *Levels) Level(module string) slog.Level {
+	state := levels.state.Load()
+	if level, ok := state.modules[module]; ok {
+		return level
+	}
+	return state.global
+}
+
+// SetLevel changes the global level when module is empty.
+func (levels *Levels) SetLevel(module string, level slog.Level) {
+	levels.update(func(state *levelsState) {
+		if module == "" {
+			state.global = level
+			return
+		}
+		state.modules[module] = level
+	})
+}
+
+// Replace drops runtime changes, used when the config is reloaded.
+func (levels *Levels) Replace(global slog.Level, modules map[string]slog.Level) {
+	state := &levelsState{global: global, modules: make(map[string]slog.Level, len(modules))}
+	for module, level := range modules {
+		state.modules[module] = level
+	}
+	levels.state.Store(state)
+}
+
+func (levels *Levels) ResetLevel(module string) {
+	levels.update(func(state *levelsState) {
+		delete(state.modules, module)
+	})
+}
+
+func (levels *Levels) Snapshot() (slog.Level, map[string]slog.Level) {
+	state := levels.state.Load()
+	modules := make(map[string]slog.Level, len(state.modules))
+	for module, level := range state.modules {
+		modules[module] = level
+	}
+	return state.global, modules
+}
+
+func (levels *Levels) update(change func(state *levelsState)) {
+	for {
+		current := levels.state.Load()
+		next := &levelsState{global: current.global, modules: make(map[string]slog.Level, len(current.modules))}
+		for module, level := range current.modules {
+			next.modules[module] = level
+		}
+		change(next)
+		if levels.state.CompareAndSwap(current, next) {
+			return
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"filmoteka/pkg/variables"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+type Logging struct {
+	Logger *slog.Logger
+	Levels *Levels
+	file   *lumberjack.Logger
+	done   chan struct{}
+}
+
+func GetLogging(config variables.LoggingConfig) (*Logging, error) {
+	global, modules, err := parseLevels(config)
+	if err != nil {
+		return nil, err
+	}
+
+	logging := &Logging{
+		Levels: GetLevels(global, modules),
+		done:   make(chan struct{}),
+	}
+
+	var writer io.Writer
+	switch config.Output {
+	case variables.LogOutputStdout, "":
+		writer = os.Stdout
+	case variables.LogOutputFile:
+		logging.file = newRotatingFile(config)
+		writer = logging.file
+	case variables.LogOutputBoth:
+		logging.file = newRotatingFile(config)
+		writer = io.MultiWriter(os.Stdout, logging.file)
+	default:
+		return nil, fmt.Errorf("%s: %q", variables.LogOutputError, config.Output)
+	}
+
+	// Filtering is done by moduleHandler, so the inner handler lets everything through.
+	options := &slog.HandlerOptions{Level: slog.Level(math.MinInt)}
+
+	var handler slog.Handler
+	switch config.Format {
+	case variables.LogFormatJson, "":
+		handler = slog.NewJSONHandler(writer, options)
+	case variables.LogFormatText:
+		handler = slog.NewTextHandler(writer, options)
+	default:
+		return nil, fmt.Errorf("%s: %q", variables.LogFormatError, config.Format)
+	}
+
+	logging.Logger = slog.New(&moduleHandler{next: handler, levels: logging.Levels})
+
+	if logging.file != nil && config.RotateInterval > 0 {
+		go logging.rotateEvery(config.RotateInterval)
+	}
+
+	return logging, nil
+}
+
+// ApplyConfig sets the levels from a reloaded config. Output and rotation
+// settings need a restart.
+func (logging *Logging) ApplyConfig(config variables.LoggingConfig) error {
+	global, modules, err := parseLevels(config)
+	if err != nil {
+		return err
+	}
+	logging.Levels.Replace(global, modules)
+	return nil
+}
+
+// Close stops the age based rotation and closes the log file.
+func (logging *Logging) Close() error {
+	close(logging.done)
+	if logging.file == nil {
+		return nil
+	}
+	return logging.file.Close()
+}
+
+func (logging *Logging) rotateEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := logging.file.Rotate()
+			if err != nil {
+				logging.Logger.Error(variables.LogRotateError, "error", err)
+			}
+		case <-logging.done:
+			return
+		}
+	}
+}
+
+func newRotatingFile(config variables.LoggingConfig) *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   config.File,
+		MaxSize:    config.MaxSizeMB,
+		MaxAge:     config.MaxAgeDays,
+		MaxBackups: config.MaxBackups,
+	}
+}
+
+func parseLevels(config variables.LoggingConfig) (slog.Level, map[string]slog.Level, error) {
+	global, err := parseLevel(config.Level)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	modules := make(map[string]slog.Level, len(config.Modules))
+	for module, value := range config.Modules {
+		level, err := parseLevel(value)
+		if err != nil {
+			return 0, nil, fmt.Errorf("%s %q: %w", variables.LogModuleLevelError, module, err)
+		}
+		modules[module] = level
+	}
+	return global, modules, nil
+}
+
+func parseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if value == "" {
+		return slog.LevelInfo, nil
+	}
+
+	err := level.UnmarshalText([]byte(value))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", variables.LogLevelError, err)
+	}
+	return level, nil
+}
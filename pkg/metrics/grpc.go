@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	observeGrpc(GrpcServerHandledTotal, GrpcServerHandlingSeconds, info.FullMethod, start, err)
+	return resp, err
+}
+
+func StreamServerInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, stream)
+	observeGrpc(GrpcServerHandledTotal, GrpcServerHandlingSeconds, info.FullMethod, start, err)
+	return err
+}
+
+func UnaryClientInterceptor(ctx context.Context, method string, req any, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	observeGrpc(GrpcClientHandledTotal, GrpcClientHandlingSeconds, method, start, err)
+	return err
+}
+
+// StreamClientInterceptor only counts stream establishment, long-lived
+// streams like invalidation watches would skew the latency histogram.
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	GrpcClientHandledTotal.WithLabelValues(method, status.Code(err).String()).Inc()
+	return stream, err
+}
+
+func observeGrpc(handled *prometheus.CounterVec, latency *prometheus.HistogramVec, method string, start time.Time, err error) {
+	handled.WithLabelValues(method, status.Code(err).String()).Inc()
+	latency.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"database/sql"
+	"filmoteka/pkg/variables"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// HTTP metrics
+var (
+	HttpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: variables.MetricsNamespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	HttpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: variables.MetricsNamespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// gRPC metrics
+var (
+	GrpcServerHandledTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: variables.MetricsNamespace,
+		Subsystem: "grpc_server",
+		Name:      "handled_total",
+		Help:      "Number of gRPC calls completed on the server by method and code.",
+	}, []string{"method", "code"})
+
+	GrpcServerHandlingSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: variables.MetricsNamespace,
+		Subsystem: "grpc_server",
+		Name:      "handling_seconds",
+		Help:      "gRPC server handling latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	GrpcClientHandledTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: variables.MetricsNamespace,
+		Subsystem: "grpc_client",
+		Name:      "handled_total",
+		Help:      "Number of gRPC calls completed by the client by method and code.",
+	}, []string{"method", "code"})
+
+	GrpcClientHandlingSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: variables.MetricsNamespace,
+		Subsystem: "grpc_client",
+		Name:      "handling_seconds",
+		Help:      "gRPC client call latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+// Business metrics
+var (
+	SignupsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: variables.MetricsNamespace,
+		Name:      "signups_total",
+		Help:      "Number of created user accounts.",
+	})
+
+	LoginsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: variables.MetricsNamespace,
+		Name:      "logins_total",
+		Help:      "Number of sign in attempts by result.",
+	}, []string{"result"})
+
+	FilmsAddedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: variables.MetricsNamespace,
+		Name:      "films_added_total",
+		Help:      "Number of films added to the catalogue.",
+	})
+
+	FilmsEditedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: variables.MetricsNamespace,
+		Name:      "films_edited_total",
+		Help:      "Number of film edits.",
+	})
+
+	FilmsDeletedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: variables.MetricsNamespace,
+		Name:      "films_deleted_total",
+		Help:      "Number of films removed from the catalogue.",
+	})
+
+	ActorsAddedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: variables.MetricsNamespace,
+		Name:      "actors_added_total",
+		Help:      "Number of actors added to the catalogue.",
+	})
+
+	ActorsEditedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: variables.MetricsNamespace,
+		Name:      "actors_edited_total",
+		Help:      "Number of actor edits.",
+	})
+
+	ActorsDeletedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: variables.MetricsNamespace,
+		Name:      "actors_deleted_total",
+		Help:      "Number of actors removed from the catalogue.",
+	})
+
+	TrashPurgedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: variables.MetricsNamespace,
+		Name:      "trash_purged_total",
+		Help:      "Number of trashed items removed for good by type.",
+	}, []string{"type"})
+)
+
+var poolSequence atomic.Int64
+
+// RegisterDBStats exports sql.DBStats of the pool. Each call gets its own
+// pool label, since one binary may open several pools to the same database.
+func RegisterDBStats(db *sql.DB, dbName string) error {
+	pool := strconv.FormatInt(poolSequence.Add(1), 10)
+	registerer := prometheus.WrapRegistererWith(prometheus.Labels{"pool": pool}, prometheus.DefaultRegisterer)
+	return registerer.Register(collectors.NewDBStatsCollector(db, dbName))
+}
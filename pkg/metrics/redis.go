@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"filmoteka/pkg/variables"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type redisPoolCollector struct {
+	stats      func() *redis.PoolStats
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+func newRedisPoolCollector(addr string, stats func() *redis.PoolStats, pool string) *redisPoolCollector {
+	labels := prometheus.Labels{"addr": addr, "pool": pool}
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(variables.MetricsNamespace, "redis_pool", name), help, nil, labels)
+	}
+
+	return &redisPoolCollector{
+		stats:      stats,
+		hits:       desc("hits_total", "Number of times a free connection was found in the pool."),
+		misses:     desc("misses_total", "Number of times a free connection was not found in the pool."),
+		timeouts:   desc("timeouts_total", "Number of times a wait for a connection timed out."),
+		totalConns: desc("total_connections", "Number of connections in the pool."),
+		idleConns:  desc("idle_connections", "Number of idle connections in the pool."),
+		staleConns: desc("stale_connections_total", "Number of stale connections removed from the pool."),
+	}
+}
+
+func (collector *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.hits
+	ch <- collector.misses
+	ch <- collector.timeouts
+	ch <- collector.totalConns
+	ch <- collector.idleConns
+	ch <- collector.staleConns
+}
+
+func (collector *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := collector.stats()
+	ch <- prometheus.MustNewConstMetric(collector.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(collector.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(collector.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(collector.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(collector.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(collector.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
+
+// RegisterRedisPoolStats takes a getter rather than a client, so the stats
+// follow the repository when it reconnects.
+func RegisterRedisPoolStats(addr string, stats func() *redis.PoolStats) error {
+	pool := strconv.FormatInt(poolSequence.Add(1), 10)
+	return prometheus.Register(newRedisPoolCollector(addr, stats, pool))
+}
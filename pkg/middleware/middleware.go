@@ -2,10 +2,20 @@ package middleware
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"filmoteka/pkg/metrics"
+	"filmoteka/pkg/tracing"
 	"filmoteka/pkg/util"
 	"filmoteka/pkg/variables"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type ICore interface {
@@ -13,12 +23,129 @@ type ICore interface {
 	GetUserRole(ctx context.Context, id int64) (string, error)
 }
 
-func MethodMiddleware(next http.Handler, method string, logger *slog.Logger) http.Handler {
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	recorder.status = status
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *statusRecorder) Write(body []byte) (int, error) {
+	written, err := recorder.ResponseWriter.Write(body)
+	recorder.bytes += written
+	return written, err
+}
+
+// routePattern resolves the mux pattern so that metric labels stay bounded
+// no matter what paths clients send. The method is dropped from the pattern,
+// it is labelled on its own.
+func routePattern(mux *http.ServeMux, r *http.Request) string {
+	_, pattern := mux.Handler(r)
+	if pattern == "" {
+		return variables.UnmatchedRoute
+	}
+	if _, path, found := strings.Cut(pattern, " "); found {
+		return path
+	}
+	return pattern
+}
+
+func MetricsMiddleware(mux *http.ServeMux) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != method {
-			util.SendResponse(w, r, http.StatusMethodNotAllowed, nil, variables.StatusMethodNotAllowedError, nil, logger)
-			return
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		mux.ServeHTTP(recorder, r)
+
+		route := routePattern(mux, r)
+		status := strconv.Itoa(recorder.status)
+		metrics.HttpRequestsTotal.WithLabelValues(r.Method, route, status).Inc()
+		metrics.HttpRequestDuration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// TracingMiddleware continues the trace sent by the caller and names server
+// spans after the matched route.
+func TracingMiddleware(next http.Handler, mux *http.ServeMux, service string) http.Handler {
+	return otelhttp.NewHandler(next, service, otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		return r.Method + " " + routePattern(mux, r)
+	}))
+}
+
+type accessInfo struct {
+	userId int64
+}
+
+func validRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > variables.RequestIdMaxLength {
+		return false
+	}
+	for _, symbol := range requestId {
+		isLetter := (symbol >= 'a' && symbol <= 'z') || (symbol >= 'A' && symbol <= 'Z')
+		isDigit := symbol >= '0' && symbol <= '9'
+		if !isLetter && !isDigit && symbol != '-' && symbol != '_' && symbol != '.' {
+			return false
 		}
+	}
+	return true
+}
+
+func newRequestId() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// RequestIdMiddleware honours a well-formed incoming X-Request-ID or generates
+// one. It is kept in the context for the audit log and, with the trace id,
+// added to the request log attributes.
+func RequestIdMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(variables.RequestIdHeader)
+		if !validRequestId(requestId) {
+			requestId = newRequestId()
+		}
+		w.Header().Set(variables.RequestIdHeader, requestId)
+
+		attrs := []any{variables.RequestIdLogKey, requestId}
+		if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.HasTraceID() {
+			attrs = append(attrs, variables.TraceIdLogKey, spanContext.TraceID().String())
+		}
+
+		ctx := context.WithValue(r.Context(), variables.RequestIDKey, requestId)
+		next.ServeHTTP(w, r.WithContext(util.WithLogAttrs(ctx, attrs...)))
+	})
+}
+
+// AccessLogMiddleware writes one line per request once the response is sent.
+func AccessLogMiddleware(next http.Handler, mux *http.ServeMux, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		info := &accessInfo{}
+
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), variables.AccessInfoKey, info)))
+
+		util.ContextLogger(r.Context(), logger).Info(variables.AccessLogMessage,
+			"method", r.Method,
+			"route", routePattern(mux, r),
+			"status", recorder.status,
+			"latency_ms", float64(time.Since(start).Microseconds())/1000,
+			variables.UserIdLogKey, info.userId,
+			"bytes", recorder.bytes)
+	})
+}
+
+// DeprecatedMiddleware serves an old alias of a route and points clients to
+// its successor.
+func DeprecatedMiddleware(next http.Handler, successor string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(variables.DeprecationHeader, "true")
+		w.Header().Set(variables.LinkHeader, "<"+successor+`>; rel="successor-version"`)
 		next.ServeHTTP(w, r)
 	})
 }
@@ -31,19 +158,28 @@ func AuthorizationMiddleware(next http.Handler, core ICore, logger *slog.Logger)
 			return
 		}
 
-		userId, err := core.GetUserId(r.Context(), session.Value)
+		ctx, span := tracing.Tracer().Start(r.Context(), variables.AuthorizationSpanName)
+		userId, err := core.GetUserId(ctx, session.Value)
+		span.End()
 		if err != nil || userId == 0 {
 			util.SendResponse(w, r, http.StatusUnauthorized, nil, variables.StatusUnauthorizedError, nil, logger)
 			return
 		}
 
+		if info, ok := r.Context().Value(variables.AccessInfoKey).(*accessInfo); ok {
+			info.userId = userId
+		}
+
+		r = r.WithContext(util.WithLogAttrs(r.Context(), variables.UserIdLogKey, userId))
 		r = r.WithContext(context.WithValue(r.Context(), variables.UserIDKey, userId))
 		r = r.WithContext(context.WithValue(r.Context(), variables.SessionIDKey, session))
 		next.ServeHTTP(w, r)
 	})
 }
 
-func PermissionsMiddleware(next http.Handler, core ICore, role string, logger *slog.Logger) http.Handler {
+// PermissionsMiddleware looks the required role up on every request, so
+// reloaded permissions apply at once. Unmapped permissions need an admin.
+func PermissionsMiddleware(next http.Handler, core ICore, permission string, permissions func() map[string]string, logger *slog.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		userId, isAuth := r.Context().Value(variables.UserIDKey).(int64)
 		if !isAuth {
@@ -51,12 +187,18 @@ func PermissionsMiddleware(next http.Handler, core ICore, role string, logger *s
 			return
 		}
 
-		userRole, err := core.GetUserRole(r.Context(), userId)
+		ctx, span := tracing.Tracer().Start(r.Context(), variables.PermissionsSpanName)
+		userRole, err := core.GetUserRole(ctx, userId)
+		span.End()
 		if err != nil {
 			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, logger)
 			return
 		}
-		if userRole != role {
+		role, ok := permissions()[permission]
+		if !ok {
+			role = variables.AdminRole
+		}
+		if userRole != role && userRole != variables.AdminRole {
 			util.SendResponse(w, r, http.StatusForbidden, nil, variables.StatusForbiddenError, nil, logger)
 			return
 		}
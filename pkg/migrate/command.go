@@ -0,0 +1,138 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/pkg/variables"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+// RunCommand runs "up", "down [steps]", "status" or "create <name>". New
+// migrations are written to dir, the source tree the binary embeds.
+func RunCommand(ctx context.Context, args []string, config variables.RelationalDataBaseConfig, source fs.FS, dir string, out io.Writer, logger *slog.Logger) error {
+	if len(args) == 0 {
+		return errors.New(variables.MigrateUsage)
+	}
+
+	if args[0] == variables.MigrateCreate {
+		if len(args) != 2 {
+			return errors.New(variables.MigrateUsage)
+		}
+		return create(dir, args[1], out)
+	}
+
+	db, err := Open(config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := GetMigrator(db, source, logger)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case variables.MigrateUp:
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+	case variables.MigrateDown:
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return errors.New(variables.MigrateUsage)
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Fprintf(out, "reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+	case variables.MigrateStatus:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(out, "%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+		return nil
+	default:
+		return errors.New(variables.MigrateUsage)
+	}
+}
+
+// Up opens its own connection, for applying migrations on startup.
+func Up(ctx context.Context, config variables.RelationalDataBaseConfig, source fs.FS, logger *slog.Logger) error {
+	db, err := Open(config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := GetMigrator(db, source, logger)
+	if err != nil {
+		return err
+	}
+
+	_, err = migrator.Up(ctx)
+	return err
+}
+
+func Open(config variables.RelationalDataBaseConfig) (*sql.DB, error) {
+	dsn := fmt.Sprintf("user=%s dbname=%s password=%s host=%s port=%d sslmode=%s",
+		config.User, config.DbName, config.Password, config.Host, config.Port, config.Sslmode)
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", variables.SqlOpenError, err)
+	}
+	return db, nil
+}
+
+func create(dir string, name string, out io.Writer) error {
+	name = strings.Map(func(symbol rune) rune {
+		if (symbol >= 'a' && symbol <= 'z') || (symbol >= '0' && symbol <= '9') {
+			return symbol
+		}
+		return '_'
+	}, strings.ToLower(name))
+
+	migrations, err := readMigrations(os.DirFS(dir))
+	if err != nil {
+		return err
+	}
+
+	version := int64(1)
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	for _, direction := range []string{variables.MigrateUp, variables.MigrateDown} {
+		path := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+		err = os.WriteFile(path, nil, 0o644)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "created", path)
+	}
+	return nil
+}
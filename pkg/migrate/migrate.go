@@ -0,0 +1,262 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/pkg/variables"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	logger     *slog.Logger
+}
+
+func GetMigrator(db *sql.DB, source fs.FS, logger *slog.Logger) (*Migrator, error) {
+	migrations, err := readMigrations(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		logger:     logger,
+	}, nil
+}
+
+func readMigrations(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", variables.MigrationsReadError, err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFileRegexp.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", variables.MigrationNameError, entry.Name(), err)
+		}
+
+		body, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", variables.MigrationsReadError, err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("%s: %d", variables.MigrationVersionConflictError, version)
+		}
+
+		if match[3] == variables.MigrateUp {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("%s: %d_%s", variables.MigrationUpMissingError, migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies every pending migration, each in its own transaction.
+func (migrator *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := migrator.locked(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrator.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+
+			err = inTransaction(ctx, conn, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, migration.Up)
+				if err != nil {
+					return err
+				}
+				_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("%s %d_%s: %w", variables.MigrationApplyError, migration.Version, migration.Name, err)
+			}
+
+			migrator.logger.Info(variables.MigrationAppliedMessage, "version", migration.Version, "name", migration.Name)
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the given number of most recently applied migrations.
+func (migrator *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+
+	err := migrator.locked(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrator.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := migrator.migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("%s: %d_%s", variables.MigrationIrreversibleError, migration.Version, migration.Name)
+			}
+
+			err = inTransaction(ctx, conn, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, migration.Down)
+				if err != nil {
+					return err
+				}
+				_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("%s %d_%s: %w", variables.MigrationRevertError, migration.Version, migration.Name, err)
+			}
+
+			migrator.logger.Info(variables.MigrationRevertedMessage, "version", migration.Version, "name", migration.Name)
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+func (migrator *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+
+	err := migrator.locked(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrator.migrations {
+			appliedAt, applied := versions[migration.Version]
+			statuses = append(statuses, MigrationStatus{Migration: migration, Applied: applied, AppliedAt: appliedAt})
+		}
+		return nil
+	})
+
+	return statuses, err
+}
+
+// locked runs on a single connection holding an advisory lock, so replicas
+// starting together do not apply the same migration twice.
+func (migrator *Migrator) locked(ctx context.Context, run func(conn *sql.Conn) error) error {
+	conn, err := migrator.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, variables.MigrationsLockId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", variables.MigrationsLockError, err)
+	}
+	defer func() {
+		_, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, variables.MigrationsLockId)
+		if err != nil {
+			migrator.logger.Error(variables.MigrationsLockError, "error", err)
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", variables.MigrationsTableError, err)
+	}
+
+	return run(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", variables.MigrationsTableError, err)
+	}
+	defer rows.Close()
+
+	versions := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", variables.MigrationsTableError, err)
+		}
+		versions[version] = appliedAt
+	}
+	return versions, rows.Err()
+}
+
+func inTransaction(ctx context.Context, conn *sql.Conn, run func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = run(tx)
+	if err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,206 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"filmoteka/pkg/sqltest"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func getTestSource() fstest.MapFS {
+	return fstest.MapFS{
+		"10_add_tags.up.sql":     {Data: []byte("up 10")},
+		"2_add_actors.up.sql":    {Data: []byte("up 2")},
+		"2_add_actors.down.sql":  {Data: []byte("down 2")},
+		"1_init.up.sql":          {Data: []byte("up 1")},
+		"1_init.down.sql":        {Data: []byte("down 1")},
+		"README.md":              {Data: []byte("not a migration")},
+		"3_Bad_Name.up.sql":      {Data: []byte("ignored")},
+		"nested/4_nested.up.sql": {Data: []byte("ignored")},
+	}
+}
+
+// getTestMigrator answers the applied versions query with applied and
+// fails every statement equal to failing
+func getTestMigrator(t *testing.T, applied []int64, failing string) (*Migrator, *sqltest.DB) {
+	db := sqltest.Open(func(statement sqltest.Statement) sqltest.Answer {
+		if statement.Query == failing {
+			return sqltest.Answer{Err: errors.New("statement failed")}
+		}
+		if strings.HasPrefix(statement.Query, "SELECT version, applied_at") {
+			answer := sqltest.Answer{Columns: []string{"version", "applied_at"}}
+			for _, version := range applied {
+				answer.Rows = append(answer.Rows, []any{version, time.Now()})
+			}
+			return answer
+		}
+		return sqltest.Answer{}
+	})
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := GetMigrator(db.DB, getTestSource(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return migrator, db
+}
+
+// queries shortens the recorded statements to their first word, or the
+// whole query for migration bodies and transaction boundaries
+func queries(db *sqltest.DB) []string {
+	var shortened []string
+	for _, statement := range db.Statements() {
+		query := strings.TrimSpace(statement.Query)
+		switch {
+		case strings.HasPrefix(query, "SELECT pg_advisory_lock"):
+			query = "lock"
+		case strings.HasPrefix(query, "SELECT pg_advisory_unlock"):
+			query = "unlock"
+		case strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+			query = "create table"
+		case strings.HasPrefix(query, "SELECT version"):
+			query = "select versions"
+		case strings.HasPrefix(query, "INSERT INTO schema_migrations"):
+			query = "insert"
+		case strings.HasPrefix(query, "DELETE FROM schema_migrations"):
+			query = "delete"
+		}
+		shortened = append(shortened, query)
+	}
+	return shortened
+}
+
+func TestReadMigrationsOrdersByVersion(t *testing.T) {
+	migrations, err := readMigrations(getTestSource())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "init", Up: "up 1", Down: "down 1"},
+		{Version: 2, Name: "add_actors", Up: "up 2", Down: "down 2"},
+		{Version: 10, Name: "add_tags", Up: "up 10"},
+	}
+	if !reflect.DeepEqual(migrations, want) {
+		t.Errorf("migrations = %+v, want %+v", migrations, want)
+	}
+}
+
+func TestReadMigrationsRejectsBrokenSets(t *testing.T) {
+	tests := []struct {
+		name   string
+		source fstest.MapFS
+	}{
+		{"down without up", fstest.MapFS{"1_init.down.sql": {Data: []byte("down")}}},
+		{"two names for a version", fstest.MapFS{
+			"1_init.up.sql":  {Data: []byte("up")},
+			"1_other.up.sql": {Data: []byte("up")},
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := readMigrations(test.source); err == nil {
+				t.Error("readMigrations accepted a broken set")
+			}
+		})
+	}
+}
+
+func TestUpAppliesPendingInOrderUnderLock(t *testing.T) {
+	migrator, db := getTestMigrator(t, []int64{1}, "")
+
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 || applied[0].Version != 2 || applied[1].Version != 10 {
+		t.Errorf("applied = %+v, want versions 2 and 10", applied)
+	}
+
+	want := []string{
+		"lock", "create table", "select versions",
+		"BEGIN", "up 2", "insert", "COMMIT",
+		"BEGIN", "up 10", "insert", "COMMIT",
+		"unlock",
+	}
+	if got := queries(db); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+
+	inserted := db.Find("INSERT INTO schema_migrations")
+	if !reflect.DeepEqual(inserted[0].Args, []any{int64(2), "add_actors"}) {
+		t.Errorf("insert args = %v, want [2 add_actors]", inserted[0].Args)
+	}
+}
+
+func TestUpStopsAtFailureAndUnlocks(t *testing.T) {
+	migrator, db := getTestMigrator(t, nil, "up 2")
+
+	applied, err := migrator.Up(context.Background())
+	if err == nil {
+		t.Fatal("Up ignored a failing migration")
+	}
+	if len(applied) != 1 || applied[0].Version != 1 {
+		t.Errorf("applied = %+v, want version 1 only", applied)
+	}
+
+	want := []string{
+		"lock", "create table", "select versions",
+		"BEGIN", "up 1", "insert", "COMMIT",
+		"BEGIN", "up 2", "ROLLBACK",
+		"unlock",
+	}
+	if got := queries(db); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
+
+func TestUpDoesNothingWithoutLock(t *testing.T) {
+	migrator, db := getTestMigrator(t, nil, "SELECT pg_advisory_lock($1)")
+
+	if _, err := migrator.Up(context.Background()); err == nil {
+		t.Fatal("Up ran without the lock")
+	}
+	if got := queries(db); len(got) != 1 {
+		t.Errorf("statements = %q, want only the lock attempt", got)
+	}
+}
+
+func TestDownRevertsNewestFirst(t *testing.T) {
+	migrator, db := getTestMigrator(t, []int64{1, 2}, "")
+
+	reverted, err := migrator.Down(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != 2 {
+		t.Errorf("reverted = %+v, want version 2", reverted)
+	}
+
+	want := []string{
+		"lock", "create table", "select versions",
+		"BEGIN", "down 2", "delete", "COMMIT",
+		"unlock",
+	}
+	if got := queries(db); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
+
+func TestDownRefusesIrreversibleMigration(t *testing.T) {
+	migrator, _ := getTestMigrator(t, []int64{1, 2, 10}, "")
+
+	reverted, err := migrator.Down(context.Background(), 2)
+	if err == nil {
+		t.Fatal("Down reverted a migration without a down file")
+	}
+	if len(reverted) != 0 {
+		t.Errorf("reverted = %+v, want nothing", reverted)
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// UnmarshalJSON also accepts the bare actor id older clients send, which
+// leaves the role empty for the core to default.
+func (member *CrewMember) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '{' {
+		*member = CrewMember{}
+		return json.Unmarshal(data, &member.ActorId)
+	}
+
+	type plain CrewMember
+	return json.Unmarshal(data, (*plain)(member))
+}
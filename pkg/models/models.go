@@ -13,13 +13,81 @@ type (
 		Login string `json:"login"`
 	}
 
+	Invalidation struct {
+		Sid string
+		Id  int64
+	}
+
+	// FilmItem crew is grouped by role and ordered by billing
 	FilmItem struct {
-		Id          int         `json:"id"`
-		Title       string      `json:"title"`
-		Description string      `json:"description"`
-		Rating      float64     `json:"rating"`
-		ReleaseDate string      `json:"release_date"`
-		Crew        []ActorItem `json:"crew"`
+		Id          int     `json:"id"`
+		Title       string  `json:"title"`
+		Description string  `json:"description"`
+		Rating      float64 `json:"rating"`
+		ReleaseDate string  `json:"release_date"`
+		FilmMetadata
+		Crew    map[string][]CrewItem `json:"crew"`
+		Version int64                 `json:"version"`
+	}
+
+	// FilmMetadata is shared by film items, requests and revisions. Genres
+	// are dictionary slugs, countries ISO 3166-1 alpha-2 codes and the
+	// language an ISO 639-1 code, a zero runtime is unknown.
+	FilmMetadata struct {
+		Genres           []string `json:"genres"`
+		Tags             []string `json:"tags"`
+		Countries        []string `json:"countries"`
+		Runtime          int      `json:"runtime"`
+		AgeRating        string   `json:"age_rating"`
+		OriginalLanguage string   `json:"original_language"`
+	}
+
+	SortKey struct {
+		Field      string
+		Descending bool
+	}
+
+	Genre struct {
+		Id   int64  `json:"id"`
+		Slug string `json:"slug"`
+		Name string `json:"name"`
+	}
+
+	// FilmFilter narrows the films list, zero fields don't filter. Crew
+	// ids match films with any of them, or all with CrewMatchAll.
+	FilmFilter struct {
+		TitlePrefix  string
+		MinRating    *float64
+		MaxRating    *float64
+		ReleasedFrom string
+		ReleasedTo   string
+		CrewIds      []int64
+		CrewMatchAll bool
+		Genre        string
+		Tag          string
+		Country      string
+		MinRuntime   int
+		MaxRuntime   int
+		AgeRating    string
+		Language     string
+	}
+
+	CrewItem struct {
+		Id            int    `json:"id"`
+		Name          string `json:"name"`
+		Gender        string `json:"gender"`
+		BirthDate     string `json:"birth_date"`
+		CharacterName string `json:"character_name,omitempty"`
+		BillingOrder  int    `json:"billing_order"`
+		Version       int64  `json:"version"`
+	}
+
+	// CrewMember links a person to a film, a bare id is read as an actor
+	CrewMember struct {
+		ActorId       int64  `json:"actor_id"`
+		Role          string `json:"role"`
+		CharacterName string `json:"character_name,omitempty"`
+		BillingOrder  int    `json:"billing_order"`
 	}
 
 	FilmShortItem struct {
@@ -31,6 +99,76 @@ type (
 		Crew        []ActorShortItem `json:"crew"`
 	}
 
+	// FilmSearchItem snippet marks the matched words with <b> tags
+	FilmSearchItem struct {
+		FilmShortItem
+		Score   float64 `json:"score"`
+		Snippet string  `json:"snippet"`
+	}
+
+	// ActorSearchItem snippet marks the matched words with <b> tags
+	ActorSearchItem struct {
+		ActorShortItem
+		Score   float64 `json:"score"`
+		Snippet string  `json:"snippet"`
+	}
+
+	// SearchFacets count the matching films, the values are genre slugs,
+	// decades like 1990s and rating buckets like 8-10
+	SearchFacets struct {
+		Genres  []FacetCount `json:"genres"`
+		Decades []FacetCount `json:"decades"`
+		Ratings []FacetCount `json:"ratings"`
+	}
+
+	FacetCount struct {
+		Value string `json:"value"`
+		Count int64  `json:"count"`
+	}
+
+	// SearchDocument is what a search index keeps of a film or an actor.
+	// Crew names rank a film below its own text, the rest feeds facets.
+	SearchDocument struct {
+		Kind        string
+		Id          int64
+		Title       string
+		Text        string
+		Crew        []string
+		Genres      []string
+		ReleaseYear int
+		Rating      *float64
+	}
+
+	// SearchQuery asks for a page of one kind of documents, facets are
+	// counted over all matching films when asked for
+	SearchQuery struct {
+		Kind   string
+		Text   string
+		Offset uint64
+		Limit  uint64
+		Facets bool
+	}
+
+	SearchHit struct {
+		Id      int64
+		Score   float64
+		Snippet string
+	}
+
+	SearchResult struct {
+		Hits   []SearchHit
+		Total  int64
+		Facets SearchFacets
+	}
+
+	// Suggestion is a film or an actor, told apart by type
+	Suggestion struct {
+		Type  string  `json:"type"`
+		Id    int64   `json:"id"`
+		Title string  `json:"title"`
+		Score float64 `json:"score"`
+	}
+
 	ActorShortItem struct {
 		Id        int    `json:"id"`
 		Name      string `json:"name"`
@@ -38,11 +176,79 @@ type (
 		BirthDate string `json:"birth_date"`
 	}
 
+	TrashItem struct {
+		Id        int64     `json:"id"`
+		Type      string    `json:"type"`
+		Name      string    `json:"name"`
+		DeletedAt time.Time `json:"deleted_at"`
+	}
+
+	// Patches leave the fields that are nil untouched
+	FilmPatch struct {
+		Title            *string       `json:"title"`
+		Description      *string       `json:"description"`
+		Rating           *float64      `json:"rating"`
+		ReleaseDate      *string       `json:"release_date"`
+		Crew             *[]CrewMember `json:"crew"`
+		Genres           *[]string     `json:"genres"`
+		Tags             *[]string     `json:"tags"`
+		Countries        *[]string     `json:"countries"`
+		Runtime          *int          `json:"runtime"`
+		AgeRating        *string       `json:"age_rating"`
+		OriginalLanguage *string       `json:"original_language"`
+	}
+
+	ActorPatch struct {
+		Name      *string  `json:"name"`
+		Gender    *string  `json:"gender"`
+		BirthDate *string  `json:"birth_date"`
+		Films     *[]int64 `json:"films"`
+	}
+
+	FilmRevision struct {
+		Revision    int64        `json:"revision"`
+		Title       string       `json:"title"`
+		Description string       `json:"description"`
+		Rating      float64      `json:"rating"`
+		ReleaseDate string       `json:"release_date"`
+		Crew        []CrewMember `json:"crew"`
+		FilmMetadata
+		AuthorId  *int64    `json:"author_id"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	AuditChange struct {
+		Before any `json:"before"`
+		After  any `json:"after"`
+	}
+
+	AuditEntry struct {
+		Id        int64                  `json:"id"`
+		ActorId   *int64                 `json:"actor_id"`
+		Action    string                 `json:"action"`
+		Entity    string                 `json:"entity"`
+		EntityId  int64                  `json:"entity_id"`
+		Changes   map[string]AuditChange `json:"changes"`
+		RequestId string                 `json:"request_id,omitempty"`
+		CreatedAt time.Time              `json:"created_at"`
+	}
+
+	AuditFilter struct {
+		ActorId   int64
+		Action    string
+		Entity    string
+		EntityId  int64
+		RequestId string
+		From      time.Time
+		To        time.Time
+	}
+
 	ActorItem struct {
 		Id        int             `json:"id"`
 		Name      string          `json:"name"`
 		Gender    string          `json:"gender"`
 		BirthDate string          `json:"birth_date"`
 		Films     []FilmShortItem `json:"films"`
+		Version   int64           `json:"version"`
 	}
 )
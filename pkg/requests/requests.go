@@ -1,6 +1,13 @@
 package communication
 
+import "filmoteka/pkg/models"
+
 type (
+	LogLevelRequest struct {
+		Module string `json:"module"`
+		Level  string `json:"level"`
+	}
+
 	SigninRequest struct {
 		Login    string `json:"login"`
 		Password string `json:"password"`
@@ -18,7 +25,11 @@ type (
 	}
 
 	EditActorRequest struct {
-		Id        int64   `json:"id"`
+		Id int64 `json:"id"`
+		models.ActorPatch
+	}
+
+	ReplaceActorRequest struct {
 		Name      string  `json:"name"`
 		Gender    string  `json:"gender"`
 		BirthDate string  `json:"birth_date"`
@@ -30,23 +41,48 @@ type (
 	}
 
 	AddFilmRequest struct {
-		Title       string  `json:"title"`
-		Description string  `json:"description"`
-		Rating      float64 `json:"rating"`
-		ReleaseDate string  `json:"release_date"`
-		Crew        []int64 `json:"crew"`
+		Title       string              `json:"title"`
+		Description string              `json:"description"`
+		Rating      float64             `json:"rating"`
+		ReleaseDate string              `json:"release_date"`
+		Crew        []models.CrewMember `json:"crew"`
+		models.FilmMetadata
 	}
 
 	EditFilmRequest struct {
-		Id          int64   `json:"id"`
-		Title       string  `json:"title"`
-		Description string  `json:"description"`
-		Rating      float64 `json:"rating"`
-		ReleaseDate string  `json:"release_date"`
-		Crew        []int64 `json:"crew"`
+		Id int64 `json:"id"`
+		models.FilmPatch
+	}
+
+	ReplaceFilmRequest struct {
+		Title       string              `json:"title"`
+		Description string              `json:"description"`
+		Rating      float64             `json:"rating"`
+		ReleaseDate string              `json:"release_date"`
+		Crew        []models.CrewMember `json:"crew"`
+		models.FilmMetadata
 	}
 
 	DeleteFilmRequest struct {
 		Id int64 `json:"id"`
 	}
+
+	RevertFilmRequest struct {
+		Revision int64 `json:"revision"`
+	}
+
+	GenreRequest struct {
+		Slug string `json:"slug"`
+		Name string `json:"name"`
+	}
+
+	TrashItemRequest struct {
+		Type string `json:"type"`
+		Id   int64  `json:"id"`
+	}
+
+	ChangeRoleRequest struct {
+		Id   int64  `json:"id"`
+		Role string `json:"role"`
+	}
 )
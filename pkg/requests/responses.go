@@ -8,10 +8,66 @@ type (
 	}
 
 	FindFilmResponse struct {
-		Films []models.FilmShortItem `json:"film_data"`
+		Films []models.FilmSearchItem `json:"film_data"`
+	}
+
+	// SearchResponse sections are paginated on their own
+	SearchResponse struct {
+		Films  SearchFilmsSection  `json:"films"`
+		Actors SearchActorsSection `json:"actors"`
+		Facets models.SearchFacets `json:"facets"`
+	}
+
+	SearchFilmsSection struct {
+		Items    []models.FilmSearchItem `json:"items"`
+		Total    int64                   `json:"total"`
+		Page     uint64                  `json:"page"`
+		PageSize uint64                  `json:"page_size"`
+	}
+
+	SearchActorsSection struct {
+		Items    []models.ActorSearchItem `json:"items"`
+		Total    int64                    `json:"total"`
+		Page     uint64                   `json:"page"`
+		PageSize uint64                   `json:"page_size"`
+	}
+
+	SearchRebuildResponse struct {
+		Films  int `json:"films"`
+		Actors int `json:"actors"`
+	}
+
+	SuggestResponse struct {
+		Suggestions []models.Suggestion `json:"suggestions"`
 	}
 
 	ActorsListResponse struct {
 		Actors []models.ActorItem `json:"actors"`
 	}
+
+	TrashListResponse struct {
+		Items []models.TrashItem `json:"items"`
+	}
+
+	GenresListResponse struct {
+		Genres []models.Genre `json:"genres"`
+	}
+
+	FilmRevisionsResponse struct {
+		Revisions []models.FilmRevision `json:"revisions"`
+	}
+
+	AuditListResponse struct {
+		Entries []models.AuditEntry `json:"entries"`
+	}
+
+	HealthResponse struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks,omitempty"`
+	}
+
+	LogLevelsResponse struct {
+		Level   string            `json:"level"`
+		Modules map[string]string `json:"modules,omitempty"`
+	}
 )
@@ -0,0 +1,224 @@
+// Package sqltest provides a database/sql driver for repository tests. It
+// records every statement with its arguments and answers it from a script,
+// so queries can be checked without a running database.
+package sqltest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+)
+
+const (
+	BeginStatement    = "BEGIN"
+	CommitStatement   = "COMMIT"
+	RollbackStatement = "ROLLBACK"
+)
+
+// Statement is one recorded query or exec, arguments are kept as passed
+type Statement struct {
+	Query string
+	Args  []any
+}
+
+// Answer is what the script returns for a statement
+type Answer struct {
+	Columns      []string
+	Rows         [][]any
+	RowsAffected int64
+	Err          error
+}
+
+// Script answers a statement, a nil script answers every statement with
+// no rows
+type Script func(statement Statement) Answer
+
+type DB struct {
+	*sql.DB
+	recorder *recorder
+}
+
+func Open(script Script) *DB {
+	recorder := &recorder{script: script}
+	return &DB{DB: sql.OpenDB(recorder), recorder: recorder}
+}
+
+// Statements returns what was run so far, transaction boundaries included
+func (db *DB) Statements() []Statement {
+	db.recorder.mutex.Lock()
+	defer db.recorder.mutex.Unlock()
+	return append([]Statement(nil), db.recorder.statements...)
+}
+
+// Find returns the recorded statements containing the fragment
+func (db *DB) Find(fragment string) []Statement {
+	var found []Statement
+	for _, statement := range db.Statements() {
+		if strings.Contains(statement.Query, fragment) {
+			found = append(found, statement)
+		}
+	}
+	return found
+}
+
+type recorder struct {
+	mutex      sync.Mutex
+	statements []Statement
+	script     Script
+}
+
+func (recorder *recorder) Connect(context.Context) (driver.Conn, error) {
+	return &conn{recorder: recorder}, nil
+}
+
+func (recorder *recorder) Driver() driver.Driver {
+	return sqlDriver{recorder: recorder}
+}
+
+func (recorder *recorder) run(query string, args []driver.NamedValue) Answer {
+	statement := Statement{Query: query, Args: make([]any, len(args))}
+	for i, arg := range args {
+		statement.Args[i] = arg.Value
+	}
+
+	recorder.mutex.Lock()
+	recorder.statements = append(recorder.statements, statement)
+	recorder.mutex.Unlock()
+
+	if recorder.script == nil {
+		return Answer{}
+	}
+	return recorder.script(statement)
+}
+
+type sqlDriver struct {
+	recorder *recorder
+}
+
+func (sqlDriver sqlDriver) Open(string) (driver.Conn, error) {
+	return &conn{recorder: sqlDriver.recorder}, nil
+}
+
+type conn struct {
+	recorder *recorder
+}
+
+func (conn *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: conn, query: query}, nil
+}
+
+func (conn *conn) Close() error {
+	return nil
+}
+
+func (conn *conn) Begin() (driver.Tx, error) {
+	return conn.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (conn *conn) BeginTx(ctx context.Context, options driver.TxOptions) (driver.Tx, error) {
+	answer := conn.recorder.run(BeginStatement, nil)
+	if answer.Err != nil {
+		return nil, answer.Err
+	}
+	return tx{conn: conn}, nil
+}
+
+// CheckNamedValue passes every argument through as is, so tests see the
+// exact values the repository bound
+func (conn *conn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+func (conn *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	answer := conn.recorder.run(query, args)
+	if answer.Err != nil {
+		return nil, answer.Err
+	}
+	return &rows{columns: answer.Columns, values: answer.Rows}, nil
+}
+
+func (conn *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	answer := conn.recorder.run(query, args)
+	if answer.Err != nil {
+		return nil, answer.Err
+	}
+	return driver.RowsAffected(answer.RowsAffected), nil
+}
+
+type tx struct {
+	conn *conn
+}
+
+func (tx tx) Commit() error {
+	return tx.conn.recorder.run(CommitStatement, nil).Err
+}
+
+func (tx tx) Rollback() error {
+	return tx.conn.recorder.run(RollbackStatement, nil).Err
+}
+
+// stmt only serves callers preparing statements explicitly, plain queries
+// go through the connection
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (stmt *stmt) Close() error {
+	return nil
+}
+
+func (stmt *stmt) NumInput() int {
+	return -1
+}
+
+func (stmt *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return stmt.conn.ExecContext(context.Background(), stmt.query, named(args))
+}
+
+func (stmt *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return stmt.conn.QueryContext(context.Background(), stmt.query, named(args))
+}
+
+func named(args []driver.Value) []driver.NamedValue {
+	values := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		values[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return values
+}
+
+type rows struct {
+	columns []string
+	values  [][]any
+	next    int
+}
+
+func (rows *rows) Columns() []string {
+	if rows.columns == nil && len(rows.values) > 0 {
+		return make([]string, len(rows.values[0]))
+	}
+	return rows.columns
+}
+
+func (rows *rows) Close() error {
+	return nil
+}
+
+func (rows *rows) Next(dest []driver.Value) error {
+	if rows.next >= len(rows.values) {
+		return io.EOF
+	}
+	for i, value := range rows.values[rows.next] {
+		converted, err := driver.DefaultParameterConverter.ConvertValue(value)
+		if err != nil {
+			return err
+		}
+		dest[i] = converted
+	}
+	rows.next++
+	return nil
+}
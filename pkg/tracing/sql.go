@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DB wraps a connection pool so that every query gets its own client span.
+type DB struct {
+	*sql.DB
+	dbName string
+}
+
+func WrapDB(db *sql.DB, dbName string) *DB {
+	return &DB{DB: db, dbName: dbName}
+}
+
+func (db *DB) startSpan(ctx context.Context, operation string, query string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, "sql."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBName(db.dbName),
+			attribute.String("db.statement", query),
+		))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := db.startSpan(ctx, "exec", query)
+	result, err := db.DB.ExecContext(ctx, query, args...)
+	endSpan(span, err)
+	return result, err
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := db.startSpan(ctx, "query", query)
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := db.startSpan(ctx, "query_row", query)
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	endSpan(span, row.Err())
+	return row
+}
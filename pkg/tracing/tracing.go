@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"context"
+	"filmoteka/pkg/variables"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(variables.TracerName)
+}
+
+// InitTracer installs the global tracer provider and W3C propagators. With the
+// "none" exporter spans are still propagated but never exported.
+func InitTracer(ctx context.Context, config variables.TracingConfig, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch config.Exporter {
+	case variables.TracingExporterOtlp:
+		exporter, err = otlptracegrpc.New(ctx,
+			otlptracegrpc.WithEndpoint(config.Endpoint),
+			otlptracegrpc.WithInsecure())
+	case variables.TracingExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	case variables.TracingExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	default:
+		return nil, fmt.Errorf("%s: %s", variables.TracingExporterError, config.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", variables.TracingExporterError, err)
+	}
+
+	serviceResource, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", variables.TracingExporterError, err)
+	}
+
+	sampleRatio := config.SampleRatio
+	if sampleRatio <= 0 {
+		sampleRatio = 1
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(serviceResource),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
@@ -1,10 +1,10 @@
 package util
 
 import (
+	"context"
 	"crypto/sha512"
 	"encoding/json"
 	"filmoteka/pkg/variables"
-	"fmt"
 	"io"
 	"log/slog"
 	"math/rand"
@@ -14,11 +14,34 @@ import (
 	"unicode/utf8"
 )
 
+// ContextLogger adds the request attributes stored by the request id
+// middleware to logger, so every layer logs with the same request id.
+func ContextLogger(ctx context.Context, logger *slog.Logger) *slog.Logger {
+	attrs, ok := ctx.Value(variables.LogAttrsKey).([]any)
+	if !ok {
+		return logger
+	}
+	return logger.With(attrs...)
+}
+
+// WithLogAttrs returns a copy of ctx whose request attributes also include attrs.
+func WithLogAttrs(ctx context.Context, attrs ...any) context.Context {
+	current, _ := ctx.Value(variables.LogAttrsKey).([]any)
+	merged := make([]any, 0, len(current)+len(attrs))
+	merged = append(merged, current...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, variables.LogAttrsKey, merged)
+}
+
+// SendResponse writes body as JSON. Successful responses are left to the
+// access log, failures are logged with their cause.
 func SendResponse(w http.ResponseWriter, r *http.Request, status int, body any, errorMessage string, handlerError error, logger *slog.Logger) {
+	logger = ContextLogger(r.Context(), logger)
+
 	jsonResponse, err := json.Marshal(body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		logger.Error(variables.JsonPackFailedError, r.Method, strconv.Itoa(http.StatusInternalServerError), r.URL.Path, err.Error())
+		logger.Error(variables.JsonPackFailedError, "method", r.Method, "status", http.StatusInternalServerError, "path", r.URL.Path, "error", err)
 		return
 	}
 
@@ -26,16 +49,21 @@ func SendResponse(w http.ResponseWriter, r *http.Request, status int, body any,
 	w.WriteHeader(status)
 	_, err = w.Write(jsonResponse)
 	if err != nil {
-		logger.Error(variables.ResponseSendFailedError, r.Method, strconv.Itoa(http.StatusInternalServerError), r.URL.Path, err.Error())
+		logger.Error(variables.ResponseSendFailedError, "method", r.Method, "status", status, "path", r.URL.Path, "error", err)
 		return
 	}
 
+	attrs := []any{"method", r.Method, "status", status, "path", r.URL.Path}
 	if handlerError != nil {
-		logger.Error(errorMessage, r.Method, strconv.Itoa(status), r.URL.Path, handlerError.Error())
-		return
+		attrs = append(attrs, "error", handlerError)
 	}
 
-	logger.Error(errorMessage, r.Method, strconv.Itoa(status), r.URL.Path, nil)
+	switch {
+	case status >= http.StatusInternalServerError:
+		logger.Error(errorMessage, attrs...)
+	case status >= http.StatusBadRequest:
+		logger.Warn(errorMessage, attrs...)
+	}
 }
 
 func GetRequestBody(w http.ResponseWriter, r *http.Request, requestObject any, logger *slog.Logger) error {
@@ -77,14 +105,17 @@ func HashPassword(password string) []byte {
 	return passwordByteSlice
 }
 
-func Pagination(r *http.Request) (uint64, uint64) {
+func Pagination(r *http.Request, config variables.PaginationConfig) (uint64, uint64) {
 	page, err := strconv.ParseUint(r.URL.Query().Get(variables.PaginationPageNumber), 10, 64)
-	if err != nil {
+	if err != nil || page == 0 {
 		page = 1
 	}
 	pageSize, err := strconv.ParseUint(r.URL.Query().Get(variables.PaginationPageSize), 10, 64)
-	if err != nil {
-		pageSize = 10
+	if err != nil || pageSize == 0 {
+		pageSize = config.DefaultPageSize
+	}
+	if pageSize > config.MaxPageSize {
+		pageSize = config.MaxPageSize
 	}
 
 	return pageSize, page
@@ -93,8 +124,24 @@ func Pagination(r *http.Request) (uint64, uint64) {
 func ValidateStringSize(validatedString string, begin int, end int, validateError string, logger *slog.Logger) error {
 	validateStringLength := utf8.RuneCountInString(validatedString)
 	if validateStringLength > end || validateStringLength < begin {
-		logger.Error(validateError)
-		return fmt.Errorf(validateError)
+		logger.Warn(validateError)
+		return InvalidInputError(validateError)
 	}
 	return nil
 }
+
+// InvalidInputError is a failed input check. It reads as the message of
+// the check and matches variables.ErrInvalidInput.
+func InvalidInputError(message string) error {
+	return invalidInputError(message)
+}
+
+type invalidInputError string
+
+func (err invalidInputError) Error() string {
+	return string(err)
+}
+
+func (err invalidInputError) Is(target error) bool {
+	return target == variables.ErrInvalidInput
+}
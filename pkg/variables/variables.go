@@ -1,10 +1,60 @@
 package variables
 
+import (
+	"errors"
+	"time"
+)
+
 // Server Errors
 const (
 	JsonPackFailedError     = "Failed to marshal JSON object"
 	ResponseSendFailedError = "Failed to send response to client"
 	ListenAndServeError     = "Failed to listen and serve"
+	ShutdownStartedMessage  = "Shutdown signal received, stopping"
+	ShutdownError           = "Graceful shutdown failed"
+	ShutdownFinishedMessage = "Shutdown finished"
+)
+
+// Health constants
+const (
+	HealthStatusUp        = "up"
+	HealthStatusDown      = "down"
+	HealthCheckTimeout    = 2 * time.Second
+	PostgresHealthChecker = "postgres"
+	RedisHealthChecker    = "redis"
+	AuthGrpcHealthChecker = "authorization_grpc"
+)
+
+// Metrics constants
+const (
+	MetricsNamespace     = "filmoteka"
+	MetricsRoute         = "/metrics"
+	UnmatchedRoute       = "unmatched"
+	LoginResultSuccess   = "success"
+	LoginResultFailure   = "failure"
+	MetricsRegisterError = "Metrics collector register failed"
+)
+
+// Tracing constants
+const (
+	TracerName            = "filmoteka"
+	TracingExporterOtlp   = "otlp"
+	TracingExporterStdout = "stdout"
+	TracingExporterNone   = "none"
+	TracingExporterError  = "Tracing exporter initialize failed"
+	FilmsServiceName      = "films"
+	AuthServiceName       = "authorization"
+	AuthorizationSpanName = "middleware.Authorization"
+	PermissionsSpanName   = "middleware.Permissions"
+)
+
+// Server constants
+const (
+	HttpReadTimeout   = 10 * time.Second
+	HttpWriteTimeout  = 15 * time.Second
+	HttpIdleTimeout   = 60 * time.Second
+	ShutdownTimeout   = 20 * time.Second
+	ListenNetworkType = "tcp"
 )
 
 // Authorization Errors
@@ -14,27 +64,37 @@ const (
 
 // API Messages
 const (
-	StatusMethodNotAllowedError = "Method not allowed"
-	StatusBadRequestError       = "Bad request"
-	StatusInternalServerError   = "Internal server error"
-	StatusUnauthorizedError     = "Unauthorized"
-	SessionCreateError          = "Session create failed"
-	StatusOkMessage             = "Succesful response"
-	SessionKilledError          = "Session killed failed"
-	SessionNotFoundError        = "Session not found"
-	UserAlreadyExistsError      = "User already exists"
-	StatusForbiddenError        = "Forbidden"
-	ActorsNotFoundError         = "Actors not found"
-	ActorNotAddedError          = "Actor not added"
-	ActorNotEditedError         = "Actor not edited"
-	FilmsNotFoundError          = "Films not found"
-	FilmNotFoundError           = "Film not found"
-	GrpcListenAndServeError     = "Failed grpc to listen and serve"
-	GrpcConnectError            = "Failed grpc to connect"
-	ActorNotDeletedError        = "Actor not deleted"
-	FilmNotAddedError           = "Film not added"
-	FilmNotEditedError          = "Film not edited"
-	FilmNotDeletedError         = "Film not deleted"
+	StatusBadRequestError     = "Bad request"
+	StatusInternalServerError = "Internal server error"
+	StatusUnauthorizedError   = "Unauthorized"
+	SessionCreateError        = "Session create failed"
+	StatusOkMessage           = "Succesful response"
+	SessionKilledError        = "Session killed failed"
+	SessionNotFoundError      = "Session not found"
+	UserAlreadyExistsError    = "User already exists"
+	StatusForbiddenError      = "Forbidden"
+	ActorsNotFoundError       = "Actors not found"
+	ActorNotAddedError        = "Actor not added"
+	ActorNotEditedError       = "Actor not edited"
+	FilmsNotFoundError        = "Films not found"
+	FilmNotFoundError         = "Film not found"
+	SearchQueryError          = "Search query must be from 1 to 200 characters"
+	SuggestLimitError         = "Suggestions limit must be from 1 to 20"
+	SuggestionsNotFoundError  = "Suggestions not found"
+	SearchError               = "Search failed"
+	SearchIndexSyncError      = "Search index sync failed, rebuild the index to catch up"
+	SearchIndexRebuildError   = "Search index rebuild failed"
+	SearchIndexRebuiltMessage = "Search index rebuilt"
+	SearchKindError           = "Unknown search document kind"
+	GrpcListenAndServeError   = "Failed grpc to listen and serve"
+	GrpcConnectError          = "Failed grpc to connect"
+	ActorNotDeletedError      = "Actor not deleted"
+	FilmNotAddedError         = "Film not added"
+	FilmNotEditedError        = "Film not edited"
+	FilmNotDeletedError       = "Film not deleted"
+	RoleNotChangedError       = "Role not changed"
+	ServiceNotReadyError      = "Service not ready"
+	ActorNotFoundError        = "Actor not found"
 )
 
 // Middleware types
@@ -45,19 +105,113 @@ type (
 
 // Middleware keys constants
 const (
-	UserIDKey    contextKey = "userId"
-	SessionIDKey sessionKey = "sessionId"
+	UserIDKey     contextKey = "userId"
+	SessionIDKey  sessionKey = "sessionId"
+	LogAttrsKey   contextKey = "logAttrs"
+	AccessInfoKey contextKey = "accessInfo"
+	RequestIDKey  contextKey = "requestId"
+)
+
+// Resource routing constants
+const (
+	PathIdParam           = "id"
+	PathIdError           = "Invalid id in path"
+	LocationHeader        = "Location"
+	DeprecationHeader     = "Deprecation"
+	LinkHeader            = "Link"
+	AllowHeader           = "Allow"
+	FilmsResourcePath     = "/api/v1/films"
+	ActorsResourcePath    = "/api/v1/actors"
+	MethodNotAllowedError = "Method not allowed"
+)
+
+// Optimistic concurrency constants
+const (
+	ETagHeader           = "ETag"
+	IfMatchHeader        = "If-Match"
+	IfMatchAny           = "*"
+	IfMatchRequiredError = "If-Match header required"
+	IfMatchError         = "Invalid If-Match header"
+)
+
+// Request logging constants
+const (
+	RequestIdHeader    = "X-Request-ID"
+	RequestIdMaxLength = 128
+	RequestIdLogKey    = "request_id"
+	TraceIdLogKey      = "trace_id"
+	UserIdLogKey       = "user_id"
+	AccessLogMessage   = "HTTP request"
 )
 
 // Configs types
 type (
+	FilmsConfig struct {
+		App        AppConfig                `yaml:"app"`
+		Database   RelationalDataBaseConfig `yaml:"database"`
+		Grpc       GrpcConfig               `yaml:"grpc"`
+		Pagination PaginationConfig         `yaml:"pagination" reload:"true"`
+		Trash      TrashConfig              `yaml:"trash"`
+		Search     SearchConfig             `yaml:"search"`
+	}
+
+	// SearchConfig backend is postgres, searching the catalogue tables, or
+	// memory, an index built at startup
+	SearchConfig struct {
+		Backend string `yaml:"backend"`
+	}
+
+	TrashConfig struct {
+		Retention     time.Duration `yaml:"retention" reload:"true"`
+		PurgeInterval time.Duration `yaml:"purge_interval"`
+	}
+
+	AuthorizationConfig struct {
+		App        AppConfig                `yaml:"app"`
+		Database   RelationalDataBaseConfig `yaml:"database"`
+		Cache      CacheDataBaseConfig      `yaml:"cache"`
+		Grpc       GrpcConfig               `yaml:"grpc"`
+		Pagination PaginationConfig         `yaml:"pagination" reload:"true"`
+		Session    SessionConfig            `yaml:"session" reload:"true"`
+	}
+
+	PaginationConfig struct {
+		DefaultPageSize uint64 `yaml:"default_page_size"`
+		MaxPageSize     uint64 `yaml:"max_page_size"`
+	}
+
+	SessionConfig struct {
+		TTL time.Duration `yaml:"ttl"`
+	}
+
 	AppConfig struct {
-		Address string `yaml:"address"`
+		Address     string            `yaml:"address"`
+		Tracing     TracingConfig     `yaml:"tracing"`
+		Logging     LoggingConfig     `yaml:"logging"`
+		Permissions map[string]string `yaml:"permissions" reload:"true"`
+	}
+
+	LoggingConfig struct {
+		Level          string            `yaml:"level" reload:"true"`
+		Format         string            `yaml:"format"`
+		Output         string            `yaml:"output"`
+		File           string            `yaml:"file"`
+		MaxSizeMB      int               `yaml:"max_size_mb"`
+		MaxAgeDays     int               `yaml:"max_age_days"`
+		MaxBackups     int               `yaml:"max_backups"`
+		RotateInterval time.Duration     `yaml:"rotate_interval"`
+		Modules        map[string]string `yaml:"modules" reload:"true"`
+	}
+
+	TracingConfig struct {
+		Exporter    string  `yaml:"exporter"`
+		Endpoint    string  `yaml:"endpoint"`
+		SampleRatio float64 `yaml:"sample_ratio"`
 	}
 
 	CacheDataBaseConfig struct {
-		Host     string `yaml:"host"`
-		Password string `yaml:"password"`
+		Addr     string `yaml:"addr"`
+		Password string `yaml:"password" secret:"true"`
 		DbNumber int    `yaml:"db"`
 		Timer    int    `yaml:"timer"`
 	}
@@ -65,20 +219,34 @@ type (
 	RelationalDataBaseConfig struct {
 		User         string `yaml:"user"`
 		DbName       string `yaml:"dbname"`
-		Password     string `yaml:"password"`
+		Password     string `yaml:"password" secret:"true"`
 		Host         string `yaml:"host"`
 		Port         int    `yaml:"port"`
 		Sslmode      string `yaml:"sslmode"`
 		MaxOpenConns int    `yaml:"max_open_conns"`
 		Timer        uint32 `yaml:"timer"`
+		AutoMigrate  bool   `yaml:"auto_migrate"`
 	}
 
 	GrpcConfig struct {
+		Host           string `yaml:"host"`
 		Port           string `yaml:"port"`
 		ConnectionType string `yaml:"connection_type"`
 	}
 )
 
+// Repository errors and failed input checks, matched with errors.Is by the
+// delivery layer
+var (
+	ErrNotFound           = errors.New("Record not found")
+	ErrAlreadyExists      = errors.New("Record already exists")
+	ErrReferenceNotFound  = errors.New("Referenced record not found")
+	ErrConstraintViolated = errors.New("Value violates a constraint")
+	ErrVersionMismatch    = errors.New("Record was changed since it was read")
+	ErrStillReferenced    = errors.New("Record is still referenced")
+	ErrInvalidInput       = errors.New("Invalid input")
+)
+
 // Cookies data
 const (
 	SessionCookieName = "session_id"
@@ -102,6 +270,9 @@ const (
 	FindProfileIdByLoginError             = "Find profile id by login failed:"
 	ProfileIdNotFoundByLoginError         = "Profile id not found:"
 	ProfileRoleNotFoundByLoginError       = "Profile role not found:"
+	ProfileRoleNotUpdatedError            = "Profile role update failed:"
+	SqlCloseError                         = "Close SQL connection failed:"
+	CacheCloseError                       = "Close cache connection failed:"
 )
 
 // Repository constants
@@ -109,7 +280,6 @@ const (
 	MaxRetries  = 5
 	UserRoleId  = 1
 	AdminRoleId = 2
-	PageSize    = 10
 )
 
 // Core Messages
@@ -126,7 +296,22 @@ const (
 	DescriptionSizeError            = "Description size must be from 1 to 1000"
 	FilmsListNotFoundError          = "Films list not found"
 	ActorNameSizeError              = "Actor name size must be from 1 to 150"
+	CrewRoleError                   = "Unknown crew role"
+	CharacterNameSizeError          = "Character name size must be up to 150"
+	BillingOrderError               = "Billing order must not be negative"
+	CrewDuplicateError              = "Crew member is listed twice in the same role"
+	RuntimeError                    = "Runtime must not be negative"
+	AgeRatingSizeError              = "Age rating size must be up to 10"
+	LanguageCodeError               = "Original language must be an ISO 639-1 code"
+	CountryCodeError                = "Countries must be ISO 3166-1 alpha-2 codes"
+	TagSizeError                    = "Tag size must be from 1 to 50"
+	GenreSlugError                  = "Genre slug must be 1 to 50 lowercase letters, digits and dashes"
+	GenreNameSizeError              = "Genre name size must be from 1 to 100"
 	GrpcRecievError                 = "gRPC recieve error"
+	InvalidRoleError                = "Unknown role"
+	ChangeProfileRoleError          = "Change profile role failed"
+	InvalidationsWatchError         = "Identity invalidations stream failed"
+	InvalidationsShutdownError      = "Identity invalidations stream closed by shutdown"
 )
 
 // Core variables
@@ -136,18 +321,120 @@ var (
 
 // Logger constants
 const (
-	ModuleLogger     = "Module"
-	CoreModuleLogger = "CoreModuleLogger"
+	ModuleLogger         = "Module"
+	CoreModuleLogger     = "CoreModuleLogger"
+	DeliveryModuleLogger = "DeliveryModuleLogger"
+	GrpcModuleLogger     = "GrpcModuleLogger"
+	IdentityModuleLogger = "IdentityModuleLogger"
+	LogOutputStdout      = "stdout"
+	LogOutputFile        = "file"
+	LogOutputBoth        = "both"
+	LogFormatJson        = "json"
+	LogFormatText        = "text"
+	LogLevelRoute        = "/admin/log-level"
+)
+
+// Logger messages
+const (
+	LogLevelError          = "Invalid log level"
+	LogModuleLevelError    = "Invalid log level for module"
+	LogOutputError         = "Unknown log output"
+	LogFormatError         = "Unknown log format"
+	LogRotateError         = "Log rotation failed"
+	LoggingInitError       = "Logging initialize failed"
+	LogLevelChangedMessage = "Log level changed"
 )
 
 // Main messages
 const (
-	ReadAuthConfigError      = "Read auth config failed"
-	ReadAuthSqlConfigError   = "Read auth sql config failed"
-	ReadFilmsSqlConfigError  = "Read films sql config failed"
-	ReadAuthCacheConfigError = "Read auth cache config failed"
-	ReadGrpcConfigError      = "Grpc config file error"
-	CoreInitializeError      = "Core initialize failed"
+	ReadAuthConfigError  = "Read auth config failed"
+	ReadFilmsConfigError = "Read films config failed"
+	CoreInitializeError  = "Core initialize failed"
+	FilmsRepositoryError = "Films repository initialize failed"
+)
+
+// Config constants
+const (
+	FilmsConfigPath         = "configs/FilmsConfig.yml"
+	AuthorizationConfigPath = "configs/AuthorizationConfig.yml"
+	ConfigPathFlag          = "config"
+	ConfigPathEnv           = "FILMOTEKA_CONFIG"
+	ConfigEnvPrefix         = "FILMOTEKA_"
+	ConfigSecretFileSuffix  = "_file"
+)
+
+// Config allowed values
+var (
+	TracingExporters    = []string{TracingExporterOtlp, TracingExporterStdout, TracingExporterNone}
+	LogFormats          = []string{LogFormatJson, LogFormatText}
+	LogOutputs          = []string{LogOutputStdout, LogOutputFile, LogOutputBoth}
+	SslModes            = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	GrpcConnectionTypes = []string{"tcp", "tcp4", "tcp6"}
+)
+
+// Config messages
+const (
+	ReadConfigFileError     = "Read config file failed"
+	ParseConfigFileError    = "Parse config file failed"
+	InvalidConfigError      = "Invalid configuration"
+	ConfigRequiredError     = "must be set"
+	ConfigUnknownValueError = "unknown value"
+	ConfigPortError         = "must be a port from 1 to 65535"
+	ConfigRatioError        = "must be from 0 to 1"
+	ConfigPositiveError     = "must be positive"
+	ConfigNegativeError     = "must not be negative"
+	ConfigMapPairError      = "expected key=value"
+	ConfigFieldTypeError    = "unsupported config field type"
+	ConfigRoleError         = "must be a known role"
+	ConfigPermissionError   = "unknown permission"
+	ConfigPageSizeError     = "must be positive and not above max_page_size"
+	ConfigReloadError       = "Config reload failed, keeping the current config"
+	ConfigRestartError      = "Config field can not change at runtime, restart to apply it"
+	ConfigReloadedMessage   = "Config reloaded"
+	ConfigWatchError        = "Config file watch failed, reload on SIGHUP only"
+)
+
+// Migration constants
+const (
+	MigrateCommand     = "migrate"
+	MigrateUp          = "up"
+	MigrateDown        = "down"
+	MigrateStatus      = "status"
+	MigrateCreate      = "create"
+	MigrateUsage       = "usage: migrate [flags] up | down [steps] | status | create <name>"
+	MigrationsLockId   = 7262031
+	AuthMigrationsDir  = "database/migrations/auth"
+	FilmsMigrationsDir = "database/migrations/films"
+)
+
+// Migration messages
+const (
+	MigrationsReadError           = "Read migrations failed"
+	MigrationNameError            = "Invalid migration file name"
+	MigrationVersionConflictError = "Migrations share a version"
+	MigrationUpMissingError       = "Migration has no up file"
+	MigrationApplyError           = "Apply migration failed"
+	MigrationRevertError          = "Revert migration failed"
+	MigrationIrreversibleError    = "Migration has no down file"
+	MigrationsLockError           = "Migrations lock failed"
+	MigrationsTableError          = "Schema migrations table failed"
+	MigrateCommandError           = "Migrate command failed"
+	MigrationAppliedMessage       = "Migration applied"
+	MigrationRevertedMessage      = "Migration reverted"
+)
+
+// Config reload constants
+const (
+	ConfigReloadDebounce = 200 * time.Millisecond
+)
+
+// Identity provider constants
+const (
+	IdentityCacheTTL          = 30 * time.Second
+	IdentityCacheSize         = 10000
+	IdentityWatchRetryTimeout = 5 * time.Second
+	IdentityLookupTimeout     = 5 * time.Second
+	InvalidationsBufferSize   = 256
 )
 
 // Regexp
@@ -158,12 +445,150 @@ const (
 // Roles
 const (
 	AdminRole = "admin"
+	UserRole  = "user"
+)
+
+// Permissions mapped to roles in the app config
+const (
+	PermissionCatalogWrite = "catalog.write"
+	PermissionLogLevel     = "admin.log_level"
+	PermissionRoleChange   = "roles.change"
+	PermissionTrashManage  = "trash.manage"
+	PermissionAuditRead    = "audit.read"
+	PermissionGenresManage = "genres.manage"
+	PermissionSearchManage = "search.manage"
+)
+
+var Permissions = []string{PermissionCatalogWrite, PermissionLogLevel, PermissionRoleChange, PermissionTrashManage, PermissionAuditRead, PermissionGenresManage, PermissionSearchManage}
+
+// Crew roles, a film's crew is returned grouped by them
+const (
+	CrewRoleActor           = "actor"
+	CrewRoleDirector        = "director"
+	CrewRoleWriter          = "writer"
+	CrewRoleProducer        = "producer"
+	CrewRoleComposer        = "composer"
+	CrewRoleCinematographer = "cinematographer"
+	CrewRoleEditor          = "editor"
+)
+
+var CrewRoles = []string{
+	CrewRoleActor,
+	CrewRoleDirector,
+	CrewRoleWriter,
+	CrewRoleProducer,
+	CrewRoleComposer,
+	CrewRoleCinematographer,
+	CrewRoleEditor,
+}
+
+// Trash constants
+const (
+	TrashFilmType  = "film"
+	TrashActorType = "actor"
+)
+
+// Trash messages
+const (
+	TrashListError      = "Trash list failed"
+	TrashRestoreError   = "Restore from trash failed"
+	TrashPurgeError     = "Purge from trash failed"
+	TrashItemTypeError  = "Unknown trash item type"
+	TrashRetentionError = "Trash retention purge failed"
+	TrashPurgedMessage  = "Expired trash purged"
+)
+
+// Genre dictionary messages
+const (
+	GenresListError      = "Genres list failed"
+	GenreNotAddedError   = "Genre not added"
+	GenreNotEditedError  = "Genre not edited"
+	GenreNotDeletedError = "Genre not deleted"
+)
+
+// Film list filter params
+const (
+	FilmGenreParam        = "genre"
+	FilmTagParam          = "tag"
+	FilmCountryParam      = "country"
+	FilmMinRuntimeParam   = "min_runtime"
+	FilmMaxRuntimeParam   = "max_runtime"
+	FilmAgeRatingParam    = "age_rating"
+	FilmLanguageParam     = "language"
+	FilmTitlePrefixParam  = "title_prefix"
+	FilmMinRatingParam    = "min_rating"
+	FilmMaxRatingParam    = "max_rating"
+	FilmReleasedFromParam = "released_from"
+	FilmReleasedToParam   = "released_to"
+	FilmCrewParam         = "crew"
+	FilmCrewMatchParam    = "crew_match"
+	FilmSortParam         = "sort"
+	FilmSortByParam       = "sort_by"
+	FilmFilterError       = "Invalid films filter"
+	FilmSortError         = "Unknown sort key"
+	GenresResourcePath    = "/api/v1/genres"
+)
+
+// Films list sort keys, a leading dash sorts descending
+const (
+	FilmSortTitle       = "title"
+	FilmSortRating      = "rating"
+	FilmSortReleaseDate = "release_date"
+	FilmSortRuntime     = "runtime"
+	FilmSortDescending  = "-"
+	CrewMatchAny        = "any"
+	CrewMatchAll        = "all"
+	FilmsMaxCrewFilter  = 20
+)
+
+// Film revisions messages
+const (
+	FilmRevisionsError = "Film revisions list failed"
+	FilmRevertError    = "Film revert failed"
+)
+
+// Audit actions and entities
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+
+	AuditEntityFilm  = "film"
+	AuditEntityActor = "actor"
+	AuditEntityGenre = "genre"
+	AuditEntityUser  = "user"
+)
+
+// Audit query params
+const (
+	AuditActorParam     = "actor_id"
+	AuditActionParam    = "action"
+	AuditEntityParam    = "entity"
+	AuditEntityIdParam  = "entity_id"
+	AuditRequestIdParam = "request_id"
+	AuditFromParam      = "from"
+	AuditToParam        = "to"
+)
+
+// Audit messages
+const (
+	AuditRecordError = "Audit entry not recorded"
+	AuditListError   = "Audit log list failed"
+	AuditFilterError = "Invalid audit filter"
 )
 
 // Query params
 const (
-	PaginationPageNumber = "page"
-	PaginationPageSize   = "page_size"
+	PaginationPageNumber  = "page"
+	PaginationPageSize    = "page_size"
+	SearchQueryParam      = "q"
+	SearchFilmNameParam   = "film_name"
+	SearchActorNameParam  = "actor_name"
+	SuggestLimitParam     = "limit"
+	SearchFilmsPageParam  = "films_page"
+	SearchActorsPageParam = "actors_page"
 )
 
 // Validate params
@@ -176,4 +601,65 @@ const (
 	FilmRatingEnd        = 10
 	ActorNameBegin       = 1
 	ActorNameEnd         = 150
+	CharacterNameBegin   = 0
+	CharacterNameEnd     = 150
+	AgeRatingBegin       = 0
+	AgeRatingEnd         = 10
+	TagBegin             = 1
+	TagEnd               = 50
+	GenreSlugEnd         = 50
+	GenreNameBegin       = 1
+	GenreNameEnd         = 100
+	SearchQueryBegin     = 1
+	SearchQueryEnd       = 200
+)
+
+// Full text search, film hits through the crew rank below hits on the
+// film itself and fuzzy name hits below both
+const (
+	SearchTextConfig      = "russian"
+	SearchCrewRankWeight  = 0.5
+	SearchFuzzyRankWeight = 0.1
+	SearchHeadlineOptions = "StartSel=<b>, StopSel=</b>, MaxWords=35, MinWords=15, MaxFragments=2"
+)
+
+// Search backends and the kinds of documents they index
+const (
+	SearchBackendPostgres = "postgres"
+	SearchBackendMemory   = "memory"
+	SearchFilmKind        = "film"
+	SearchActorKind       = "actor"
+)
+
+var SearchBackends = []string{SearchBackendPostgres, SearchBackendMemory}
+
+// The memory index ranks by BM25 with title and description weights like
+// the ones of the postgres backend
+const (
+	SearchTitleWeight    = 1.0
+	SearchTextWeight     = 0.4
+	SearchBm25K1         = 1.2
+	SearchBm25B          = 0.75
+	SearchSnippetWords   = 35
+	SearchHighlightStart = "<b>"
+	SearchHighlightStop  = "</b>"
+)
+
+// Search facets count the matching films by genre, release decade and
+// rating bucket of the given width
+const (
+	SearchFacetGenre        = "genre"
+	SearchFacetDecade       = "decade"
+	SearchFacetRating       = "rating"
+	SearchRatingBucketWidth = 2
+)
+
+// Suggestions are ranked by name similarity and, with the given weight, by
+// popularity
+const (
+	SuggestFilmType         = "film"
+	SuggestActorType        = "actor"
+	SuggestPopularityWeight = 0.2
+	SuggestLimitDefault     = 10
+	SuggestLimitMax         = 20
 )